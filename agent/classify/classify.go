@@ -0,0 +1,263 @@
+// Package classify rozpoznaje procesy, usługi i kontenery związane z LLM na podstawie
+// deklaratywnych reguł. Reguły z konfiguracji agenta są sprawdzane przed wbudowanymi;
+// wynik wskazuje pierwszą dopasowaną regułę i kategorię obciążenia.
+package classify
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Kategorie obciążeń związanych z LLM
+const (
+	CategoryInferenceServer = "inference_server" // Serwer udostępniający model (Ollama, vLLM, TGI)
+	CategoryTraining        = "training"         // Trenowanie lub dostrajanie modelu
+	CategoryVectorDB        = "vector_db"        // Baza wektorowa (Qdrant, Milvus, Chroma)
+	CategoryEmbedding       = "embedding"        // Serwer wektorów osadzeń
+	CategoryOther           = "other"            // Inne obciążenie ML, np. skrypt korzystający z PyTorch
+	CategoryNone            = "none"             // Wyklucza dopasowanie; kończy sprawdzanie reguł
+)
+
+// categories to kategorie dopuszczalne w regułach
+var categories = map[string]bool{
+	CategoryInferenceServer: true,
+	CategoryTraining:        true,
+	CategoryVectorDB:        true,
+	CategoryEmbedding:       true,
+	CategoryOther:           true,
+	CategoryNone:            true,
+}
+
+// Rule to reguła klasyfikacji. Wyrażenia regularne nie rozróżniają wielkości liter.
+// Reguła pasuje, gdy spełnione są wszystkie podane kryteria; w obrębie listy
+// wystarczy dopasowanie jednego elementu.
+type Rule struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	Any      []string `json:"any,omitempty"`     // Nazwa, opis, linia poleceń lub obraz
+	Names    []string `json:"names,omitempty"`   // Nazwa procesu, usługi lub kontenera albo opis jednostki
+	Cmdline  []string `json:"cmdline,omitempty"` // Linia poleceń
+	Images   []string `json:"images,omitempty"`  // Obraz kontenera
+	Labels   []string `json:"labels,omitempty"`  // Etykiety kontenera w postaci klucz=wartość
+	Env      []string `json:"env,omitempty"`     // Zmienne środowiskowe w postaci NAZWA=wartość
+	Files    []string `json:"files,omitempty"`   // Otwarte pliki i ścieżki zamontowanych wolumenów
+	Ports    []uint32 `json:"ports,omitempty"`   // Porty nasłuchujące lub opublikowane
+	GPU      bool     `json:"gpu,omitempty"`     // Czy wymagane jest użycie GPU
+}
+
+// Subject to dane procesu, usługi lub kontenera sprawdzane przez reguły
+type Subject struct {
+	Names   []string
+	Cmdline string
+	Images  []string
+	Labels  []string
+	Env     []string
+	Files   []string
+	Ports   []uint32
+	GPU     bool
+}
+
+// compiledRule to reguła ze skompilowanymi wyrażeniami regularnymi
+type compiledRule struct {
+	Rule
+	any     []*regexp.Regexp
+	names   []*regexp.Regexp
+	cmdline []*regexp.Regexp
+	images  []*regexp.Regexp
+	labels  []*regexp.Regexp
+	env     []*regexp.Regexp
+	files   []*regexp.Regexp
+}
+
+// Classifier przypisuje procesom i usługom kategorię według listy reguł
+type Classifier struct {
+	rules []compiledRule
+}
+
+// New tworzy klasyfikator z regułami użytkownika sprawdzanymi przed wbudowanymi.
+// Zwraca wszystkie znalezione błędy reguł.
+func New(custom []Rule) (*Classifier, error) {
+	rules := append(append([]Rule(nil), custom...), DefaultRules()...)
+
+	c := &Classifier{rules: make([]compiledRule, 0, len(rules))}
+	problems := make([]string, 0)
+	for i, rule := range rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("reguła %d (%s): %v", i+1, rule.Name, err))
+			continue
+		}
+		c.rules = append(c.rules, compiled)
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return c, nil
+}
+
+// Default zwraca klasyfikator z samymi wbudowanymi regułami
+func Default() *Classifier {
+	c, err := New(nil)
+	if err != nil {
+		panic(fmt.Sprintf("niepoprawne wbudowane reguły LLM: %v", err))
+	}
+	return c
+}
+
+// Validate sprawdza reguły bez tworzenia klasyfikatora
+func Validate(rules []Rule) error {
+	problems := make([]string, 0)
+	for i, rule := range rules {
+		if _, err := compile(rule); err != nil {
+			problems = append(problems, fmt.Sprintf("llm_rules[%d] (%s): %v", i, rule.Name, err))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// compile sprawdza regułę i kompiluje jej wyrażenia regularne
+func compile(rule Rule) (compiledRule, error) {
+	compiled := compiledRule{Rule: rule}
+
+	if rule.Name == "" {
+		return compiled, fmt.Errorf("brak nazwy reguły")
+	}
+	if !categories[rule.Category] {
+		return compiled, fmt.Errorf("nieznana kategoria %q", rule.Category)
+	}
+	if len(rule.Any)+len(rule.Names)+len(rule.Cmdline)+len(rule.Images)+len(rule.Labels)+len(rule.Env)+len(rule.Files)+len(rule.Ports) == 0 && !rule.GPU {
+		return compiled, fmt.Errorf("reguła nie zawiera żadnego kryterium")
+	}
+
+	var err error
+	for _, field := range []struct {
+		patterns []string
+		target   *[]*regexp.Regexp
+	}{
+		{rule.Any, &compiled.any},
+		{rule.Names, &compiled.names},
+		{rule.Cmdline, &compiled.cmdline},
+		{rule.Images, &compiled.images},
+		{rule.Labels, &compiled.labels},
+		{rule.Env, &compiled.env},
+		{rule.Files, &compiled.files},
+	} {
+		if *field.target, err = compilePatterns(field.patterns); err != nil {
+			return compiled, err
+		}
+	}
+
+	return compiled, nil
+}
+
+// compilePatterns kompiluje wyrażenia regularne bez rozróżniania wielkości liter
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("niepoprawne wyrażenie %q: %v", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// Classify zwraca pierwszą regułę pasującą do obiektu lub nil, gdy obiekt nie jest
+// związany z LLM (żadna reguła nie pasuje albo pasuje reguła kategorii "none")
+func (c *Classifier) Classify(subject Subject) *models.LLMClassification {
+	for i := range c.rules {
+		rule := &c.rules[i]
+		if !rule.matches(&subject) {
+			continue
+		}
+		if rule.Category == CategoryNone {
+			return nil
+		}
+		return &models.LLMClassification{Rule: rule.Name, Category: rule.Category}
+	}
+	return nil
+}
+
+// matches sprawdza wszystkie kryteria reguły
+func (r *compiledRule) matches(s *Subject) bool {
+	if r.any != nil && !matchAny(r.any, append(append(append([]string(nil), s.Names...), s.Cmdline), s.Images...)) {
+		return false
+	}
+	if r.names != nil && !matchAny(r.names, s.Names) {
+		return false
+	}
+	if r.cmdline != nil && !matchAny(r.cmdline, []string{s.Cmdline}) {
+		return false
+	}
+	if r.images != nil && !matchAny(r.images, s.Images) {
+		return false
+	}
+	if r.labels != nil && !matchAny(r.labels, s.Labels) {
+		return false
+	}
+	if r.env != nil && !matchAny(r.env, s.Env) {
+		return false
+	}
+	if r.files != nil && !matchAny(r.files, s.Files) {
+		return false
+	}
+	if len(r.Ports) > 0 && !matchPort(r.Ports, s.Ports) {
+		return false
+	}
+	return !r.GPU || s.GPU
+}
+
+// LabelList zamienia etykiety na posortowaną listę w postaci klucz=wartość
+func LabelList(labels map[string]string) []string {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	list := make([]string, 0, len(labels))
+	for key, value := range labels {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// matchAny sprawdza, czy któreś wyrażenie pasuje do któregoś z niepustych tekstów
+func matchAny(patterns []*regexp.Regexp, values []string) bool {
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		for _, pattern := range patterns {
+			if pattern.MatchString(value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPort sprawdza, czy obiekt używa któregoś z portów reguły
+func matchPort(rulePorts, ports []uint32) bool {
+	for _, port := range ports {
+		for _, rulePort := range rulePorts {
+			if port == rulePort {
+				return true
+			}
+		}
+	}
+	return false
+}
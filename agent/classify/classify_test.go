@@ -0,0 +1,105 @@
+package classify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultRules(t *testing.T) {
+	c := Default()
+
+	tests := []struct {
+		name     string
+		subject  Subject
+		rule     string
+		category string
+	}{
+		{"serwer Ollama", Subject{Names: []string{"ollama"}, Cmdline: "/usr/local/bin/ollama serve"}, "ollama", CategoryInferenceServer},
+		{"obraz vLLM", Subject{Names: []string{"api"}, Images: []string{"vllm/vllm-openai:v0.6.3"}}, "vllm", CategoryInferenceServer},
+		{"zmienna Ollama", Subject{Names: []string{"llm"}, Env: []string{"OLLAMA_HOST=0.0.0.0"}}, "ollama-env", CategoryInferenceServer},
+		{"port Qdrant", Subject{Names: []string{"search"}, Ports: []uint32{6333}}, "qdrant-port", CategoryVectorDB},
+		{"serwer osadzeń", Subject{Images: []string{"ghcr.io/huggingface/text-embeddings-inference:1.5"}}, "text-embeddings-inference", CategoryEmbedding},
+		{"trenowanie", Subject{Names: []string{"python3"}, Cmdline: "torchrun --nproc_per_node 8 train.py"}, "torch-distributed", CategoryTraining},
+		{"skrypt PyTorch", Subject{Names: []string{"python3"}, Cmdline: "python3 -c import torch"}, "python-ml", CategoryOther},
+		{"plik modelu", Subject{Names: []string{"server"}, Files: []string{"/models/mistral-7b.Q4_K_M.gguf"}}, "model-files", CategoryOther},
+		{"Python na GPU", Subject{Names: []string{"python3"}, Cmdline: "python3 app.py", GPU: true}, "python-gpu", CategoryOther},
+		{"obraz Mistral", Subject{Names: []string{"chat"}, Images: []string{"ghcr.io/mistralai/mistral-inference:1.0"}}, "llm-image", CategoryOther},
+		{"obraz ONNX Runtime", Subject{Names: []string{"worker"}, Images: []string{"mcr.microsoft.com/onnxruntime/server"}}, "llm-image", CategoryOther},
+		{"etykieta LangChain", Subject{Names: []string{"api"}, Images: []string{"python:3.12"}, Labels: LabelList(map[string]string{"com.docker.compose.project": "langchain-rag"})}, "llm-label", CategoryOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := c.Classify(tt.subject)
+			if got == nil || got.Rule != tt.rule || got.Category != tt.category {
+				t.Errorf("Niepoprawna klasyfikacja: got %+v, want %s/%s", got, tt.rule, tt.category)
+			}
+		})
+	}
+}
+
+func TestDefaultRulesNoFalsePositives(t *testing.T) {
+	c := Default()
+
+	// Krótkie ciągi w nazwach popularnych usług nie są dopasowaniem
+	for _, subject := range []Subject{
+		{Names: []string{"postfix-mail", "Postfix Mail Transport Agent"}},
+		{Names: []string{"nginx"}, Cmdline: "nginx -g daemon off;", Images: []string{"nginx:1.27"}, Files: []string{"/usr/share/nginx/html/index.html"},
+			Labels: []string{"maintainer=NGINX Docker Maintainers <docker-maint@nginx.com>"}},
+		{Names: []string{"fullmoon"}, Images: []string{"registry.local/fullmoon:2"}},
+		{Names: []string{"systemd-timesyncd", "Network Time Synchronization"}},
+		{Names: []string{"python3"}, Cmdline: "python3 -m http.server"},
+		{Names: []string{"nvidia-smi"}},
+	} {
+		if got := c.Classify(subject); got != nil {
+			t.Errorf("Nieoczekiwana klasyfikacja %+v dla %+v", got, subject)
+		}
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	c, err := New([]Rule{
+		// Wyłączenie wbudowanej reguły dla narzędzia o mylącej nazwie
+		{Name: "ollama-exporter", Category: CategoryNone, Names: []string{`^ollama-exporter$`}},
+		// Wszystkie kryteria reguły muszą być spełnione
+		{Name: "rag-api", Category: CategoryOther, Names: []string{`^rag`}, Ports: []uint32{8080}},
+	})
+	if err != nil {
+		t.Fatalf("Błąd tworzenia klasyfikatora: %v", err)
+	}
+
+	if got := c.Classify(Subject{Names: []string{"ollama-exporter"}}); got != nil {
+		t.Errorf("Oczekiwano wykluczenia przez regułę none: got %+v", got)
+	}
+	if got := c.Classify(Subject{Names: []string{"ollama"}}); got == nil || got.Rule != "ollama" {
+		t.Errorf("Reguła wbudowana powinna nadal działać: got %+v", got)
+	}
+	if got := c.Classify(Subject{Names: []string{"RAG-api"}, Ports: []uint32{8080}}); got == nil || got.Rule != "rag-api" {
+		t.Errorf("Oczekiwano dopasowania reguły użytkownika: got %+v", got)
+	}
+	if got := c.Classify(Subject{Names: []string{"rag-api"}, Ports: []uint32{9090}}); got != nil {
+		t.Errorf("Reguła nie powinna pasować bez portu: got %+v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := Validate([]Rule{
+		{Name: "ok", Category: CategoryEmbedding, Names: []string{`embed`}},
+		{Name: "", Category: CategoryEmbedding, Names: []string{`x`}},
+		{Name: "kategoria", Category: "rag", Names: []string{`x`}},
+		{Name: "pusta", Category: CategoryOther},
+		{Name: "wyrażenie", Category: CategoryOther, Cmdline: []string{`(`}},
+		{Name: "etykiety", Category: CategoryOther, Labels: []string{`^ai\.model=`}},
+	})
+	if err == nil {
+		t.Fatal("Oczekiwano błędów reguł")
+	}
+	for _, want := range []string{"llm_rules[1]", `llm_rules[2] (kategoria): nieznana kategoria "rag"`, "llm_rules[3] (pusta)", "llm_rules[4] (wyrażenie)"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Błąd nie zawiera %q: %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "llm_rules[0]") || strings.Contains(err.Error(), "llm_rules[5]") {
+		t.Errorf("Poprawna reguła zgłoszona jako błędna: %v", err)
+	}
+}
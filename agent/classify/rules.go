@@ -0,0 +1,51 @@
+package classify
+
+// llmKeywords to nazwy modeli i bibliotek LLM szukane w obrazach i etykietach kontenerów
+const llmKeywords = `\b(llama|mistral|falcon|vicuna|gpt|bert|llm|langchain|openai|huggingface|transformers?|pytorch|tensorflow|onnxruntime)`
+
+// DefaultRules zwraca wbudowane reguły klasyfikacji. Wzorce dopasowują całe nazwy
+// narzędzi, aby krótkie ciągi (np. "ai" w "mail") nie dawały fałszywych trafień.
+func DefaultRules() []Rule {
+	return []Rule{
+		// Serwery modeli
+		{Name: "ollama", Category: CategoryInferenceServer, Any: []string{`ollama`}},
+		{Name: "ollama-env", Category: CategoryInferenceServer, Env: []string{`^OLLAMA_(HOST|MODELS|NUM_PARALLEL|KEEP_ALIVE)=`}},
+		{Name: "ollama-port", Category: CategoryInferenceServer, Ports: []uint32{11434}},
+		{Name: "vllm", Category: CategoryInferenceServer, Any: []string{`\bvllm\b`}},
+		{Name: "text-generation-inference", Category: CategoryInferenceServer, Any: []string{`text-generation-(inference|launcher|server|router)`}},
+		{Name: "llama.cpp", Category: CategoryInferenceServer, Any: []string{`llama[.-]?cpp`, `\bllama-server\b`, `\bllamafile\b`}},
+		{Name: "triton", Category: CategoryInferenceServer, Any: []string{`tritonserver`}},
+		{Name: "localai", Category: CategoryInferenceServer, Any: []string{`\blocal-?ai\b`}},
+		{Name: "sglang", Category: CategoryInferenceServer, Any: []string{`\bsglang\b`}},
+		{Name: "lmdeploy", Category: CategoryInferenceServer, Any: []string{`\blmdeploy\b`}},
+
+		// Serwery wektorów osadzeń
+		{Name: "text-embeddings-inference", Category: CategoryEmbedding, Any: []string{`text-embeddings-(inference|router)`}},
+		{Name: "infinity-embeddings", Category: CategoryEmbedding, Any: []string{`infinity[-_]emb`}},
+		{Name: "sentence-transformers", Category: CategoryEmbedding, Any: []string{`sentence[-_]transformers`}},
+
+		// Bazy wektorowe
+		{Name: "qdrant", Category: CategoryVectorDB, Any: []string{`qdrant`}},
+		{Name: "qdrant-port", Category: CategoryVectorDB, Ports: []uint32{6333, 6334}},
+		{Name: "milvus", Category: CategoryVectorDB, Any: []string{`milvus`}},
+		{Name: "milvus-port", Category: CategoryVectorDB, Ports: []uint32{19530}},
+		{Name: "weaviate", Category: CategoryVectorDB, Any: []string{`weaviate`}},
+		{Name: "chroma", Category: CategoryVectorDB, Any: []string{`\bchroma(db)?\b`}},
+		{Name: "pgvector", Category: CategoryVectorDB, Images: []string{`pgvector`}},
+
+		// Trenowanie i dostrajanie
+		{Name: "torch-distributed", Category: CategoryTraining, Cmdline: []string{`\btorchrun\b`, `torch\.distributed\.(run|launch)`}},
+		{Name: "deepspeed", Category: CategoryTraining, Cmdline: []string{`\bdeepspeed\b`}},
+		{Name: "accelerate", Category: CategoryTraining, Cmdline: []string{`\baccelerate launch\b`}},
+		{Name: "fine-tuning", Category: CategoryTraining, Any: []string{`\baxolotl\b`, `llama-?factory`, `\bunsloth\b`}},
+
+		// Pozostałe obciążenia ML
+		{Name: "python-ml", Category: CategoryOther, Cmdline: []string{`python.*\b(torch|tensorflow|transformers|huggingface|llama|whisper|bert|gpt[\w.-]*|t5)\b`}},
+		{Name: "ggml", Category: CategoryOther, Any: []string{`ggml`}},
+		{Name: "huggingface-env", Category: CategoryOther, Env: []string{`^(HF_HOME|HF_TOKEN|HUGGING_FACE_HUB_TOKEN|TRANSFORMERS_CACHE)=`}},
+		{Name: "model-files", Category: CategoryOther, Files: []string{`\.(gguf|ggml|safetensors)$`, `model[^/]*\.(bin|pt|pth)$`}},
+		{Name: "python-gpu", Category: CategoryOther, Cmdline: []string{`python`}, GPU: true},
+		{Name: "llm-image", Category: CategoryOther, Images: []string{llmKeywords}},
+		{Name: "llm-label", Category: CategoryOther, Labels: []string{llmKeywords}},
+	}
+}
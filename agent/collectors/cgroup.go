@@ -0,0 +1,95 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// containerCgroupName rozpoznaje element ścieżki cgroup kontenera, np. "<id>",
+// "docker-<id>.scope", "cri-containerd-<id>.scope", "crio-<id>.scope" lub "libpod-<id>.scope"
+var containerCgroupName = regexp.MustCompile(`^(?:[a-z0-9-]+-)?([0-9a-f]{64})(?:\.scope)?$`)
+
+// processCgroup opisuje przynależność procesu do cgroup
+type processCgroup struct {
+	Path        string // Ścieżka w hierarchii cgroup v2 lub name=systemd (v1)
+	ContainerID string // Identyfikator kontenera, jeśli proces działa w kontenerze
+	SystemdUnit string // Jednostka systemd (usługa lub scope), do której należy proces
+}
+
+// procRoot zwraca katalog systemu plików proc; zmienna HOST_PROC pozwala wskazać
+// proc hosta, gdy agent działa w kontenerze (tak jak w gopsutil)
+func procRoot() string {
+	if root := os.Getenv("HOST_PROC"); root != "" {
+		return root
+	}
+	return "/proc"
+}
+
+// sysRoot zwraca katalog systemu plików sysfs; zmienna HOST_SYS pozwala wskazać
+// sysfs hosta (tak jak w gopsutil)
+func sysRoot() string {
+	if root := os.Getenv("HOST_SYS"); root != "" {
+		return root
+	}
+	return "/sys"
+}
+
+// readProcessCgroup odczytuje cgroup procesu z <root>/<pid>/cgroup
+func readProcessCgroup(root string, pid int32) (processCgroup, error) {
+	data, err := os.ReadFile(filepath.Join(root, strconv.Itoa(int(pid)), "cgroup"))
+	if err != nil {
+		return processCgroup{}, err
+	}
+	return parseProcessCgroup(string(data)), nil
+}
+
+// parseProcessCgroup przetwarza zawartość pliku /proc/<pid>/cgroup. Każdy wiersz ma
+// postać "hierarchia:kontrolery:ścieżka"; w cgroup v2 jest to jeden wiersz "0::ścieżka",
+// a w v1 (i trybie hybrydowym) ścieżkę systemd zawiera hierarchia "name=systemd".
+func parseProcessCgroup(data string) processCgroup {
+	var unified, systemd, first string
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		hierarchy, controllers, path := parts[0], parts[1], parts[2]
+
+		switch {
+		case hierarchy == "0" && controllers == "":
+			unified = path
+		case controllers == "name=systemd":
+			systemd = path
+		case first == "":
+			first = path
+		}
+	}
+
+	// Ścieżka v2 ma pierwszeństwo, ale w trybie hybrydowym bywa pusta ("/");
+	// wtedy używana jest ścieżka hierarchii systemd
+	cgroup := processCgroup{Path: first}
+	for _, path := range []string{systemd, unified} {
+		if path != "" && (path != "/" || cgroup.Path == "") {
+			cgroup.Path = path
+		}
+	}
+
+	// Szukaj od końca ścieżki, bo najbardziej zagnieżdżony element opisuje proces
+	elements := strings.Split(cgroup.Path, "/")
+	for i := len(elements) - 1; i >= 0; i-- {
+		element := elements[i]
+		if cgroup.ContainerID == "" && !strings.Contains(element, "conmon") {
+			if match := containerCgroupName.FindStringSubmatch(element); match != nil {
+				cgroup.ContainerID = match[1]
+			}
+		}
+		if cgroup.SystemdUnit == "" && (strings.HasSuffix(element, ".service") || strings.HasSuffix(element, ".scope")) {
+			cgroup.SystemdUnit = element
+		}
+	}
+
+	return cgroup
+}
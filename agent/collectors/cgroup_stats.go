@@ -0,0 +1,292 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// cgroupCPUSample to próbka licznika CPU cgroup
+type cgroupCPUSample struct {
+	usageUsec uint64
+	at        time.Time
+}
+
+// CgroupEnricher uzupełnia usługi i kontenery o zużycie zasobów odczytane z cgroup v2:
+// CPU, pamięć, operacje I/O, liczbę procesów i PSI. W przeciwieństwie do statystyk
+// głównego procesu obejmuje to wszystkie procesy potomne usługi. Na hostach bez
+// cgroup v2 moduł nie zmienia stanu.
+type CgroupEnricher struct {
+	root        string
+	procRoot    string
+	memoryTotal func() uint64
+
+	// Poprzednie próbki CPU według ścieżki cgroup, potrzebne do wyliczenia użycia CPU
+	mu         sync.Mutex
+	cpuSamples map[string]cgroupCPUSample
+}
+
+// NewCgroupEnricher tworzy nowy moduł odczytujący zużycie zasobów z cgroup v2.
+// Zmienna HOST_SYS pozwala wskazać katalog /sys hosta (tak jak w gopsutil).
+func NewCgroupEnricher() *CgroupEnricher {
+	return &CgroupEnricher{
+		root:     filepath.Join(sysRoot(), "fs", "cgroup"),
+		procRoot: procRoot(),
+		memoryTotal: func() uint64 {
+			if vm, err := mem.VirtualMemory(); err == nil {
+				return vm.Total
+			}
+			return 0
+		},
+		cpuSamples: make(map[string]cgroupCPUSample),
+	}
+}
+
+// Name zwraca nazwę modułu
+func (e *CgroupEnricher) Name() string {
+	return "cgroups"
+}
+
+// Enrich odczytuje statystyki cgroup usług systemd i kontenerów. Użycie CPU i pamięci
+// usługi jest zastępowane wartościami dla całej cgroup; użycie CPU jest liczone względem
+// poprzedniego zbierania, więc pierwsze zbieranie go nie podaje.
+func (e *CgroupEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	// Tylko cgroup v2 (zunifikowana hierarchia) ma plik cgroup.controllers w katalogu głównym
+	if _, err := os.Stat(filepath.Join(e.root, "cgroup.controllers")); err != nil {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	memoryTotal := e.memoryTotal()
+	seen := make(map[string]bool)
+
+	for i := range state.Services {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		service := &state.Services[i]
+		path := e.servicePath(service)
+		if path == "" {
+			continue
+		}
+
+		stats, err := readCgroupStats(e.root, path)
+		if err != nil {
+			continue
+		}
+		service.Cgroup = stats
+
+		// Użycie CPU względem poprzedniej próbki tej samej cgroup
+		current := cgroupCPUSample{usageUsec: stats.CPUUsageUsec, at: now}
+		previous, ok := e.cpuSamples[path]
+		e.cpuSamples[path] = current
+		seen[path] = true
+		if ok {
+			service.CPUPercent = cgroupCPUPercent(previous, current)
+		}
+
+		// Użycie pamięci względem limitu cgroup lub, bez limitu, całej pamięci hosta
+		limit := stats.MemoryMax
+		if limit == 0 {
+			limit = memoryTotal
+		}
+		if limit > 0 {
+			service.MemoryPercent = float32(float64(stats.MemoryCurrent) / float64(limit) * 100)
+		}
+	}
+
+	// Zapomnij próbki cgroup, które zniknęły
+	for path := range e.cpuSamples {
+		if !seen[path] {
+			delete(e.cpuSamples, path)
+		}
+	}
+
+	return nil
+}
+
+// servicePath zwraca ścieżkę cgroup usługi: grupę jednostki systemd lub cgroup
+// głównego procesu kontenera. Pozostałe usługi są pomijane, bo ich główny proces
+// może należeć do cgroup współdzielonej z innymi procesami (np. sesji użytkownika).
+func (e *CgroupEnricher) servicePath(service *models.Service) string {
+	if service.Systemd != nil && service.Systemd.ControlGroup != "" {
+		return service.Systemd.ControlGroup
+	}
+
+	if service.IsContainer() && service.PID > 0 {
+		if cgroup, err := readProcessCgroup(e.procRoot, service.PID); err == nil && cgroup.Path != "/" {
+			return cgroup.Path
+		}
+	}
+
+	return ""
+}
+
+// cgroupCPUPercent wylicza użycie CPU cgroup między dwiema próbkami (100% = jeden rdzeń)
+func cgroupCPUPercent(previous, current cgroupCPUSample) float64 {
+	elapsed := current.at.Sub(previous.at).Microseconds()
+	if current.usageUsec < previous.usageUsec || elapsed <= 0 {
+		return 0
+	}
+	return float64(current.usageUsec-previous.usageUsec) / float64(elapsed) * 100
+}
+
+// readCgroupStats odczytuje statystyki cgroup v2 spod ścieżki path w hierarchii root.
+// Brak pojedynczych plików (np. wyłączonego kontrolera) nie jest błędem.
+func readCgroupStats(root, path string) (*models.CgroupStats, error) {
+	dir := filepath.Join(root, path)
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	stats := &models.CgroupStats{Path: path}
+
+	if cpu, err := readKeyedFile(filepath.Join(dir, "cpu.stat")); err == nil {
+		stats.CPUUsageUsec = cpu["usage_usec"]
+		stats.CPUUserUsec = cpu["user_usec"]
+		stats.CPUSystemUsec = cpu["system_usec"]
+		stats.CPUNrThrottled = cpu["nr_throttled"]
+		stats.CPUThrottledUsec = cpu["throttled_usec"]
+	}
+
+	stats.MemoryCurrent, _ = readCgroupValue(filepath.Join(dir, "memory.current"))
+	stats.MemoryMax, _ = readCgroupValue(filepath.Join(dir, "memory.max"))
+	stats.PidsCurrent, _ = readCgroupValue(filepath.Join(dir, "pids.current"))
+	stats.PidsMax, _ = readCgroupValue(filepath.Join(dir, "pids.max"))
+
+	// io.stat: jeden wiersz na urządzenie, np. "8:0 rbytes=1 wbytes=2 rios=3 wios=4 ..."
+	if data, err := os.ReadFile(filepath.Join(dir, "io.stat")); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, field := range fields[1:] {
+				key, value, _ := strings.Cut(field, "=")
+				n, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					continue
+				}
+				switch key {
+				case "rbytes":
+					stats.IOReadBytes += n
+				case "wbytes":
+					stats.IOWriteBytes += n
+				case "rios":
+					stats.IOReadOps += n
+				case "wios":
+					stats.IOWriteOps += n
+				}
+			}
+		}
+	}
+
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		data, err := os.ReadFile(filepath.Join(dir, resource+".pressure"))
+		if err != nil {
+			continue
+		}
+		pressure, err := parsePressure(string(data))
+		if err != nil {
+			continue
+		}
+		if stats.Pressure == nil {
+			stats.Pressure = make(map[string]models.PressureStats)
+		}
+		stats.Pressure[resource] = pressure
+	}
+
+	return stats, nil
+}
+
+// readKeyedFile odczytuje plik w formacie "klucz wartość" (np. cpu.stat)
+func readKeyedFile(path string) (map[string]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]uint64)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if n, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+			values[fields[0]] = n
+		}
+	}
+
+	return values, nil
+}
+
+// readCgroupValue odczytuje plik z pojedynczą wartością; "max" (brak limitu) daje 0
+func readCgroupValue(path string) (uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// parsePressure przetwarza plik PSI, np.
+// "some avg10=0.00 avg60=0.00 avg300=0.00 total=0" oraz analogiczny wiersz "full"
+func parsePressure(data string) (models.PressureStats, error) {
+	var stats models.PressureStats
+
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		pressure := &models.Pressure{}
+		for _, field := range fields[1:] {
+			key, value, _ := strings.Cut(field, "=")
+			var err error
+			switch key {
+			case "avg10":
+				pressure.Avg10, err = strconv.ParseFloat(value, 64)
+			case "avg60":
+				pressure.Avg60, err = strconv.ParseFloat(value, 64)
+			case "avg300":
+				pressure.Avg300, err = strconv.ParseFloat(value, 64)
+			case "total":
+				pressure.TotalUsec, err = strconv.ParseUint(value, 10, 64)
+			}
+			if err != nil {
+				return stats, fmt.Errorf("niepoprawna wartość %s: %v", field, err)
+			}
+		}
+
+		switch fields[0] {
+		case "some":
+			stats.Some = pressure
+		case "full":
+			stats.Full = pressure
+		}
+	}
+
+	if stats.Some == nil && stats.Full == nil {
+		return stats, fmt.Errorf("brak danych PSI")
+	}
+	return stats, nil
+}
@@ -0,0 +1,135 @@
+package collectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// writeCgroupFiles tworzy pliki cgroup w katalogu testowym
+func writeCgroupFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestCgroupEnricher(t *testing.T) {
+	root := t.TempDir()
+	proc := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{"cgroup.controllers": "cpu io memory pids\n"})
+
+	writeCgroupFiles(t, filepath.Join(root, "system.slice", "ollama.service"), map[string]string{
+		"cpu.stat":        "usage_usec 1000000\nuser_usec 800000\nsystem_usec 200000\nnr_periods 0\nnr_throttled 3\nthrottled_usec 500\n",
+		"memory.current":  "2147483648\n",
+		"memory.max":      "8589934592\n",
+		"pids.current":    "12\n",
+		"pids.max":        "max\n",
+		"io.stat":         "8:0 rbytes=100 wbytes=200 rios=1 wios=2 dbytes=0 dios=0\n259:0 rbytes=1000 wbytes=0 rios=10 wios=0 dbytes=0 dios=0\n",
+		"memory.pressure": "some avg10=1.50 avg60=0.75 avg300=0.10 total=12345\nfull avg10=0.50 avg60=0.25 avg300=0.00 total=6789\n",
+	})
+
+	// Kontener bez limitu pamięci, znaleziony przez cgroup głównego procesu
+	containerPath := "/system.slice/docker-abc.scope"
+	writeCgroupFiles(t, filepath.Join(root, containerPath), map[string]string{
+		"cpu.stat":       "usage_usec 0\n",
+		"memory.current": "1073741824\n",
+		"memory.max":     "max\n",
+	})
+	writeCgroupFiles(t, filepath.Join(proc, "4242"), map[string]string{"cgroup": "0::" + containerPath + "\n"})
+
+	e := &CgroupEnricher{
+		root:        root,
+		procRoot:    proc,
+		memoryTotal: func() uint64 { return 16 << 30 },
+		cpuSamples:  make(map[string]cgroupCPUSample),
+	}
+
+	newState := func() *models.SystemState {
+		state := models.NewSystemState()
+		state.Services = []models.Service{
+			{Name: "ollama", Type: "systemd", CPUPercent: 1, Systemd: &models.SystemdUnit{ControlGroup: "/system.slice/ollama.service"}},
+			{Name: "web", Type: "docker", PID: 4242},
+			{Name: "cron", Type: "system", PID: 4242},
+		}
+		return state
+	}
+
+	state := newState()
+	if err := e.Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd odczytu cgroup: %v", err)
+	}
+
+	ollama := state.Services[0]
+	stats := ollama.Cgroup
+	if stats == nil {
+		t.Fatal("Brak statystyk cgroup usługi systemd")
+	}
+	if stats.CPUUsageUsec != 1000000 || stats.CPUNrThrottled != 3 || stats.MemoryMax != 8<<30 || stats.PidsCurrent != 12 || stats.PidsMax != 0 {
+		t.Errorf("Niepoprawne statystyki: got %+v", stats)
+	}
+	if stats.IOReadBytes != 1100 || stats.IOWriteBytes != 200 || stats.IOReadOps != 11 || stats.IOWriteOps != 2 {
+		t.Errorf("Niepoprawne statystyki I/O: got %+v", stats)
+	}
+	if pressure := stats.Pressure["memory"]; pressure.Some == nil || pressure.Some.Avg10 != 1.5 || pressure.Full.TotalUsec != 6789 {
+		t.Errorf("Niepoprawne PSI: got %+v", stats.Pressure)
+	}
+	if ollama.MemoryPercent != 25 {
+		t.Errorf("Niepoprawne użycie pamięci: got %v, want %v", ollama.MemoryPercent, 25)
+	}
+	// Pierwsze zbieranie nie zna poprzedniej próbki CPU
+	if ollama.CPUPercent != 1 {
+		t.Errorf("Niepoprawne użycie CPU po pierwszym zbieraniu: got %v", ollama.CPUPercent)
+	}
+
+	web := state.Services[1]
+	if web.Cgroup == nil || web.Cgroup.Path != containerPath || web.MemoryPercent != 6.25 {
+		t.Errorf("Niepoprawne statystyki kontenera: got %+v (%v%%)", web.Cgroup, web.MemoryPercent)
+	}
+	if state.Services[2].Cgroup != nil {
+		t.Errorf("Usługa bez jednostki systemd nie powinna mieć statystyk cgroup: got %+v", state.Services[2].Cgroup)
+	}
+
+	// Drugie zbieranie wylicza użycie CPU względem poprzedniej próbki
+	e.cpuSamples["/system.slice/ollama.service"] = cgroupCPUSample{usageUsec: 500000, at: time.Now().Add(-time.Second)}
+	state = newState()
+	if err := e.Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd odczytu cgroup: %v", err)
+	}
+	if cpu := state.Services[0].CPUPercent; cpu < 45 || cpu > 51 {
+		t.Errorf("Niepoprawne użycie CPU: got %v, want ok. %v", cpu, 50)
+	}
+}
+
+func TestCgroupEnricherWithoutCgroupV2(t *testing.T) {
+	e := &CgroupEnricher{root: t.TempDir(), procRoot: t.TempDir(), memoryTotal: func() uint64 { return 0 }}
+	state := models.NewSystemState()
+	state.Services = []models.Service{{Name: "ollama", Systemd: &models.SystemdUnit{ControlGroup: "/system.slice/ollama.service"}}}
+
+	if err := e.Enrich(context.Background(), state); err != nil || state.Services[0].Cgroup != nil {
+		t.Errorf("Oczekiwano braku zmian bez cgroup v2: got %+v, %v", state.Services[0].Cgroup, err)
+	}
+}
+
+func TestParsePressure(t *testing.T) {
+	// cpu.pressure na starszych jądrach zawiera tylko wiersz "some"
+	stats, err := parsePressure("some avg10=0.10 avg60=0.20 avg300=0.30 total=400\n")
+	if err != nil || stats.Some == nil || stats.Full != nil || stats.Some.Avg300 != 0.3 || stats.Some.TotalUsec != 400 {
+		t.Errorf("Niepoprawne PSI: got %+v, %v", stats, err)
+	}
+	if _, err := parsePressure("some avg10=x\n"); err == nil {
+		t.Error("Oczekiwano błędu dla niepoprawnej wartości")
+	}
+	if _, err := parsePressure(""); err == nil {
+		t.Error("Oczekiwano błędu dla pustego pliku")
+	}
+}
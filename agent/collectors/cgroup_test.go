@@ -0,0 +1,80 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProcessCgroup(t *testing.T) {
+	id := strings.Repeat("0123abcd", 8)
+
+	tests := []struct {
+		name string
+		data string
+		want processCgroup
+	}{
+		{
+			name: "usługa systemd (v2)",
+			data: "0::/system.slice/ollama.service\n",
+			want: processCgroup{Path: "/system.slice/ollama.service", SystemdUnit: "ollama.service"},
+		},
+		{
+			name: "kontener Docker (v2)",
+			data: "0::/system.slice/docker-" + id + ".scope\n",
+			want: processCgroup{Path: "/system.slice/docker-" + id + ".scope", ContainerID: id, SystemdUnit: "docker-" + id + ".scope"},
+		},
+		{
+			name: "pod Kubernetesa (v2)",
+			data: "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope\n",
+			want: processCgroup{
+				Path:        "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope",
+				ContainerID: id,
+				SystemdUnit: "cri-containerd-" + id + ".scope",
+			},
+		},
+		{
+			name: "kontener Docker bez systemd (v1)",
+			data: "12:memory:/docker/" + id + "\n11:cpu,cpuacct:/docker/" + id + "\n",
+			want: processCgroup{Path: "/docker/" + id, ContainerID: id},
+		},
+		{
+			name: "tryb hybrydowy",
+			data: "5:cpu,cpuacct:/\n1:name=systemd:/user.slice/user-1000.slice/user@1000.service/app.slice/llama-server.service\n0::/\n",
+			want: processCgroup{
+				Path:        "/user.slice/user-1000.slice/user@1000.service/app.slice/llama-server.service",
+				SystemdUnit: "llama-server.service",
+			},
+		},
+		{
+			name: "monitor conmon Podmana",
+			data: "0::/machine.slice/libpod-conmon-" + id + ".scope\n",
+			want: processCgroup{Path: "/machine.slice/libpod-conmon-" + id + ".scope", SystemdUnit: "libpod-conmon-" + id + ".scope"},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := parseProcessCgroup(tt.data); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReadProcessCgroup(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "42"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "42", "cgroup"), []byte("0::/system.slice/ollama.service\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cgroup, err := readProcessCgroup(root, 42)
+	if err != nil || cgroup.SystemdUnit != "ollama.service" {
+		t.Errorf("Niepoprawna cgroup procesu: got %+v, %v", cgroup, err)
+	}
+	if _, err := readProcessCgroup(root, 43); err == nil {
+		t.Error("Oczekiwano błędu dla nieistniejącego procesu")
+	}
+}
@@ -0,0 +1,215 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Collector to wspólny interfejs kolektorów uruchamianych przez SystemCollector.
+// Wynik Collect trafia do SystemState na podstawie swojego typu: *models.Hardware,
+// []models.Process i []models.Service mają własne pola, pozostałe typy są
+// zapisywane w SystemState.Extra pod nazwą kolektora.
+type Collector interface {
+	// Name zwraca unikalną nazwę kolektora używaną w konfiguracji
+	Name() string
+	// Collect zbiera dane o fragmencie systemu
+	Collect(ctx context.Context) (interface{}, error)
+}
+
+// Triggerer to kolektor, który może żądać natychmiastowego zbierania danych poza
+// zwykłym interwałem, np. po zmianie kontenera związanego z LLM
+type Triggerer interface {
+	// SetTrigger przekazuje kolektorowi funkcję, którą wywołuje z opisem przyczyny
+	SetTrigger(trigger func(reason string))
+}
+
+// Enricher uzupełnia stan systemu po zakończeniu pracy kolektorów, np. łącząc dane
+// zebrane przez kilka z nich. Moduły uzupełniające działają po kolei, w kolejności
+// rejestracji, i mogą modyfikować przekazany stan.
+type Enricher interface {
+	// Name zwraca unikalną nazwę modułu używaną w konfiguracji
+	Name() string
+	// Enrich uzupełnia stan systemu
+	Enrich(ctx context.Context, state *models.SystemState) error
+}
+
+// Factory tworzy nową instancję kolektora
+type Factory func() Collector
+
+// registration opisuje kolektor zarejestrowany w rejestrze
+type registration struct {
+	name    string
+	factory Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   []registration
+)
+
+// Register dodaje kolektor do rejestru. Kolektory zarejestrowane w ten sposób
+// są domyślnie włączone i mogą być wyłączane w konfiguracji agenta.
+// Wywołanie z nazwą, która jest już zarejestrowana, kończy się panic.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, r := range registry {
+		if r.name == name {
+			panic(fmt.Sprintf("kolektor %q jest już zarejestrowany", name))
+		}
+	}
+
+	registry = append(registry, registration{name: name, factory: factory})
+}
+
+// RegisterEnricher dodaje do rejestru moduł uzupełniający stan. Tak jak kolektory,
+// moduły są domyślnie włączone i mogą być wyłączane w konfiguracji agenta.
+func RegisterEnricher(name string, factory func() Enricher) {
+	Register(name, func() Collector {
+		return &enricherCollector{Enricher: factory()}
+	})
+}
+
+// enricherCollector pozwala przechowywać moduł uzupełniający razem z kolektorami
+type enricherCollector struct {
+	Enricher
+}
+
+// Collect nie zbiera danych; SystemCollector wywołuje Enrich po pozostałych kolektorach
+func (e *enricherCollector) Collect(ctx context.Context) (interface{}, error) {
+	return nil, nil
+}
+
+// Registered zwraca nazwy zarejestrowanych kolektorów w kolejności rejestracji
+func Registered() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for _, r := range registry {
+		names = append(names, r.name)
+	}
+
+	return names
+}
+
+// newCollector tworzy instancję zarejestrowanego kolektora o podanej nazwie
+func newCollector(name string) (Collector, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	for _, r := range registry {
+		if r.name == name {
+			return r.factory(), true
+		}
+	}
+
+	return nil, false
+}
+
+// collectorFunc dostosowuje zwykłą funkcję do interfejsu Collector
+type collectorFunc struct {
+	name string
+	fn   func(ctx context.Context) (interface{}, error)
+}
+
+// NewCollectorFunc tworzy kolektor o podanej nazwie na podstawie funkcji
+func NewCollectorFunc(name string, fn func(ctx context.Context) (interface{}, error)) Collector {
+	return &collectorFunc{name: name, fn: fn}
+}
+
+// Name zwraca nazwę kolektora
+func (c *collectorFunc) Name() string {
+	return c.name
+}
+
+// Collect wywołuje funkcję kolektora
+func (c *collectorFunc) Collect(ctx context.Context) (interface{}, error) {
+	return c.fn(ctx)
+}
+
+// Rejestracja wbudowanych kolektorów
+func init() {
+	Register("hardware", func() Collector {
+		hardwareCollector := NewHardwareCollector()
+		return NewCollectorFunc("hardware", func(ctx context.Context) (interface{}, error) {
+			return hardwareCollector.Collect()
+		})
+	})
+
+	Register("processes", func() Collector {
+		processCollector := NewProcessCollector()
+		return NewCollectorFunc("processes", func(ctx context.Context) (interface{}, error) {
+			return processCollector.Collect(ctx)
+		})
+	})
+
+	Register("services", func() Collector {
+		systemdCollector := NewSystemdCollector()
+		serviceCollector := NewServiceCollector()
+		return NewCollectorFunc("services", func(ctx context.Context) (interface{}, error) {
+			// Na systemach z systemd odpytaj go bezpośrednio przez D-Bus
+			if systemdCollector.Available() {
+				services, err := systemdCollector.Collect(ctx)
+				if err == nil {
+					return services, nil
+				}
+				fmt.Printf("Ostrzeżenie: %v; używam ogólnej listy usług\n", err)
+			}
+			return serviceCollector.Collect()
+		})
+	})
+
+	Register("docker", func() Collector {
+		return newRuntimeCollector(NewDockerCollector())
+	})
+
+	Register("podman", func() Collector {
+		return newRuntimeCollector(NewPodmanCollector())
+	})
+
+	Register("containerd", func() Collector {
+		return newRuntimeCollector(NewContainerdCollector())
+	})
+
+	Register("kubernetes", func() Collector {
+		kubernetesCollector := NewKubernetesCollector()
+		return NewCollectorFunc("kubernetes", func(ctx context.Context) (interface{}, error) {
+			return kubernetesCollector.Collect(ctx)
+		})
+	})
+
+	Register("docker_events", func() Collector {
+		return NewDockerEventWatcher()
+	})
+
+	RegisterEnricher("cgroups", func() Enricher {
+		return NewCgroupEnricher()
+	})
+
+	RegisterEnricher("compose", func() Enricher {
+		return NewComposeEnricher()
+	})
+
+	RegisterEnricher("gpu_processes", func() Enricher {
+		return NewGPUProcessEnricher()
+	})
+
+	// Klasyfikacja LLM korzysta z danych uzupełnionych przez poprzednie moduły
+	RegisterEnricher("llm", func() Enricher {
+		return NewLLMEnricher()
+	})
+
+	RegisterEnricher("models", func() Enricher {
+		return NewModelInventoryEnricher()
+	})
+
+	// Opcjonalny: odpytuje API serwerów inferencji, więc domyślnie jest wyłączony
+	RegisterEnricher("inference", func() Enricher {
+		return NewInferenceEnricher()
+	})
+}
@@ -0,0 +1,370 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Etykiety, którymi Docker Compose oznacza utworzone kontenery
+const (
+	composeLabelPrefix      = "com.docker.compose."
+	composeProjectLabel     = "com.docker.compose.project"
+	composeServiceLabel     = "com.docker.compose.service"
+	composeWorkingDirLabel  = "com.docker.compose.project.working_dir"
+	composeConfigFilesLabel = "com.docker.compose.project.config_files"
+	composeNumberLabel      = "com.docker.compose.container-number"
+	composeOneoffLabel      = "com.docker.compose.oneoff"
+	composeDependsOnLabel   = "com.docker.compose.depends_on"
+)
+
+// anonymousVolumeName rozpoznaje wolumeny anonimowe, którym Docker nadaje losowe nazwy
+var anonymousVolumeName = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ComposeEnricher odtwarza projekty Docker Compose na podstawie etykiet zebranych
+// kontenerów i zapisuje je w SystemState.Extra["compose"]. Odtworzony plik zawiera
+// stan kontenerów w chwili zbierania, więc obejmuje też np. zmienne środowiskowe
+// i polecenie pochodzące z obrazu.
+type ComposeEnricher struct{}
+
+// NewComposeEnricher tworzy nowy moduł odtwarzający projekty Docker Compose
+func NewComposeEnricher() *ComposeEnricher {
+	return &ComposeEnricher{}
+}
+
+// Name zwraca nazwę modułu
+func (e *ComposeEnricher) Name() string {
+	return "compose"
+}
+
+// Enrich grupuje kontenery według projektów Compose i odtwarza ich pliki docker-compose.yml
+func (e *ComposeEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	projects := composeProjects(state.Services)
+	if len(projects) == 0 {
+		return nil
+	}
+
+	if state.Extra == nil {
+		state.Extra = make(map[string]interface{})
+	}
+	state.Extra["compose"] = projects
+	return nil
+}
+
+// composeService to usługa projektu Compose wraz z jej kontenerami
+type composeService struct {
+	name       string
+	containers []models.Service
+}
+
+// composeProjects odtwarza projekty Compose z kontenerów, posortowane według nazwy
+func composeProjects(services []models.Service) []models.ComposeProject {
+	// Pogrupuj kontenery według projektu i usługi
+	grouped := make(map[string]map[string][]models.Service)
+	for _, service := range services {
+		project := service.Labels[composeProjectLabel]
+		name := service.Labels[composeServiceLabel]
+		if !service.IsContainer() || project == "" || name == "" {
+			continue
+		}
+		// Kontenery "docker compose run" nie są częścią definicji projektu
+		if strings.EqualFold(service.Labels[composeOneoffLabel], "true") {
+			continue
+		}
+
+		if grouped[project] == nil {
+			grouped[project] = make(map[string][]models.Service)
+		}
+		grouped[project][name] = append(grouped[project][name], service)
+	}
+
+	projectNames := make([]string, 0, len(grouped))
+	for project := range grouped {
+		projectNames = append(projectNames, project)
+	}
+	sort.Strings(projectNames)
+
+	projects := make([]models.ComposeProject, 0, len(grouped))
+	for _, project := range projectNames {
+		serviceNames := make([]string, 0, len(grouped[project]))
+		for name := range grouped[project] {
+			serviceNames = append(serviceNames, name)
+		}
+		sort.Strings(serviceNames)
+
+		projectServices := make([]composeService, 0, len(serviceNames))
+		for _, name := range serviceNames {
+			containers := grouped[project][name]
+			sort.Slice(containers, func(i, j int) bool {
+				if containerNumber(containers[i]) != containerNumber(containers[j]) {
+					return containerNumber(containers[i]) < containerNumber(containers[j])
+				}
+				return containers[i].Name < containers[j].Name
+			})
+			projectServices = append(projectServices, composeService{name: name, containers: containers})
+		}
+		projects = append(projects, buildComposeProject(project, projectServices))
+	}
+
+	return projects
+}
+
+// buildComposeProject tworzy opis projektu i jego plik docker-compose.yml
+func buildComposeProject(name string, services []composeService) models.ComposeProject {
+	project := models.ComposeProject{
+		Name:       name,
+		Services:   make([]string, 0, len(services)),
+		Containers: make([]string, 0),
+	}
+
+	// Nazwy kontenerów przypisane do usług, potrzebne do odtworzenia linków
+	serviceByContainer := make(map[string]string)
+	for _, service := range services {
+		project.Services = append(project.Services, service.name)
+		for _, container := range service.containers {
+			project.Containers = append(project.Containers, container.Name)
+			serviceByContainer[container.Name] = service.name
+		}
+	}
+
+	first := services[0].containers[0]
+	project.WorkingDir = first.Labels[composeWorkingDirLabel]
+	if configFiles := first.Labels[composeConfigFilesLabel]; configFiles != "" {
+		project.ConfigFiles = strings.Split(configFiles, ",")
+	}
+
+	// Sieci i wolumeny projektu oraz zewnętrzne, według nazwy w pliku
+	networks := make(map[string]bool)
+	volumes := make(map[string]bool)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "name: %s\n", yamlQuote(name))
+	b.WriteString("services:\n")
+	for _, service := range services {
+		writeComposeService(&b, name, service, serviceByContainer, networks, volumes)
+	}
+
+	if len(networks) > 0 {
+		b.WriteString("networks:\n")
+		for _, network := range sortedNames(networks) {
+			project.Networks = append(project.Networks, network)
+			writeComposeResource(&b, network, networks[network])
+		}
+	}
+	if len(volumes) > 0 {
+		b.WriteString("volumes:\n")
+		for _, volume := range sortedNames(volumes) {
+			project.Volumes = append(project.Volumes, volume)
+			writeComposeResource(&b, volume, volumes[volume])
+		}
+	}
+
+	project.ComposeYAML = b.String()
+	return project
+}
+
+// writeComposeService zapisuje definicję usługi na podstawie jej pierwszego kontenera.
+// Do map networks i volumes trafiają użyte sieci i wolumeny (true = zewnętrzne).
+func writeComposeService(b *strings.Builder, project string, service composeService, serviceByContainer map[string]string, networks, volumes map[string]bool) {
+	container := service.containers[0]
+
+	fmt.Fprintf(b, "  %s:\n", service.name)
+	fmt.Fprintf(b, "    image: %s\n", yamlQuote(container.Image))
+
+	// Nazwa kontenera tylko wtedy, gdy różni się od nadawanej przez Compose
+	if len(service.containers) == 1 && !isDefaultContainerName(project, service.name, container.Name) {
+		fmt.Fprintf(b, "    container_name: %s\n", yamlQuote(container.Name))
+	}
+	if len(service.containers) > 1 {
+		fmt.Fprintf(b, "    scale: %d\n", len(service.containers))
+	}
+
+	writeYAMLList(b, "command", container.Command)
+	if container.RestartPolicy != "" && container.RestartPolicy != "no" {
+		fmt.Fprintf(b, "    restart: %s\n", yamlQuote(container.RestartPolicy))
+	}
+
+	// Porty opublikowane na hoście i tylko udostępnione innym kontenerom
+	ports, expose := composePorts(container.Ports)
+	writeYAMLList(b, "ports", ports)
+	writeYAMLList(b, "expose", expose)
+
+	writeYAMLList(b, "environment", container.Environment)
+
+	// Wolumeny: ścieżki hosta, wolumeny nazwane i anonimowe
+	var mounts []string
+	for _, volume := range container.Volumes {
+		var source string
+		switch volume.Type {
+		case "bind":
+			source = volume.Source
+		case "volume":
+			if anonymousVolumeName.MatchString(volume.Name) {
+				break
+			}
+			short, internal := strings.CutPrefix(volume.Name, project+"_")
+			volumes[short] = !internal
+			source = short
+		default:
+			continue
+		}
+
+		mount := volume.Destination
+		if source != "" {
+			mount = source + ":" + mount
+		}
+		if volume.ReadOnly {
+			mount += ":ro"
+		}
+		mounts = append(mounts, mount)
+	}
+	writeYAMLList(b, "volumes", mounts)
+
+	// Sieci; kontener podłączony tylko do sieci domyślnej projektu jej nie wymienia
+	var serviceNetworks []string
+	for _, network := range container.Networks {
+		switch network {
+		case "host", "none", "bridge":
+			fmt.Fprintf(b, "    network_mode: %s\n", network)
+			continue
+		}
+		short, internal := strings.CutPrefix(network, project+"_")
+		if internal && short == "default" && len(container.Networks) == 1 {
+			continue
+		}
+		networks[short] = !internal
+		serviceNetworks = append(serviceNetworks, short)
+	}
+	writeYAMLList(b, "networks", serviceNetworks)
+
+	// Etykiety użytkownika, bez etykiet nadanych przez Compose
+	var labels []string
+	for key := range container.Labels {
+		if !strings.HasPrefix(key, composeLabelPrefix) {
+			labels = append(labels, key)
+		}
+	}
+	sort.Strings(labels)
+	if len(labels) > 0 {
+		b.WriteString("    labels:\n")
+		for _, key := range labels {
+			fmt.Fprintf(b, "      %s: %s\n", yamlQuote(key), yamlQuote(container.Labels[key]))
+		}
+	}
+
+	// Zależności zapisane przez Compose w formacie "usługa:warunek:restart,..."
+	if dependsOn := container.Labels[composeDependsOnLabel]; dependsOn != "" {
+		b.WriteString("    depends_on:\n")
+		for _, dependency := range strings.Split(dependsOn, ",") {
+			parts := strings.Split(dependency, ":")
+			condition := "service_started"
+			if len(parts) > 1 && parts[1] != "" {
+				condition = parts[1]
+			}
+			fmt.Fprintf(b, "      %s:\n        condition: %s\n", parts[0], condition)
+		}
+	}
+
+	// Linki w formacie Dockera "/kontener:/ten-kontener/alias"
+	var links []string
+	for _, link := range container.Links {
+		target, alias, _ := strings.Cut(link, ":")
+		target = strings.TrimPrefix(target, "/")
+		if name, ok := serviceByContainer[target]; ok {
+			target = name
+		}
+		alias = alias[strings.LastIndex(alias, "/")+1:]
+		if alias != "" && alias != target {
+			target += ":" + alias
+		}
+		links = append(links, target)
+	}
+	writeYAMLList(b, "links", links)
+}
+
+// writeComposeResource zapisuje sieć lub wolumen najwyższego poziomu
+func writeComposeResource(b *strings.Builder, name string, external bool) {
+	if external {
+		fmt.Fprintf(b, "  %s:\n    external: true\n", name)
+		return
+	}
+	fmt.Fprintf(b, "  %s: {}\n", name)
+}
+
+// writeYAMLList zapisuje listę wartości usługi; pusta lista jest pomijana
+func writeYAMLList(b *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "    %s:\n", key)
+	for _, value := range values {
+		fmt.Fprintf(b, "      - %s\n", yamlQuote(value))
+	}
+}
+
+// composePorts zamienia porty kontenera na wpisy "ports" i "expose". Docker zgłasza
+// port opublikowany na wszystkich interfejsach osobno dla IPv4 i IPv6, więc
+// powtórzenia są pomijane.
+func composePorts(ports []models.Port) (published, exposed []string) {
+	seen := make(map[string]bool)
+	for _, port := range ports {
+		containerPort := strings.TrimSuffix(port.ContainerPort, "/tcp")
+
+		var entry string
+		switch {
+		case port.HostPort == "" || port.HostPort == "0":
+			entry = containerPort
+		case port.HostIP == "" || port.HostIP == "0.0.0.0" || port.HostIP == "::":
+			entry = port.HostPort + ":" + containerPort
+		default:
+			entry = port.HostIP + ":" + port.HostPort + ":" + containerPort
+		}
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+
+		if port.HostPort == "" || port.HostPort == "0" {
+			exposed = append(exposed, entry)
+		} else {
+			published = append(published, entry)
+		}
+	}
+	return published, exposed
+}
+
+// isDefaultContainerName sprawdza, czy nazwa kontenera to nazwa nadawana przez
+// Compose v2 ("projekt-usługa-1") lub v1 ("projekt_usługa_1")
+func isDefaultContainerName(project, service, name string) bool {
+	return name == project+"-"+service+"-1" || name == project+"_"+service+"_1"
+}
+
+// containerNumber zwraca numer kontenera w usłudze Compose
+func containerNumber(service models.Service) int {
+	number, err := strconv.Atoi(service.Labels[composeNumberLabel])
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// yamlQuote zapisuje tekst jako skalar YAML w cudzysłowach; sekwencje ucieczki Go
+// są poprawne w YAML
+func yamlQuote(value string) string {
+	return strconv.Quote(value)
+}
+
+// sortedNames zwraca posortowane klucze mapy
+func sortedNames(m map[string]bool) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,147 @@
+package collectors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// composeContainer tworzy kontener usługi projektu Compose
+func composeContainer(project, service, number, name string) models.Service {
+	return models.Service{
+		Name:  name,
+		Type:  "docker",
+		Image: "nginx:1.27",
+		Labels: map[string]string{
+			composeProjectLabel:     project,
+			composeServiceLabel:     service,
+			composeNumberLabel:      number,
+			composeWorkingDirLabel:  "/srv/" + project,
+			composeConfigFilesLabel: "/srv/" + project + "/compose.yml,/srv/" + project + "/compose.override.yml",
+		},
+		Networks: []string{project + "_default"},
+	}
+}
+
+func TestComposeEnricher(t *testing.T) {
+	ollama := composeContainer("llm", "ollama", "1", "ollama")
+	ollama.Image = "ollama/ollama:latest"
+	ollama.Command = []string{"serve"}
+	ollama.RestartPolicy = "unless-stopped"
+	ollama.Environment = []string{"OLLAMA_HOST=0.0.0.0"}
+	ollama.Ports = []models.Port{
+		{ContainerPort: "11434/tcp", HostIP: "0.0.0.0", HostPort: "11434"},
+		{ContainerPort: "11434/tcp", HostIP: "::", HostPort: "11434"},
+		{ContainerPort: "9090/tcp", HostPort: "0"},
+	}
+	ollama.Volumes = []models.Volume{
+		{Name: "llm_models", Source: "/var/lib/docker/volumes/llm_models/_data", Destination: "/root/.ollama", Type: "volume"},
+		{Source: "/srv/llm/config", Destination: "/config", ReadOnly: true, Type: "bind"},
+		{Name: strings.Repeat("ab", 32), Destination: "/tmp/cache", Type: "volume"},
+		{Name: "shared-cache", Destination: "/cache", Type: "volume"},
+	}
+	ollama.Networks = []string{"llm_backend", "llm_default"}
+	ollama.Labels["traefik.enable"] = "true"
+
+	web1 := composeContainer("llm", "web", "1", "llm-web-1")
+	web1.Labels[composeDependsOnLabel] = "ollama:service_healthy:false"
+	web1.Links = []string{"/ollama:/llm-web-1/api"}
+	web2 := composeContainer("llm", "web", "2", "llm-web-2")
+
+	oneoff := composeContainer("llm", "migrate", "1", "llm-migrate-run-1")
+	oneoff.Labels[composeOneoffLabel] = "True"
+
+	state := models.NewSystemState()
+	// Kolejność kontenerów usługi nie zależy od kolejności zbierania
+	state.Services = []models.Service{
+		web2,
+		ollama,
+		web1,
+		oneoff,
+		composeContainer("other", "db", "1", "other-db-1"),
+		{Name: "sshd", Type: "systemd", Labels: map[string]string{composeProjectLabel: "llm"}},
+	}
+
+	if err := NewComposeEnricher().Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd odtwarzania projektów: %v", err)
+	}
+
+	projects, ok := state.Extra["compose"].([]models.ComposeProject)
+	if !ok || len(projects) != 2 {
+		t.Fatalf("Niepoprawne projekty: got %+v", state.Extra["compose"])
+	}
+
+	llm := projects[0]
+	if llm.Name != "llm" || llm.WorkingDir != "/srv/llm" || len(llm.ConfigFiles) != 2 {
+		t.Errorf("Niepoprawny opis projektu: got %+v", llm)
+	}
+	if strings.Join(llm.Services, ",") != "ollama,web" || strings.Join(llm.Containers, ",") != "ollama,llm-web-1,llm-web-2" {
+		t.Errorf("Niepoprawne usługi projektu: got %v, %v", llm.Services, llm.Containers)
+	}
+	if strings.Join(llm.Networks, ",") != "backend,default" || strings.Join(llm.Volumes, ",") != "models,shared-cache" {
+		t.Errorf("Niepoprawne sieci lub wolumeny: got %v, %v", llm.Networks, llm.Volumes)
+	}
+
+	want := `name: "llm"
+services:
+  ollama:
+    image: "ollama/ollama:latest"
+    container_name: "ollama"
+    command:
+      - "serve"
+    restart: "unless-stopped"
+    ports:
+      - "11434:11434"
+    expose:
+      - "9090"
+    environment:
+      - "OLLAMA_HOST=0.0.0.0"
+    volumes:
+      - "models:/root/.ollama"
+      - "/srv/llm/config:/config:ro"
+      - "/tmp/cache"
+      - "shared-cache:/cache"
+    networks:
+      - "backend"
+      - "default"
+    labels:
+      "traefik.enable": "true"
+  web:
+    image: "nginx:1.27"
+    scale: 2
+    depends_on:
+      ollama:
+        condition: service_healthy
+    links:
+      - "ollama:api"
+networks:
+  backend: {}
+  default: {}
+volumes:
+  models: {}
+  shared-cache:
+    external: true
+`
+	if llm.ComposeYAML != want {
+		t.Errorf("Niepoprawny plik docker-compose.yml:\ngot:\n%s\nwant:\n%s", llm.ComposeYAML, want)
+	}
+
+	// Projekt tylko z siecią domyślną nie wymienia sieci
+	if other := projects[1]; strings.Contains(other.ComposeYAML, "networks:") || len(other.Networks) != 0 {
+		t.Errorf("Niepoprawna sieć domyślna: got %+v", other)
+	}
+}
+
+func TestComposeEnricherWithoutProjects(t *testing.T) {
+	state := models.NewSystemState()
+	state.Services = []models.Service{{Name: "nginx", Type: "docker"}}
+
+	if err := NewComposeEnricher().Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd odtwarzania projektów: %v", err)
+	}
+	if _, ok := state.Extra["compose"]; ok {
+		t.Errorf("Nieoczekiwane projekty: got %+v", state.Extra["compose"])
+	}
+}
@@ -0,0 +1,255 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/containers"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/shirou/gopsutil/v3/process"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// containerdDialTimeout ogranicza czas łączenia z demonem containerd
+const containerdDialTimeout = 5 * time.Second
+
+// criKindLabel to etykieta, którą wtyczka CRI odróżnia piaskownice podów od kontenerów
+const criKindLabel = "io.cri-containerd.kind"
+
+// nerdctlPortsLabel to etykieta, w której nerdctl zapisuje mapowania portów (JSON)
+const nerdctlPortsLabel = "nerdctl/ports"
+
+// containerdSockets to domyślne gniazda containerd: systemowe i wbudowane w k3s
+var containerdSockets = []string{
+	"/run/containerd/containerd.sock",
+	"/run/k3s/containerd/containerd.sock",
+}
+
+// ContainerdCollector zbiera informacje o kontenerach bezpośrednio z containerd,
+// np. na hostach k3s bez Dockera. Kontenery z przestrzeni nazw "moby" należą do
+// Dockera i są pomijane, bo zbiera je kolektor "docker". Pomijane są też kontenery
+// "pause" piaskownic podów CRI (etykieta io.cri-containerd.kind=sandbox).
+type ContainerdCollector struct {
+	address string
+}
+
+// NewContainerdCollector tworzy nowy kolektor informacji o kontenerach containerd.
+// Adres gniazda pochodzi ze zmiennej CONTAINERD_ADDRESS, a domyślnie jest to pierwsze
+// istniejące gniazdo z containerdSockets.
+func NewContainerdCollector() *ContainerdCollector {
+	address := os.Getenv("CONTAINERD_ADDRESS")
+	if address == "" {
+		address = containerdSockets[0]
+		for _, candidate := range containerdSockets {
+			if socketAvailable(candidate) {
+				address = candidate
+				break
+			}
+		}
+	}
+
+	return &ContainerdCollector{address: address}
+}
+
+// Runtime zwraca nazwę środowiska uruchomieniowego
+func (c *ContainerdCollector) Runtime() string {
+	return "containerd"
+}
+
+// Available sprawdza, czy gniazdo containerd istnieje
+func (c *ContainerdCollector) Available() bool {
+	return socketAvailable(c.address)
+}
+
+// Collect zbiera informacje o kontenerach ze wszystkich przestrzeni nazw containerd
+func (c *ContainerdCollector) Collect(ctx context.Context) ([]Container, error) {
+	cli, err := containerd.New(c.address, containerd.WithTimeout(containerdDialTimeout))
+	if err != nil {
+		return nil, fmt.Errorf("nie można połączyć się z containerd (%s): %v", c.address, err)
+	}
+	defer cli.Close()
+
+	// Pobierz listę przestrzeni nazw
+	namespaceList, err := cli.NamespaceService().List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("błąd podczas pobierania przestrzeni nazw containerd: %v", err)
+	}
+
+	containerModels := make([]Container, 0)
+	for _, namespace := range namespaceList {
+		if namespace == "moby" {
+			continue
+		}
+
+		nsCtx := namespaces.WithNamespace(ctx, namespace)
+		nsContainers, err := cli.Containers(nsCtx)
+		if err != nil {
+			fmt.Printf("Ostrzeżenie: nie można pobrać kontenerów z przestrzeni nazw %s: %v\n", namespace, err)
+			continue
+		}
+
+		for _, cont := range nsContainers {
+			info, err := cont.Info(nsCtx)
+			if err != nil {
+				fmt.Printf("Ostrzeżenie: nie można pobrać informacji o kontenerze %s: %v\n", cont.ID(), err)
+				continue
+			}
+			// Piaskownica poda to tylko kontener "pause" utrzymujący jego przestrzenie nazw
+			if info.Labels[criKindLabel] == "sandbox" {
+				continue
+			}
+			containerModels = append(containerModels, c.collectContainer(nsCtx, cont, info))
+		}
+	}
+
+	return containerModels, nil
+}
+
+// collectContainer zbiera informacje o pojedynczym kontenerze containerd
+func (c *ContainerdCollector) collectContainer(ctx context.Context, cont containerd.Container, info containers.Container) Container {
+	// Utwórz model kontenera
+	container := Container{
+		ID:     info.ID,
+		Name:   containerdName(info.ID, info.Labels),
+		Image:  info.Image,
+		Status: "created",
+		Ports:  nerdctlPorts(info.Labels),
+		Labels: info.Labels,
+	}
+
+	// Zmienne środowiskowe i wolumeny pochodzą ze specyfikacji OCI
+	if spec, err := cont.Spec(ctx); err == nil {
+		if spec.Process != nil {
+			container.Environment = spec.Process.Env
+		}
+		container.Volumes = ociVolumes(spec.Mounts)
+	}
+
+	// Stan zadania (procesu) kontenera; kontener bez zadania nie jest uruchomiony
+	task, err := cont.Task(ctx, nil)
+	if err != nil {
+		return container
+	}
+	status, err := task.Status(ctx)
+	if err != nil {
+		return container
+	}
+	container.Status = string(status.Status)
+
+	if status.Status == containerd.Running {
+		container.PID = int32(task.Pid())
+		collectProcessStats(&container)
+	}
+
+	return container
+}
+
+// containerdName zwraca czytelną nazwę kontenera na podstawie etykiet Kubernetesa
+// lub nerdctl; bez nich nazwą jest identyfikator
+func containerdName(id string, labels map[string]string) string {
+	if name := labels["io.kubernetes.container.name"]; name != "" {
+		if pod := labels["io.kubernetes.pod.name"]; pod != "" {
+			return pod + "/" + name
+		}
+		return name
+	}
+	if name := labels["nerdctl/name"]; name != "" {
+		return name
+	}
+	return id
+}
+
+// nerdctlPorts odczytuje mapowania portów z etykiety nerdctl. Containerd sam nie
+// przechowuje portów: kontenery CRI (Kubernetes) publikują je przez CNI poda, więc
+// dla nich lista jest pusta, a porty podów zbiera kolektor "kubernetes".
+func nerdctlPorts(labels map[string]string) []models.Port {
+	portModels := make([]models.Port, 0)
+
+	value := labels[nerdctlPortsLabel]
+	if value == "" {
+		return portModels
+	}
+
+	var mappings []struct {
+		HostPort      int
+		ContainerPort int
+		Protocol      string
+		HostIP        string
+	}
+	if err := json.Unmarshal([]byte(value), &mappings); err != nil {
+		return portModels
+	}
+
+	for _, mapping := range mappings {
+		protocol := mapping.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		portModels = append(portModels, models.Port{
+			ContainerPort: fmt.Sprintf("%d/%s", mapping.ContainerPort, protocol),
+			HostIP:        mapping.HostIP,
+			HostPort:      fmt.Sprintf("%d", mapping.HostPort),
+		})
+	}
+
+	return portModels
+}
+
+// ociVolumes zamienia montowania ze specyfikacji OCI na wolumeny, pomijając
+// systemy plików tworzone przez środowisko (proc, sysfs, tmpfs itp.)
+func ociVolumes(mounts []specs.Mount) []models.Volume {
+	volumeModels := make([]models.Volume, 0, len(mounts))
+
+	for _, mount := range mounts {
+		if mount.Type != "bind" && !containsString(mount.Options, "bind") && !containsString(mount.Options, "rbind") {
+			continue
+		}
+
+		volumeModels = append(volumeModels, models.Volume{
+			Source:      mount.Source,
+			Destination: mount.Destination,
+			ReadOnly:    containsString(mount.Options, "ro"),
+			Type:        "bind",
+		})
+	}
+
+	return volumeModels
+}
+
+// collectProcessStats uzupełnia kontener o użycie CPU i pamięci jego głównego procesu
+func collectProcessStats(container *Container) {
+	proc, err := process.NewProcess(container.PID)
+	if err != nil {
+		return
+	}
+
+	if createTime, err := proc.CreateTime(); err == nil {
+		container.StartTime = time.UnixMilli(createTime).Format(time.RFC3339Nano)
+	}
+	if cpuPercent, err := proc.CPUPercent(); err == nil {
+		container.CPUPercent = cpuPercent
+	}
+	if memoryPercent, err := proc.MemoryPercent(); err == nil {
+		container.MemoryPercent = memoryPercent
+	}
+	if memoryInfo, err := proc.MemoryInfo(); err == nil {
+		container.MemoryUsage = memoryInfo.RSS
+	}
+}
+
+// containsString sprawdza, czy lista zawiera podany tekst
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
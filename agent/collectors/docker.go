@@ -2,52 +2,99 @@ package collectors
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/client"
 
 	"gitlab.com/safetytwin/safetytwin/agent/models"
 )
 
-// DockerContainer reprezentuje informacje o kontenerze Docker
-type DockerContainer struct {
-	ID          string
-	Name        string
-	Image       string
-	Status      string
-	Ports       []models.Port
-	Volumes     []models.Volume
-	Environment []string
-	Links       []string
+// DockerCollector zbiera informacje o kontenerach przez API Dockera. Podman udostępnia
+// zgodne API REST na własnym gnieździe, więc ten sam kolektor obsługuje oba środowiska.
+type DockerCollector struct {
+	runtime string
+	client  *client.Client
+
+	// Poprzednie próbki CPU kontenerów, potrzebne do wyliczenia użycia CPU
+	cpuSamples map[string]cpuSample
 }
 
-// DockerCollector zbiera informacje o kontenerach Docker
-type DockerCollector struct {
-	client *client.Client
+// cpuSample to próbka liczników CPU kontenera
+type cpuSample struct {
+	container uint64
+	system    uint64
 }
 
 // NewDockerCollector tworzy nowy kolektor informacji o kontenerach Docker
 func NewDockerCollector() *DockerCollector {
-	// Inicjalizuj klienta Docker
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	return newDockerAPICollector("docker", client.FromEnv)
+}
+
+// NewPodmanCollector tworzy nowy kolektor informacji o kontenerach Podman. Adres gniazda
+// pochodzi ze zmiennej CONTAINER_HOST, a domyślnie jest to gniazdo systemowe lub
+// gniazdo użytkownika w $XDG_RUNTIME_DIR.
+func NewPodmanCollector() *DockerCollector {
+	return newDockerAPICollector("podman", client.WithHost(podmanHost()))
+}
+
+// newDockerAPICollector tworzy kolektor korzystający z API zgodnego z Dockerem
+func newDockerAPICollector(runtime string, hostOpt client.Opt) *DockerCollector {
+	// Inicjalizuj klienta API
+	cli, err := client.NewClientWithOpts(hostOpt, client.WithAPIVersionNegotiation())
 	if err != nil {
-		// Jeśli nie można zainicjować klienta, zwróć nil
-		fmt.Printf("Ostrzeżenie: nie można zainicjować klienta Docker: %v\n", err)
-		return nil
+		// Bez klienta kolektor zgłasza środowisko jako niedostępne
+		fmt.Printf("Ostrzeżenie: nie można zainicjować klienta %s: %v\n", runtime, err)
 	}
 
 	return &DockerCollector{
-		client: cli,
+		runtime:    runtime,
+		client:     cli,
+		cpuSamples: make(map[string]cpuSample),
 	}
 }
 
-// Collect zbiera informacje o kontenerach Docker
-func (c *DockerCollector) Collect() ([]DockerContainer, error) {
-	// Utwórz kontekst
-	ctx := context.Background()
+// podmanHost zwraca adres gniazda API Podmana
+func podmanHost() string {
+	if host := os.Getenv("CONTAINER_HOST"); host != "" {
+		return host
+	}
+
+	candidates := []string{"/run/podman/podman.sock"}
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		candidates = append(candidates, filepath.Join(runtimeDir, "podman", "podman.sock"))
+	}
+	for _, candidate := range candidates {
+		if socketAvailable(candidate) {
+			return "unix://" + candidate
+		}
+	}
+	return "unix://" + candidates[0]
+}
+
+// Runtime zwraca nazwę środowiska uruchomieniowego
+func (c *DockerCollector) Runtime() string {
+	return c.runtime
+}
+
+// Available sprawdza, czy gniazdo API środowiska istnieje
+func (c *DockerCollector) Available() bool {
+	return c.client != nil && socketAvailable(c.client.DaemonHost())
+}
+
+// Collect zbiera informacje o kontenerach; zapytania do demona są przerywane
+// po anulowaniu kontekstu
+func (c *DockerCollector) Collect(ctx context.Context) ([]Container, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("brak klienta %s", c.runtime)
+	}
 
 	// Pobierz listę kontenerów
 	containers, err := c.client.ContainerList(ctx, container.ListOptions{All: true})
@@ -56,7 +103,8 @@ func (c *DockerCollector) Collect() ([]DockerContainer, error) {
 	}
 
 	// Utwórz slice na informacje o kontenerach
-	containerModels := make([]DockerContainer, 0, len(containers))
+	containerModels := make([]Container, 0, len(containers))
+	seen := make(map[string]bool, len(containers))
 
 	// Zbierz informacje o każdym kontenerze
 	for _, cont := range containers {
@@ -66,33 +114,121 @@ func (c *DockerCollector) Collect() ([]DockerContainer, error) {
 			fmt.Printf("Ostrzeżenie: nie można pobrać szczegółowych informacji o kontenerze %s: %v\n", cont.ID, err)
 			continue
 		}
+		seen[cont.ID] = true
 
 		// Utwórz model kontenera
-		container := DockerContainer{
+		container := Container{
 			ID:     cont.ID,
 			Name:   strings.TrimPrefix(contInfo.Name, "/"),
 			Image:  cont.Image,
 			Status: cont.State,
 		}
 
+		// Zbierz informacje o procesie głównym
+		if contInfo.State != nil {
+			container.PID = int32(contInfo.State.Pid)
+			container.StartTime = contInfo.State.StartedAt
+		}
+
 		// Zbierz informacje o portach
 		container.Ports = c.collectPorts(cont.Ports)
 
 		// Zbierz informacje o wolumenach
 		container.Volumes = c.collectVolumes(contInfo.Mounts)
 
-		// Zbierz zmienne środowiskowe
-		container.Environment = contInfo.Config.Env
+		// Zbierz polecenie, zmienne środowiskowe i etykiety
+		if contInfo.Config != nil {
+			container.Command = contInfo.Config.Cmd
+			container.Environment = contInfo.Config.Env
+			container.Labels = contInfo.Config.Labels
+		}
+
+		// Zbierz linki i politykę restartu
+		if contInfo.HostConfig != nil {
+			container.Links = c.collectLinks(contInfo.HostConfig.Links)
+			container.RestartPolicy = string(contInfo.HostConfig.RestartPolicy.Name)
+		}
 
-		// Zbierz linki
-		container.Links = c.collectLinks(contInfo.HostConfig.Links)
+		// Zbierz sieci kontenera
+		if contInfo.NetworkSettings != nil {
+			container.Networks = c.collectNetworks(contInfo.NetworkSettings.Networks)
+		}
+
+		// Zbierz statystyki działającego kontenera
+		if cont.State == "running" {
+			if err := c.collectStats(ctx, &container); err != nil {
+				fmt.Printf("Ostrzeżenie: nie można pobrać statystyk kontenera %s: %v\n", container.Name, err)
+			}
+		}
 
 		containerModels = append(containerModels, container)
 	}
 
+	// Zapomnij próbki CPU usuniętych kontenerów
+	for id := range c.cpuSamples {
+		if !seen[id] {
+			delete(c.cpuSamples, id)
+		}
+	}
+
 	return containerModels, nil
 }
 
+// collectStats zbiera użycie CPU i pamięci kontenera. Użycie CPU jest liczone
+// względem próbki z poprzedniego zbierania, więc pierwsze zbieranie go nie podaje.
+func (c *DockerCollector) collectStats(ctx context.Context, cont *Container) error {
+	response, err := c.client.ContainerStatsOneShot(ctx, cont.ID)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	var stats container.StatsResponse
+	if err := json.NewDecoder(response.Body).Decode(&stats); err != nil {
+		return err
+	}
+
+	current := cpuSample{
+		container: stats.CPUStats.CPUUsage.TotalUsage,
+		system:    stats.CPUStats.SystemUsage,
+	}
+	previous, ok := c.cpuSamples[cont.ID]
+	c.cpuSamples[cont.ID] = current
+	if ok {
+		cont.CPUPercent = cpuPercent(previous, current, stats.CPUStats.OnlineCPUs)
+	}
+
+	// Pamięć podręczna plików nie jest wliczana, tak jak w "docker stats"
+	usage := stats.MemoryStats.Usage
+	for _, key := range []string{"inactive_file", "total_inactive_file"} {
+		if inactive, ok := stats.MemoryStats.Stats[key]; ok && inactive < usage {
+			usage -= inactive
+			break
+		}
+	}
+	cont.MemoryUsage = usage
+	cont.MemoryLimit = stats.MemoryStats.Limit
+	if cont.MemoryLimit > 0 {
+		cont.MemoryPercent = float32(float64(usage) / float64(cont.MemoryLimit) * 100)
+	}
+
+	return nil
+}
+
+// cpuPercent wylicza użycie CPU kontenera między dwiema próbkami (100% = jeden rdzeń)
+func cpuPercent(previous, current cpuSample, onlineCPUs uint32) float64 {
+	if current.container < previous.container || current.system <= previous.system {
+		return 0
+	}
+	if onlineCPUs == 0 {
+		onlineCPUs = 1
+	}
+
+	containerDelta := float64(current.container - previous.container)
+	systemDelta := float64(current.system - previous.system)
+	return containerDelta / systemDelta * float64(onlineCPUs) * 100
+}
+
 // collectPorts zbiera informacje o portach kontenera
 func (c *DockerCollector) collectPorts(ports []types.Port) []models.Port {
 	portModels := make([]models.Port, 0, len(ports))
@@ -116,9 +252,10 @@ func (c *DockerCollector) collectVolumes(mounts []types.MountPoint) []models.Vol
 
 	for _, mount := range mounts {
 		volumeModel := models.Volume{
+			Name:        mount.Name,
 			Source:      mount.Source,
 			Destination: mount.Destination,
-			ReadOnly:    mount.RW,
+			ReadOnly:    !mount.RW,
 			Type:        string(mount.Type),
 		}
 
@@ -128,6 +265,17 @@ func (c *DockerCollector) collectVolumes(mounts []types.MountPoint) []models.Vol
 	return volumeModels
 }
 
+// collectNetworks zwraca posortowane nazwy sieci, do których podłączony jest kontener
+func (c *DockerCollector) collectNetworks(networks map[string]*network.EndpointSettings) []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
 // collectLinks zbiera informacje o linkach kontenera
 func (c *DockerCollector) collectLinks(links []string) []string {
 	// Zwracamy kopię slice, aby uniknąć modyfikacji oryginalnych danych
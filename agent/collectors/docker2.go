@@ -2,6 +2,7 @@ package collectors
 
 import (
 	"context"
+	"safetytwin/agent/classify"
 	"safetytwin/agent/models"
 	"fmt"
 	"os/exec"
@@ -169,43 +170,13 @@ func addContainerStats(cli *client.Client, service *models.Service) {
 
 // isLLMContainer sprawdza, czy kontener jest potencjalnie związany z LLM
 func isLLMContainer(containerInfo types.ContainerJSON) bool {
-	// Lista kluczowych słów dla LLM
-	keywords := []string{
-		"llama", "gpt", "bert", "transformer", "pytorch", "tensorflow",
-		"onnxruntime", "huggingface", "langchain", "llm", "openai",
-		"mistral", "vicuna", "falcon",
+	subject := classify.Subject{Names: []string{strings.TrimPrefix(containerInfo.Name, "/")}}
+	if containerInfo.Config != nil {
+		subject.Images = []string{containerInfo.Config.Image}
+		subject.Env = containerInfo.Config.Env
+		subject.Labels = classify.LabelList(containerInfo.Config.Labels)
 	}
-
-	// Sprawdź nazwę obrazu
-	image := strings.ToLower(containerInfo.Config.Image)
-	for _, keyword := range keywords {
-		if strings.Contains(image, keyword) {
-			return true
-		}
-	}
-
-	// Sprawdź zmienne środowiskowe
-	if containerInfo.Config != nil && containerInfo.Config.Env != nil {
-		envStr := strings.ToLower(strings.Join(containerInfo.Config.Env, " "))
-		for _, keyword := range keywords {
-			if strings.Contains(envStr, keyword) {
-				return true
-			}
-		}
-	}
-
-	// Sprawdź etykiety
-	if containerInfo.Config != nil && containerInfo.Config.Labels != nil {
-		labelsStr := fmt.Sprintf("%v", containerInfo.Config.Labels)
-		labelsStr = strings.ToLower(labelsStr)
-		for _, keyword := range keywords {
-			if strings.Contains(labelsStr, keyword) {
-				return true
-			}
-		}
-	}
-
-	return false
+	return currentClassifier().Classify(subject) != nil
 }
 
 // GetContainerStats pobiera statystyki dla konkretnego kontenera
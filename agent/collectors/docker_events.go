@@ -0,0 +1,261 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+
+	"gitlab.com/safetytwin/safetytwin/agent/classify"
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Ustawienia obserwatora zdarzeń Docker
+const (
+	// maxBufferedEvents ogranicza liczbę zdarzeń przechowywanych między zbieraniami
+	maxBufferedEvents = 1000
+	// eventsMinBackoff i eventsMaxBackoff określają odstęp ponownego łączenia z Dockerem
+	eventsMinBackoff = time.Second
+	eventsMaxBackoff = 30 * time.Second
+)
+
+// dockerEventActions to obserwowane akcje kontenerów
+var dockerEventActions = []string{"start", "die", "oom", "health_status", "restart"}
+
+// dockerEventSource subskrybuje zdarzenia Docker (sygnatura client.Events)
+type dockerEventSource func(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error)
+
+// DockerEventWatcher obserwuje strumień zdarzeń Docker w tle i zapamiętuje zdarzenia
+// kontenerów, które wystąpiły od ostatniego zbierania. Dzięki temu stan zawiera także
+// kontenery, które uległy awarii i zostały zrestartowane między kolejnymi odpytaniami.
+// Zmiana kontenera związanego z LLM może wyzwolić natychmiastowe zbieranie danych.
+type DockerEventWatcher struct {
+	subscribe dockerEventSource
+
+	mu        sync.Mutex
+	events    []models.ContainerEvent
+	dropped   int
+	connected bool
+	lastErr   error
+	lastEvent time.Time
+	trigger   func(reason string)
+	cancel    context.CancelFunc
+}
+
+// NewDockerEventWatcher tworzy nowy obserwator zdarzeń Docker. Obserwowanie zaczyna się
+// przy pierwszym zbieraniu danych.
+func NewDockerEventWatcher() *DockerEventWatcher {
+	w := &DockerEventWatcher{}
+
+	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		w.lastErr = fmt.Errorf("nie można utworzyć klienta Docker: %v", err)
+		return w
+	}
+	w.subscribe = cli.Events
+
+	return w
+}
+
+// Name zwraca nazwę kolektora
+func (w *DockerEventWatcher) Name() string {
+	return "docker_events"
+}
+
+// SetTrigger ustawia funkcję wywoływaną, gdy zmienia się kontener związany z LLM
+func (w *DockerEventWatcher) SetTrigger(trigger func(reason string)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.trigger = trigger
+}
+
+// Collect zwraca zdarzenia kontenerów zarejestrowane od poprzedniego wywołania
+func (w *DockerEventWatcher) Collect(ctx context.Context) (interface{}, error) {
+	w.start()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.dropped > 0 {
+		fmt.Printf("Ostrzeżenie: pominięto %d najstarszych zdarzeń Docker (limit %d)\n", w.dropped, maxBufferedEvents)
+		w.dropped = 0
+	}
+
+	recorded := w.events
+	w.events = nil
+
+	// Bez połączenia z Dockerem brak zdarzeń nie oznacza, że nic się nie stało
+	if len(recorded) == 0 && !w.connected && w.lastErr != nil {
+		return nil, w.lastErr
+	}
+	if recorded == nil {
+		recorded = make([]models.ContainerEvent, 0)
+	}
+
+	return recorded, nil
+}
+
+// Close zatrzymuje obserwowanie zdarzeń
+func (w *DockerEventWatcher) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+	return nil
+}
+
+// start uruchamia obserwowanie zdarzeń w tle, jeśli jeszcze nie działa
+func (w *DockerEventWatcher) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.cancel != nil || w.subscribe == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	go w.watch(ctx)
+}
+
+// watch odbiera zdarzenia Docker i po zerwaniu połączenia wznawia subskrypcję
+// od czasu ostatniego odebranego zdarzenia
+func (w *DockerEventWatcher) watch(ctx context.Context) {
+	backoff := eventsMinBackoff
+
+	for {
+		options := events.ListOptions{Filters: filters.NewArgs(filters.Arg("type", string(events.ContainerEventType)))}
+		for _, action := range dockerEventActions {
+			options.Filters.Add("event", action)
+		}
+
+		w.mu.Lock()
+		if !w.lastEvent.IsZero() {
+			options.Since = strconv.FormatInt(w.lastEvent.Unix(), 10)
+		}
+		w.mu.Unlock()
+
+		messages, errs := w.subscribe(ctx, options)
+		w.setConnected(true, nil)
+
+		err := w.receive(ctx, messages, errs, &backoff)
+		if ctx.Err() != nil {
+			return
+		}
+		w.setConnected(false, fmt.Errorf("utracono połączenie ze strumieniem zdarzeń Docker: %v", err))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		if backoff *= 2; backoff > eventsMaxBackoff {
+			backoff = eventsMaxBackoff
+		}
+	}
+}
+
+// receive przetwarza zdarzenia do czasu błędu subskrypcji
+func (w *DockerEventWatcher) receive(ctx context.Context, messages <-chan events.Message, errs <-chan error, backoff *time.Duration) error {
+	for {
+		select {
+		case message := <-messages:
+			*backoff = eventsMinBackoff
+			w.record(message)
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// setConnected zapamiętuje stan połączenia ze strumieniem zdarzeń
+func (w *DockerEventWatcher) setConnected(connected bool, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.connected = connected
+	if err != nil {
+		w.lastErr = err
+	}
+}
+
+// record zapisuje zdarzenie kontenera i w razie potrzeby wyzwala zbieranie danych
+func (w *DockerEventWatcher) record(message events.Message) {
+	if message.Type != events.ContainerEventType {
+		return
+	}
+
+	event := newContainerEvent(message)
+	// Atrybuty zdarzenia zawierają też etykiety kontenera
+	subject := classify.Subject{
+		Names:  []string{event.Name},
+		Images: []string{event.Image},
+		Labels: classify.LabelList(message.Actor.Attributes),
+	}
+	if llm := currentClassifier().Classify(subject); llm != nil {
+		event.IsLLMRelated = true
+		event.LLMCategory = llm.Category
+	}
+
+	w.mu.Lock()
+	// Po wznowieniu subskrypcji Docker powtarza zdarzenia z ostatniej sekundy
+	eventTime := time.Unix(0, message.TimeNano)
+	if message.TimeNano != 0 && !eventTime.After(w.lastEvent) {
+		w.mu.Unlock()
+		return
+	}
+	if eventTime.After(w.lastEvent) {
+		w.lastEvent = eventTime
+	}
+	w.events = append(w.events, event)
+	if len(w.events) > maxBufferedEvents {
+		w.dropped += len(w.events) - maxBufferedEvents
+		w.events = w.events[len(w.events)-maxBufferedEvents:]
+	}
+	trigger := w.trigger
+	w.mu.Unlock()
+
+	if event.IsLLMRelated && trigger != nil {
+		trigger(fmt.Sprintf("kontener %s: %s", event.Name, event.Action))
+	}
+}
+
+// newContainerEvent tworzy model zdarzenia na podstawie komunikatu Docker
+func newContainerEvent(message events.Message) models.ContainerEvent {
+	attributes := message.Actor.Attributes
+
+	eventTime := time.Unix(message.Time, 0)
+	if message.TimeNano != 0 {
+		eventTime = time.Unix(0, message.TimeNano)
+	}
+
+	event := models.ContainerEvent{
+		Time:        eventTime.Format(time.RFC3339Nano),
+		ContainerID: message.Actor.ID,
+		Name:        attributes["name"],
+		Image:       attributes["image"],
+		Action:      string(message.Action),
+	}
+
+	// Zmiana stanu zdrowia ma postać "health_status: healthy"
+	if status, ok := strings.CutPrefix(event.Action, "health_status:"); ok {
+		event.Action = "health_status"
+		event.Health = strings.TrimSpace(status)
+	}
+
+	if exitCode, err := strconv.Atoi(attributes["exitCode"]); err == nil {
+		event.ExitCode = &exitCode
+	}
+
+	return event
+}
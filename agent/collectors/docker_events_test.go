@@ -0,0 +1,140 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// newTestEventWatcher tworzy obserwator z testowym strumieniem zdarzeń
+func newTestEventWatcher() (*DockerEventWatcher, chan events.Message, chan events.ListOptions) {
+	messages := make(chan events.Message)
+	subscriptions := make(chan events.ListOptions, 1)
+
+	w := &DockerEventWatcher{
+		subscribe: func(ctx context.Context, options events.ListOptions) (<-chan events.Message, <-chan error) {
+			subscriptions <- options
+			return messages, make(chan error)
+		},
+	}
+	return w, messages, subscriptions
+}
+
+// containerMessage tworzy komunikat Docker o zdarzeniu kontenera
+func containerMessage(action, name, image string, at time.Time, attributes map[string]string) events.Message {
+	if attributes == nil {
+		attributes = make(map[string]string)
+	}
+	attributes["name"] = name
+	attributes["image"] = image
+	return events.Message{
+		Type:     events.ContainerEventType,
+		Action:   events.Action(action),
+		Actor:    events.Actor{ID: name + "-id", Attributes: attributes},
+		Time:     at.Unix(),
+		TimeNano: at.UnixNano(),
+	}
+}
+
+func TestDockerEventWatcherCollect(t *testing.T) {
+	w, messages, subscriptions := newTestEventWatcher()
+	defer w.Close()
+
+	reasons := make(chan string, 10)
+	w.SetTrigger(func(reason string) { reasons <- reason })
+
+	// Pierwsze zbieranie uruchamia obserwowanie
+	if _, err := w.Collect(context.Background()); err != nil {
+		t.Fatalf("Błąd pierwszego zbierania: %v", err)
+	}
+
+	select {
+	case options := <-subscriptions:
+		if options.Filters.Get("type")[0] != "container" || len(options.Filters.Get("event")) != len(dockerEventActions) {
+			t.Errorf("Niepoprawne filtry subskrypcji: got %v", options.Filters)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Obserwator nie zasubskrybował zdarzeń")
+	}
+
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	messages <- containerMessage("die", "nginx", "nginx:1.27", at, map[string]string{"exitCode": "137"})
+	messages <- containerMessage("oom", "ollama", "ollama/ollama:latest", at.Add(time.Millisecond), nil)
+	messages <- containerMessage("health_status: unhealthy", "ollama", "ollama/ollama:latest", at.Add(2*time.Millisecond), nil)
+	// Powtórzone zdarzenie (np. po wznowieniu subskrypcji) jest pomijane
+	messages <- containerMessage("die", "nginx", "nginx:1.27", at, map[string]string{"exitCode": "137"})
+	messages <- containerMessage("start", "nginx", "nginx:1.27", at.Add(time.Second), nil)
+
+	// Poczekaj, aż obserwator przetworzy zdarzenia
+	deadline := time.Now().Add(time.Second)
+	for {
+		w.mu.Lock()
+		count := len(w.events)
+		w.mu.Unlock()
+		if count == 4 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	value, err := w.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania zdarzeń: %v", err)
+	}
+	recorded := value.([]models.ContainerEvent)
+	if len(recorded) != 4 {
+		t.Fatalf("Niepoprawna liczba zdarzeń: got %v, want %v (%+v)", len(recorded), 4, recorded)
+	}
+
+	die := recorded[0]
+	if die.Action != "die" || die.ExitCode == nil || *die.ExitCode != 137 || die.ContainerID != "nginx-id" {
+		t.Errorf("Niepoprawne zdarzenie die: got %+v", die)
+	}
+	if die.Time != at.Local().Format(time.RFC3339Nano) {
+		t.Errorf("Niepoprawny czas zdarzenia: got %v", die.Time)
+	}
+	if health := recorded[2]; health.Action != "health_status" || health.Health != "unhealthy" {
+		t.Errorf("Niepoprawne zdarzenie health_status: got %+v", health)
+	}
+	if !recorded[1].IsLLMRelated || recorded[0].IsLLMRelated {
+		t.Errorf("Niepoprawne wykrywanie kontenerów LLM: got %v, %v", recorded[1].IsLLMRelated, recorded[0].IsLLMRelated)
+	}
+
+	// Tylko zdarzenia kontenerów LLM wyzwalają zbieranie danych
+	if len(reasons) != 2 {
+		t.Errorf("Niepoprawna liczba wyzwoleń: got %v, want %v", len(reasons), 2)
+	}
+
+	// Zdarzenia są zwracane tylko raz
+	value, _ = w.Collect(context.Background())
+	if recorded := value.([]models.ContainerEvent); len(recorded) != 0 {
+		t.Errorf("Oczekiwano braku nowych zdarzeń: got %+v", recorded)
+	}
+}
+
+func TestDockerEventWatcherBufferLimit(t *testing.T) {
+	w := &DockerEventWatcher{}
+	at := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < maxBufferedEvents+5; i++ {
+		w.record(containerMessage("restart", "web", "nginx", at.Add(time.Duration(i)*time.Millisecond), nil))
+	}
+
+	if len(w.events) != maxBufferedEvents || w.dropped != 5 {
+		t.Errorf("Niepoprawny bufor zdarzeń: got %v (pominięto %v), want %v (pominięto 5)", len(w.events), w.dropped, maxBufferedEvents)
+	}
+	if w.events[0].Time != at.Add(5*time.Millisecond).Local().Format(time.RFC3339Nano) {
+		t.Errorf("Oczekiwano usunięcia najstarszych zdarzeń: pierwsze zdarzenie z %v", w.events[0].Time)
+	}
+}
+
+func TestDockerEventWatcherUnavailable(t *testing.T) {
+	w := &DockerEventWatcher{lastErr: errors.New("nie można utworzyć klienta Docker")}
+	if _, err := w.Collect(context.Background()); err == nil {
+		t.Error("Oczekiwano błędu, gdy Docker jest niedostępny")
+	}
+}
@@ -0,0 +1,299 @@
+package collectors
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Ograniczenia parsera nagłówka GGUF chroniące przed uszkodzonymi plikami
+const (
+	// ggufMaxHeaderBytes to największa liczba bajtów czytana z nagłówka (słownik
+	// tokenizera dużego modelu zajmuje kilka MB)
+	ggufMaxHeaderBytes = 64 << 20
+	// ggufMaxStringLen to najdłuższy dopuszczalny ciąg metadanych
+	ggufMaxStringLen = 16 << 20
+)
+
+// Typy wartości metadanych GGUF
+const (
+	ggufTypeUint8 uint32 = iota
+	ggufTypeInt8
+	ggufTypeUint16
+	ggufTypeInt16
+	ggufTypeUint32
+	ggufTypeInt32
+	ggufTypeFloat32
+	ggufTypeBool
+	ggufTypeString
+	ggufTypeArray
+	ggufTypeUint64
+	ggufTypeInt64
+	ggufTypeFloat64
+)
+
+// ggufFileTypes to nazwy kwantyzacji dla wartości general.file_type (llama_ftype)
+var ggufFileTypes = map[uint64]string{
+	0: "F32", 1: "F16", 2: "Q4_0", 3: "Q4_1", 7: "Q8_0", 8: "Q5_0", 9: "Q5_1",
+	10: "Q2_K", 11: "Q3_K_S", 12: "Q3_K_M", 13: "Q3_K_L", 14: "Q4_K_S", 15: "Q4_K_M",
+	16: "Q5_K_S", 17: "Q5_K_M", 18: "Q6_K", 19: "IQ2_XXS", 20: "IQ2_XS", 21: "Q2_K_S",
+	22: "IQ3_XS", 23: "IQ3_XXS", 24: "IQ1_S", 25: "IQ4_NL", 26: "IQ3_S", 27: "IQ3_M",
+	28: "IQ2_S", 29: "IQ2_M", 30: "IQ4_XS", 31: "IQ1_M", 32: "BF16", 36: "TQ1_0", 37: "TQ2_0",
+}
+
+// ggufReader czyta wartości nagłówka GGUF w porządku little-endian
+type ggufReader struct {
+	r       *bufio.Reader
+	version uint32
+}
+
+// parseGGUF odczytuje z nagłówka pliku GGUF architekturę, nazwę, kwantyzację i
+// rozmiar kontekstu modelu. Dane tensorów nie są czytane.
+func parseGGUF(r io.Reader) (*models.GGUFMetadata, error) {
+	g := &ggufReader{r: bufio.NewReader(io.LimitReader(r, ggufMaxHeaderBytes))}
+
+	var magic [4]byte
+	if _, err := io.ReadFull(g.r, magic[:]); err != nil {
+		return nil, fmt.Errorf("nie można odczytać nagłówka GGUF: %v", err)
+	}
+	if string(magic[:]) != "GGUF" {
+		return nil, fmt.Errorf("niepoprawna sygnatura GGUF %q", magic[:])
+	}
+
+	version, err := g.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if version < 1 || version > 3 {
+		return nil, fmt.Errorf("nieobsługiwana wersja GGUF %d", version)
+	}
+	g.version = version
+
+	tensorCount, err := g.count()
+	if err != nil {
+		return nil, err
+	}
+	kvCount, err := g.count()
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := &models.GGUFMetadata{Version: version, TensorCount: tensorCount}
+	values := make(map[string]interface{})
+	for i := uint64(0); i < kvCount; i++ {
+		key, err := g.string()
+		if err != nil {
+			return nil, fmt.Errorf("klucz metadanych %d: %v", i, err)
+		}
+		valueType, err := g.uint32()
+		if err != nil {
+			return nil, err
+		}
+
+		// Zapamiętaj tylko potrzebne klucze; pozostałe wartości (np. słownik
+		// tokenizera) są pomijane bez alokacji
+		wanted := strings.HasPrefix(key, "general.") || strings.HasSuffix(key, ".context_length") ||
+			strings.HasSuffix(key, ".embedding_length") || strings.HasSuffix(key, ".block_count")
+		value, err := g.value(valueType, wanted)
+		if err != nil {
+			return nil, fmt.Errorf("wartość %s: %v", key, err)
+		}
+		if wanted {
+			values[key] = value
+		}
+	}
+
+	metadata.Architecture, _ = values["general.architecture"].(string)
+	metadata.Name, _ = values["general.name"].(string)
+	if fileType, ok := ggufUint(values["general.file_type"]); ok {
+		if name, ok := ggufFileTypes[fileType]; ok {
+			metadata.Quantization = name
+		} else {
+			metadata.Quantization = fmt.Sprintf("type_%d", fileType)
+		}
+	}
+	if metadata.Architecture != "" {
+		metadata.ContextLength, _ = ggufUint(values[metadata.Architecture+".context_length"])
+		metadata.EmbeddingLength, _ = ggufUint(values[metadata.Architecture+".embedding_length"])
+		metadata.BlockCount, _ = ggufUint(values[metadata.Architecture+".block_count"])
+	}
+
+	return metadata, nil
+}
+
+// ggufUint zamienia całkowitą wartość metadanych na uint64
+func ggufUint(value interface{}) (uint64, bool) {
+	switch v := value.(type) {
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	case int8:
+		return uint64(v), v >= 0
+	case int16:
+		return uint64(v), v >= 0
+	case int32:
+		return uint64(v), v >= 0
+	case int64:
+		return uint64(v), v >= 0
+	}
+	return 0, false
+}
+
+func (g *ggufReader) uint32() (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(g.r, buf[:]); err != nil {
+		return 0, fmt.Errorf("nieoczekiwany koniec nagłówka GGUF: %v", err)
+	}
+	return binary.LittleEndian.Uint32(buf[:]), nil
+}
+
+func (g *ggufReader) uint64() (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(g.r, buf[:]); err != nil {
+		return 0, fmt.Errorf("nieoczekiwany koniec nagłówka GGUF: %v", err)
+	}
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+// count czyta liczbę elementów; w wersji 1 liczby i długości mają 32 bity
+func (g *ggufReader) count() (uint64, error) {
+	if g.version == 1 {
+		n, err := g.uint32()
+		return uint64(n), err
+	}
+	return g.uint64()
+}
+
+// string czyta ciąg poprzedzony długością
+func (g *ggufReader) string() (string, error) {
+	n, err := g.count()
+	if err != nil {
+		return "", err
+	}
+	if n > ggufMaxStringLen {
+		return "", fmt.Errorf("zbyt długi ciąg (%d B)", n)
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(g.r, buf); err != nil {
+		return "", fmt.Errorf("nieoczekiwany koniec nagłówka GGUF: %v", err)
+	}
+	return string(buf), nil
+}
+
+// skip pomija n bajtów
+func (g *ggufReader) skip(n uint64) error {
+	if _, err := g.r.Discard(int(n)); err != nil {
+		return fmt.Errorf("nieoczekiwany koniec nagłówka GGUF: %v", err)
+	}
+	return nil
+}
+
+// value czyta wartość podanego typu; gdy keep jest fałszywe, wartość jest tylko pomijana
+func (g *ggufReader) value(valueType uint32, keep bool) (interface{}, error) {
+	if size := ggufScalarSize(valueType); size > 0 {
+		var buf [8]byte
+		if _, err := io.ReadFull(g.r, buf[:size]); err != nil {
+			return nil, fmt.Errorf("nieoczekiwany koniec nagłówka GGUF: %v", err)
+		}
+		if !keep {
+			return nil, nil
+		}
+		return ggufScalar(valueType, buf[:size]), nil
+	}
+
+	switch valueType {
+	case ggufTypeString:
+		if keep {
+			return g.string()
+		}
+		n, err := g.count()
+		if err != nil {
+			return nil, err
+		}
+		if n > ggufMaxStringLen {
+			return nil, fmt.Errorf("zbyt długi ciąg (%d B)", n)
+		}
+		return nil, g.skip(n)
+	case ggufTypeArray:
+		elemType, err := g.uint32()
+		if err != nil {
+			return nil, err
+		}
+		n, err := g.count()
+		if err != nil {
+			return nil, err
+		}
+		// Tablice nie są zapamiętywane; tablice liczb pomijane są w całości
+		if size := ggufScalarSize(elemType); size > 0 {
+			if n > ggufMaxHeaderBytes/uint64(size) {
+				return nil, fmt.Errorf("zbyt duża tablica (%d elementów)", n)
+			}
+			return nil, g.skip(n * uint64(size))
+		}
+		if n > ggufMaxHeaderBytes {
+			return nil, fmt.Errorf("zbyt duża tablica (%d elementów)", n)
+		}
+		for i := uint64(0); i < n; i++ {
+			if _, err := g.value(elemType, false); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("nieznany typ wartości %d", valueType)
+}
+
+// ggufScalarSize zwraca rozmiar wartości skalarnej w bajtach lub 0 dla ciągów i tablic
+func ggufScalarSize(valueType uint32) int {
+	switch valueType {
+	case ggufTypeUint8, ggufTypeInt8, ggufTypeBool:
+		return 1
+	case ggufTypeUint16, ggufTypeInt16:
+		return 2
+	case ggufTypeUint32, ggufTypeInt32, ggufTypeFloat32:
+		return 4
+	case ggufTypeUint64, ggufTypeInt64, ggufTypeFloat64:
+		return 8
+	}
+	return 0
+}
+
+// ggufScalar dekoduje wartość skalarną
+func ggufScalar(valueType uint32, buf []byte) interface{} {
+	switch valueType {
+	case ggufTypeUint8:
+		return buf[0]
+	case ggufTypeInt8:
+		return int8(buf[0])
+	case ggufTypeBool:
+		return buf[0] != 0
+	case ggufTypeUint16:
+		return binary.LittleEndian.Uint16(buf)
+	case ggufTypeInt16:
+		return int16(binary.LittleEndian.Uint16(buf))
+	case ggufTypeUint32:
+		return binary.LittleEndian.Uint32(buf)
+	case ggufTypeInt32:
+		return int32(binary.LittleEndian.Uint32(buf))
+	case ggufTypeFloat32:
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf))
+	case ggufTypeUint64:
+		return binary.LittleEndian.Uint64(buf)
+	case ggufTypeInt64:
+		return int64(binary.LittleEndian.Uint64(buf))
+	case ggufTypeFloat64:
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf))
+	}
+	return nil
+}
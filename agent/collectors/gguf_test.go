@@ -0,0 +1,122 @@
+package collectors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// ggufBuilder zapisuje nagłówek GGUF w wersji 3 dla testów
+type ggufBuilder struct {
+	buf bytes.Buffer
+	kvs int
+	kv  bytes.Buffer
+}
+
+func (b *ggufBuilder) str(w *bytes.Buffer, s string) {
+	binary.Write(w, binary.LittleEndian, uint64(len(s)))
+	w.WriteString(s)
+}
+
+func (b *ggufBuilder) addString(key, value string) {
+	b.str(&b.kv, key)
+	binary.Write(&b.kv, binary.LittleEndian, ggufTypeString)
+	b.str(&b.kv, value)
+	b.kvs++
+}
+
+func (b *ggufBuilder) addUint32(key string, value uint32) {
+	b.str(&b.kv, key)
+	binary.Write(&b.kv, binary.LittleEndian, ggufTypeUint32)
+	binary.Write(&b.kv, binary.LittleEndian, value)
+	b.kvs++
+}
+
+func (b *ggufBuilder) addFloat32(key string, value float32) {
+	b.str(&b.kv, key)
+	binary.Write(&b.kv, binary.LittleEndian, ggufTypeFloat32)
+	binary.Write(&b.kv, binary.LittleEndian, value)
+	b.kvs++
+}
+
+func (b *ggufBuilder) addStrings(key string, values []string) {
+	b.str(&b.kv, key)
+	binary.Write(&b.kv, binary.LittleEndian, ggufTypeArray)
+	binary.Write(&b.kv, binary.LittleEndian, ggufTypeString)
+	binary.Write(&b.kv, binary.LittleEndian, uint64(len(values)))
+	for _, value := range values {
+		b.str(&b.kv, value)
+	}
+	b.kvs++
+}
+
+func (b *ggufBuilder) bytes(tensors uint64) []byte {
+	var out bytes.Buffer
+	out.WriteString("GGUF")
+	binary.Write(&out, binary.LittleEndian, uint32(3))
+	binary.Write(&out, binary.LittleEndian, tensors)
+	binary.Write(&out, binary.LittleEndian, uint64(b.kvs))
+	out.Write(b.kv.Bytes())
+	return out.Bytes()
+}
+
+// testGGUF zwraca nagłówek małego modelu llama z kwantyzacją Q4_K_M
+func testGGUF() []byte {
+	b := &ggufBuilder{}
+	b.addString("general.architecture", "llama")
+	b.addString("general.name", "TinyLlama 1.1B Chat")
+	b.addUint32("general.file_type", 15)
+	b.addUint32("llama.context_length", 2048)
+	b.addFloat32("llama.rope.freq_base", 10000)
+	b.addStrings("tokenizer.ggml.tokens", []string{"<unk>", "<s>", "</s>"})
+	b.addUint32("llama.embedding_length", 2048)
+	b.addUint32("llama.block_count", 22)
+	return b.bytes(201)
+}
+
+func TestParseGGUF(t *testing.T) {
+	metadata, err := parseGGUF(bytes.NewReader(testGGUF()))
+	if err != nil {
+		t.Fatalf("Błąd odczytu nagłówka GGUF: %v", err)
+	}
+
+	want := &models.GGUFMetadata{
+		Version:         3,
+		Architecture:    "llama",
+		Name:            "TinyLlama 1.1B Chat",
+		Quantization:    "Q4_K_M",
+		ContextLength:   2048,
+		EmbeddingLength: 2048,
+		BlockCount:      22,
+		TensorCount:     201,
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("Niepoprawne metadane: got %+v, want %+v", metadata, want)
+	}
+}
+
+func TestParseGGUFInvalid(t *testing.T) {
+	header := testGGUF()
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"inna sygnatura", append([]byte("GGML"), header[4:]...), "sygnatura"},
+		{"ucięty nagłówek", header[:len(header)-10], "koniec nagłówka"},
+		{"nieznana wersja", append(append([]byte("GGUF"), 9, 0, 0, 0), header[8:]...), "wersja"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseGGUF(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Oczekiwano błędu %q: got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package collectors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// GPUBackend to źródło informacji o kartach graficznych jednego producenta
+type GPUBackend interface {
+	// Vendor zwraca klucz producenta w mapie Hardware.GPU (np. "nvidia", "amd")
+	Vendor() string
+	// Collect zbiera informacje o kartach; na hostach bez kart producenta
+	// zwraca pustą listę bez błędu
+	Collect() ([]models.GPUDevice, error)
+}
+
+// defaultGPUBackends zwraca wbudowane źródła informacji o kartach graficznych
+func defaultGPUBackends() []GPUBackend {
+	return []GPUBackend{
+		NewNvidiaGPUBackend(),
+		NewAMDGPUBackend(),
+		NewIntelGPUBackend(),
+	}
+}
+
+// collectGPUs zbiera karty graficzne ze wszystkich źródeł. Błąd jednego źródła nie
+// przerywa pozostałych; zwracana mapa zawiera tylko producentów z kartami.
+func collectGPUs(backends []GPUBackend) (map[string][]models.GPUDevice, error) {
+	var gpus map[string][]models.GPUDevice
+	var errs []string
+
+	for _, backend := range backends {
+		devices, err := backend.Collect()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", backend.Vendor(), err))
+		}
+		if len(devices) == 0 {
+			continue
+		}
+		if gpus == nil {
+			gpus = make(map[string][]models.GPUDevice)
+		}
+		gpus[backend.Vendor()] = devices
+	}
+
+	if len(errs) > 0 {
+		return gpus, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return gpus, nil
+}
+
+// DRMGPUBackend odczytuje karty graficzne z /sys/class/drm, bez bibliotek producenta.
+// Dla kart AMD (amdgpu) podaje wykorzystanie, pamięć VRAM, temperaturę i taktowanie,
+// dla kart Intel (i915) temperaturę i taktowanie.
+type DRMGPUBackend struct {
+	root     string
+	vendor   string
+	vendorID string // Identyfikator producenta PCI
+	label    string // Nazwa producenta używana w nazwie karty
+}
+
+// NewAMDGPUBackend tworzy źródło informacji o kartach AMD
+func NewAMDGPUBackend() *DRMGPUBackend {
+	return &DRMGPUBackend{root: sysRoot(), vendor: "amd", vendorID: "0x1002", label: "AMD"}
+}
+
+// NewIntelGPUBackend tworzy źródło informacji o kartach Intel
+func NewIntelGPUBackend() *DRMGPUBackend {
+	return &DRMGPUBackend{root: sysRoot(), vendor: "intel", vendorID: "0x8086", label: "Intel"}
+}
+
+// Vendor zwraca klucz producenta
+func (b *DRMGPUBackend) Vendor() string {
+	return b.vendor
+}
+
+// Collect odczytuje karty producenta z katalogów /sys/class/drm/card*
+func (b *DRMGPUBackend) Collect() ([]models.GPUDevice, error) {
+	cards, _ := filepath.Glob(filepath.Join(b.root, "class", "drm", "card*"))
+	sortNumerically(cards, "")
+
+	devices := make([]models.GPUDevice, 0)
+	for _, card := range cards {
+		// Pomijaj złącza wyświetlaczy, np. card0-DP-1
+		if strings.Contains(filepath.Base(card), "-") {
+			continue
+		}
+
+		device := filepath.Join(card, "device")
+		if readSysfsString(filepath.Join(device, "vendor")) != b.vendorID {
+			continue
+		}
+
+		devices = append(devices, b.collectCard(card, device, len(devices)))
+	}
+
+	return devices, nil
+}
+
+// collectCard odczytuje informacje o pojedynczej karcie; brakujące atrybuty
+// (zależne od sterownika i wersji jądra) pozostają puste
+func (b *DRMGPUBackend) collectCard(card, device string, index int) models.GPUDevice {
+	uevent := readUevent(filepath.Join(device, "uevent"))
+
+	gpu := models.GPUDevice{
+		Index:    index,
+		Name:     readSysfsString(filepath.Join(device, "product_name")),
+		Driver:   uevent["DRIVER"],
+		PCIBusID: uevent["PCI_SLOT_NAME"],
+	}
+	if gpu.Name == "" {
+		gpu.Name = fmt.Sprintf("%s GPU [%s]", b.label, strings.TrimPrefix(readSysfsString(filepath.Join(device, "device")), "0x"))
+	}
+
+	// amdgpu: wykorzystanie w procentach i pamięć VRAM w bajtach
+	if busy, err := readSysfsFloat(filepath.Join(device, "gpu_busy_percent")); err == nil {
+		gpu.UtilizationGPU = busy
+	}
+	if used, err := readSysfsFloat(filepath.Join(device, "mem_info_vram_used")); err == nil {
+		gpu.MemoryUsedMB = used / (1024 * 1024)
+	}
+	if total, err := readSysfsFloat(filepath.Join(device, "mem_info_vram_total")); err == nil {
+		gpu.MemoryTotalMB = total / (1024 * 1024)
+	}
+
+	// Temperatura z pierwszego czujnika hwmon karty (w amdgpu "edge")
+	hwmons, _ := filepath.Glob(filepath.Join(device, "hwmon", "hwmon*"))
+	sortNumerically(hwmons, "")
+	for _, hwmon := range hwmons {
+		if temps := readHwmonSensors(hwmon, "", "", "temp", 1000); len(temps) > 0 {
+			gpu.Temperature = temps[0].Value
+			break
+		}
+	}
+
+	// Taktowanie: i915 udostępnia je w katalogu karty, amdgpu jako listę stanów
+	// pp_dpm_sclk z bieżącym oznaczonym gwiazdką
+	if clock, err := readSysfsFloat(filepath.Join(card, "gt_cur_freq_mhz")); err == nil {
+		gpu.ClockMHz = clock
+	}
+	if clock, err := readSysfsFloat(filepath.Join(card, "gt_max_freq_mhz")); err == nil {
+		gpu.MaxClockMHz = clock
+	}
+	if data, err := os.ReadFile(filepath.Join(device, "pp_dpm_sclk")); err == nil {
+		gpu.ClockMHz, gpu.MaxClockMHz = parseDPMClocks(string(data))
+	}
+
+	return gpu
+}
+
+// parseDPMClocks przetwarza listę stanów taktowania amdgpu, np.
+// "0: 500Mhz\n1: 800Mhz *\n2: 2100Mhz", zwracając bieżące i najwyższe taktowanie
+func parseDPMClocks(data string) (current, highest float64) {
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		clock, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(fields[1]), "mhz"), 64)
+		if err != nil {
+			continue
+		}
+		if clock > highest {
+			highest = clock
+		}
+		if len(fields) > 2 && fields[2] == "*" {
+			current = clock
+		}
+	}
+	return current, highest
+}
+
+// readUevent odczytuje plik uevent urządzenia w formacie KLUCZ=wartość
+func readUevent(path string) map[string]string {
+	values := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return values
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(line), "="); ok {
+			values[key] = value
+		}
+	}
+	return values
+}
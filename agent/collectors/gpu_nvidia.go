@@ -0,0 +1,230 @@
+package collectors
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// nvmlLibrary to część API NVML używana przez NvidiaGPUBackend; w testach jest
+// zastępowana listą fałszywych urządzeń
+type nvmlLibrary interface {
+	Init() nvml.Return
+	Shutdown() nvml.Return
+	DeviceGetCount() (int, nvml.Return)
+	DeviceGetHandleByIndex(index int) (nvmlDevice, nvml.Return)
+}
+
+// nvmlDevice to część API urządzenia NVML używana przez NvidiaGPUBackend
+type nvmlDevice interface {
+	GetName() (string, nvml.Return)
+	GetUUID() (string, nvml.Return)
+	GetTemperature(sensor nvml.TemperatureSensors) (uint32, nvml.Return)
+	GetUtilizationRates() (nvml.Utilization, nvml.Return)
+	GetMemoryInfo() (nvml.Memory, nvml.Return)
+	GetComputeRunningProcesses() ([]nvml.ProcessInfo, nvml.Return)
+	GetGraphicsRunningProcesses() ([]nvml.ProcessInfo, nvml.Return)
+	GetProcessUtilization(lastSeenTimestamp uint64) ([]nvml.ProcessUtilizationSample, nvml.Return)
+}
+
+// systemNVML wywołuje bibliotekę NVML instalowaną razem ze sterownikiem NVIDIA
+type systemNVML struct{}
+
+func (systemNVML) Init() nvml.Return {
+	return nvml.Init()
+}
+
+func (systemNVML) Shutdown() nvml.Return {
+	return nvml.Shutdown()
+}
+
+func (systemNVML) DeviceGetCount() (int, nvml.Return) {
+	return nvml.DeviceGetCount()
+}
+
+func (systemNVML) DeviceGetHandleByIndex(index int) (nvmlDevice, nvml.Return) {
+	device, ret := nvml.DeviceGetHandleByIndex(index)
+	return device, ret
+}
+
+// NvidiaGPUBackend odczytuje karty graficzne NVIDIA przez bibliotekę NVML,
+// razem z procesami korzystającymi z każdej karty
+type NvidiaGPUBackend struct {
+	lib nvmlLibrary
+
+	// Znacznik czasu ostatniej próbki wykorzystania procesów według UUID karty
+	mu       sync.Mutex
+	lastSeen map[string]uint64
+}
+
+// NewNvidiaGPUBackend tworzy źródło informacji o kartach NVIDIA
+func NewNvidiaGPUBackend() *NvidiaGPUBackend {
+	return &NvidiaGPUBackend{
+		lib:      systemNVML{},
+		lastSeen: make(map[string]uint64),
+	}
+}
+
+// Vendor zwraca klucz producenta
+func (b *NvidiaGPUBackend) Vendor() string {
+	return "nvidia"
+}
+
+// Collect zbiera informacje o kartach NVIDIA; na hostach bez biblioteki NVML
+// (bez sterownika NVIDIA) zwraca pustą listę
+func (b *NvidiaGPUBackend) Collect() ([]models.GPUDevice, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Inicjalizuj NVML
+	ret := b.lib.Init()
+	if ret == nvml.ERROR_LIBRARY_NOT_FOUND {
+		return nil, nil
+	}
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nie można zainicjować NVML: %v", nvml.ErrorString(ret))
+	}
+	defer b.lib.Shutdown()
+
+	// Pobierz liczbę urządzeń
+	count, ret := b.lib.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, fmt.Errorf("nie można pobrać liczby urządzeń GPU: %v", nvml.ErrorString(ret))
+	}
+
+	devices := make([]models.GPUDevice, 0, count)
+
+	// Zbierz informacje o każdym urządzeniu
+	for i := 0; i < count; i++ {
+		// Pobierz uchwyt do urządzenia
+		device, ret := b.lib.DeviceGetHandleByIndex(i)
+		if ret != nvml.SUCCESS {
+			fmt.Printf("Ostrzeżenie: nie można pobrać uchwytu dla GPU %d: %v\n", i, nvml.ErrorString(ret))
+			continue
+		}
+
+		// Pobierz nazwę urządzenia
+		name, ret := device.GetName()
+		if ret != nvml.SUCCESS {
+			fmt.Printf("Ostrzeżenie: nie można pobrać nazwy dla GPU %d: %v\n", i, nvml.ErrorString(ret))
+			name = "Unknown NVIDIA GPU"
+		}
+
+		// Pobierz identyfikator urządzenia, stały między restartami
+		uuid, ret := device.GetUUID()
+		if ret != nvml.SUCCESS {
+			uuid = ""
+		}
+
+		// Pobierz temperaturę
+		temp, ret := device.GetTemperature(nvml.TEMPERATURE_GPU)
+		if ret != nvml.SUCCESS {
+			fmt.Printf("Ostrzeżenie: nie można pobrać temperatury dla GPU %d: %v\n", i, nvml.ErrorString(ret))
+			temp = 0
+		}
+
+		// Pobierz wykorzystanie GPU
+		utilization, ret := device.GetUtilizationRates()
+		if ret != nvml.SUCCESS {
+			fmt.Printf("Ostrzeżenie: nie można pobrać wykorzystania dla GPU %d: %v\n", i, nvml.ErrorString(ret))
+			utilization.Gpu = 0
+		}
+
+		// Pobierz informacje o pamięci
+		memory, ret := device.GetMemoryInfo()
+		if ret != nvml.SUCCESS {
+			fmt.Printf("Ostrzeżenie: nie można pobrać informacji o pamięci dla GPU %d: %v\n", i, nvml.ErrorString(ret))
+			memory.Total = 0
+			memory.Used = 0
+		}
+
+		// Utwórz i wypełnij strukturę GPUDevice
+		devices = append(devices, models.GPUDevice{
+			Index:          i,
+			Name:           name,
+			Temperature:    float64(temp),
+			UtilizationGPU: float64(utilization.Gpu),
+			MemoryUsedMB:   bytesToMB(memory.Used),
+			MemoryTotalMB:  bytesToMB(memory.Total),
+			Driver:         "nvidia",
+			UUID:           uuid,
+			Processes:      b.collectProcesses(device, uuid),
+		})
+	}
+
+	return devices, nil
+}
+
+// collectProcesses zwraca procesy obliczeniowe i graficzne karty wraz z zajętą pamięcią
+// i wykorzystaniem SM uśrednionym od poprzedniego zbierania
+func (b *NvidiaGPUBackend) collectProcesses(device nvmlDevice, uuid string) []models.GPUProcess {
+	processes := make(map[int32]*models.GPUProcess)
+
+	add := func(infos []nvml.ProcessInfo, kind string) {
+		for _, info := range infos {
+			pid := int32(info.Pid)
+			if process, ok := processes[pid]; ok {
+				process.Type += "+" + kind
+				continue
+			}
+			process := &models.GPUProcess{PID: pid, Type: kind}
+			// Bez uprawnień (np. w kontenerze) NVML nie podaje zajętej pamięci
+			// (NVML_VALUE_NOT_AVAILABLE)
+			if info.UsedGpuMemory != math.MaxUint64 {
+				process.MemoryUsedMB = bytesToMB(info.UsedGpuMemory)
+			}
+			processes[pid] = process
+		}
+	}
+
+	if infos, ret := device.GetComputeRunningProcesses(); ret == nvml.SUCCESS {
+		add(infos, "compute")
+	}
+	if infos, ret := device.GetGraphicsRunningProcesses(); ret == nvml.SUCCESS {
+		add(infos, "graphics")
+	}
+	if len(processes) == 0 {
+		return nil
+	}
+
+	// Próbki wykorzystania od poprzedniego zbierania; karty bez tej funkcji
+	// (lub bez nowych próbek) zwracają błąd
+	samples, ret := device.GetProcessUtilization(b.lastSeen[uuid])
+	if ret == nvml.SUCCESS {
+		sums := make(map[int32]float64)
+		counts := make(map[int32]int)
+		for _, sample := range samples {
+			pid := int32(sample.Pid)
+			sums[pid] += float64(sample.SmUtil)
+			counts[pid]++
+			if sample.TimeStamp > b.lastSeen[uuid] {
+				b.lastSeen[uuid] = sample.TimeStamp
+			}
+		}
+		for pid, process := range processes {
+			if counts[pid] > 0 {
+				process.UtilizationPercent = sums[pid] / float64(counts[pid])
+			}
+		}
+	}
+
+	result := make([]models.GPUProcess, 0, len(processes))
+	for _, process := range processes {
+		result = append(result, *process)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].PID < result[j].PID
+	})
+
+	return result
+}
+
+// bytesToMB przelicza bajty na MB
+func bytesToMB(bytes uint64) float64 {
+	return float64(bytes) / (1024 * 1024)
+}
@@ -0,0 +1,149 @@
+package collectors
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// fakeNVML to biblioteka NVML z ustaloną listą urządzeń
+type fakeNVML struct {
+	initErr nvml.Return
+	devices []*fakeNVMLDevice
+}
+
+func (l *fakeNVML) Init() nvml.Return {
+	return l.initErr
+}
+
+func (l *fakeNVML) Shutdown() nvml.Return {
+	return nvml.SUCCESS
+}
+
+func (l *fakeNVML) DeviceGetCount() (int, nvml.Return) {
+	return len(l.devices), nvml.SUCCESS
+}
+
+func (l *fakeNVML) DeviceGetHandleByIndex(index int) (nvmlDevice, nvml.Return) {
+	return l.devices[index], nvml.SUCCESS
+}
+
+// fakeNVMLDevice to urządzenie NVML z ustalonymi odczytami
+type fakeNVMLDevice struct {
+	name        string
+	uuid        string
+	memory      nvml.Memory
+	utilization nvml.Utilization
+	compute     []nvml.ProcessInfo
+	graphics    []nvml.ProcessInfo
+	samples     []nvml.ProcessUtilizationSample
+	lastSeen    []uint64 // Znaczniki czasu przekazane do GetProcessUtilization
+}
+
+func (d *fakeNVMLDevice) GetName() (string, nvml.Return) {
+	return d.name, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetUUID() (string, nvml.Return) {
+	return d.uuid, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetTemperature(sensor nvml.TemperatureSensors) (uint32, nvml.Return) {
+	return 65, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetUtilizationRates() (nvml.Utilization, nvml.Return) {
+	return d.utilization, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetMemoryInfo() (nvml.Memory, nvml.Return) {
+	return d.memory, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetComputeRunningProcesses() ([]nvml.ProcessInfo, nvml.Return) {
+	return d.compute, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetGraphicsRunningProcesses() ([]nvml.ProcessInfo, nvml.Return) {
+	return d.graphics, nvml.SUCCESS
+}
+
+func (d *fakeNVMLDevice) GetProcessUtilization(lastSeenTimestamp uint64) ([]nvml.ProcessUtilizationSample, nvml.Return) {
+	d.lastSeen = append(d.lastSeen, lastSeenTimestamp)
+	if len(d.samples) == 0 {
+		return nil, nvml.ERROR_NOT_FOUND
+	}
+	return d.samples, nvml.SUCCESS
+}
+
+func TestNvidiaGPUBackend(t *testing.T) {
+	busy := &fakeNVMLDevice{
+		name:        "NVIDIA A100-SXM4-80GB",
+		uuid:        "GPU-1111",
+		memory:      nvml.Memory{Total: 80 << 30, Used: 72 << 30},
+		utilization: nvml.Utilization{Gpu: 90},
+		compute: []nvml.ProcessInfo{
+			{Pid: 4242, UsedGpuMemory: 70 << 30},
+			{Pid: 100, UsedGpuMemory: math.MaxUint64},
+		},
+		graphics: []nvml.ProcessInfo{{Pid: 4242, UsedGpuMemory: 70 << 30}},
+		samples: []nvml.ProcessUtilizationSample{
+			{Pid: 4242, TimeStamp: 1000, SmUtil: 80},
+			{Pid: 4242, TimeStamp: 2000, SmUtil: 90},
+		},
+	}
+	idle := &fakeNVMLDevice{name: "NVIDIA A100-SXM4-80GB", uuid: "GPU-2222", memory: nvml.Memory{Total: 80 << 30}}
+
+	b := &NvidiaGPUBackend{
+		lib:      &fakeNVML{devices: []*fakeNVMLDevice{busy, idle}},
+		lastSeen: make(map[string]uint64),
+	}
+
+	devices, err := b.Collect()
+	if err != nil {
+		t.Fatalf("Błąd odczytu kart NVIDIA: %v", err)
+	}
+	if len(devices) != 2 {
+		t.Fatalf("Niepoprawna liczba kart: got %d, want %d", len(devices), 2)
+	}
+
+	gpu := devices[0]
+	if gpu.UUID != "GPU-1111" || gpu.MemoryUsedMB != 72*1024 || gpu.UtilizationGPU != 90 || gpu.Temperature != 65 {
+		t.Errorf("Niepoprawna karta: got %+v", gpu)
+	}
+	want := []models.GPUProcess{
+		// Pamięć niedostępna bez uprawnień
+		{PID: 100, Type: "compute"},
+		{PID: 4242, Type: "compute+graphics", MemoryUsedMB: 70 * 1024, UtilizationPercent: 85},
+	}
+	if !reflect.DeepEqual(gpu.Processes, want) {
+		t.Errorf("Niepoprawne procesy karty: got %+v, want %+v", gpu.Processes, want)
+	}
+	if devices[1].Processes != nil {
+		t.Errorf("Nieoczekiwane procesy bezczynnej karty: got %+v", devices[1].Processes)
+	}
+
+	// Kolejne zbieranie pyta tylko o próbki nowsze od ostatniej
+	if _, err := b.Collect(); err != nil {
+		t.Fatalf("Błąd odczytu kart NVIDIA: %v", err)
+	}
+	if !reflect.DeepEqual(busy.lastSeen, []uint64{0, 2000}) {
+		t.Errorf("Niepoprawne znaczniki czasu próbek: got %v, want %v", busy.lastSeen, []uint64{0, 2000})
+	}
+}
+
+func TestNvidiaGPUBackendWithoutLibrary(t *testing.T) {
+	b := &NvidiaGPUBackend{lib: &fakeNVML{initErr: nvml.ERROR_LIBRARY_NOT_FOUND}, lastSeen: make(map[string]uint64)}
+	if devices, err := b.Collect(); devices != nil || err != nil {
+		t.Errorf("Oczekiwano braku kart bez NVML: got %+v, %v", devices, err)
+	}
+
+	b.lib = &fakeNVML{initErr: nvml.ERROR_DRIVER_NOT_LOADED}
+	if _, err := b.Collect(); err == nil {
+		t.Error("Oczekiwano błędu, gdy sterownik nie jest załadowany")
+	}
+}
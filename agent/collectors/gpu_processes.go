@@ -0,0 +1,139 @@
+package collectors
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// GPUProcessEnricher przypisuje użycie kart graficznych do procesów i usług na podstawie
+// list procesów zgłaszanych przez sterownik (obecnie NVML). Usługa otrzymuje sumę użycia
+// swojego głównego procesu i procesów należących do jej kontenera lub jednostki systemd.
+type GPUProcessEnricher struct{}
+
+// NewGPUProcessEnricher tworzy nowy moduł przypisujący użycie GPU
+func NewGPUProcessEnricher() *GPUProcessEnricher {
+	return &GPUProcessEnricher{}
+}
+
+// Name zwraca nazwę modułu
+func (e *GPUProcessEnricher) Name() string {
+	return "gpu_processes"
+}
+
+// Enrich uzupełnia pola GPU procesów i usług
+func (e *GPUProcessEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	if state.Hardware == nil {
+		return nil
+	}
+
+	usage := gpuUsageByPID(state.Hardware.GPU)
+	if len(usage) == 0 {
+		return nil
+	}
+
+	for i := range state.Processes {
+		state.Processes[i].GPU = usage[state.Processes[i].PID]
+	}
+
+	for i := range state.Services {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		service := &state.Services[i]
+		pids := make(map[int32]bool)
+		if service.PID > 0 {
+			pids[service.PID] = true
+		}
+		for _, process := range state.Processes {
+			if processBelongsToService(&process, service) {
+				pids[process.PID] = true
+			}
+		}
+
+		var usages []models.GPUUsage
+		for pid := range pids {
+			usages = append(usages, usage[pid]...)
+		}
+		service.GPU = sumGPUUsage(usages)
+	}
+
+	return nil
+}
+
+// gpuUsageByPID zamienia listy procesów kart na użycie GPU według PID
+func gpuUsageByPID(gpus map[string][]models.GPUDevice) map[int32][]models.GPUUsage {
+	vendors := make([]string, 0, len(gpus))
+	for vendor := range gpus {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+
+	usage := make(map[int32][]models.GPUUsage)
+	for _, vendor := range vendors {
+		for _, gpu := range gpus[vendor] {
+			for _, process := range gpu.Processes {
+				usage[process.PID] = append(usage[process.PID], models.GPUUsage{
+					Vendor:             vendor,
+					Index:              gpu.Index,
+					UUID:               gpu.UUID,
+					MemoryUsedMB:       process.MemoryUsedMB,
+					UtilizationPercent: process.UtilizationPercent,
+				})
+			}
+		}
+	}
+
+	return usage
+}
+
+// processBelongsToService sprawdza, czy proces należy do kontenera, poda lub
+// jednostki systemd usługi
+func processBelongsToService(process *models.Process, service *models.Service) bool {
+	if process.ContainerID != "" {
+		if service.IsContainer() && service.ID == process.ContainerID {
+			return true
+		}
+		if service.Kubernetes != nil {
+			for _, container := range service.Kubernetes.Containers {
+				// Identyfikator kontenera poda zawiera środowisko, np. "containerd://<id>"
+				if i := strings.Index(container.ContainerID, "://"); i >= 0 && container.ContainerID[i+3:] == process.ContainerID {
+					return true
+				}
+			}
+		}
+	}
+
+	return process.SystemdUnit != "" && service.Systemd != nil && service.Systemd.Unit == process.SystemdUnit
+}
+
+// sumGPUUsage sumuje użycie tych samych kart przez wiele procesów, zachowując
+// kolejność producentów i numerów kart
+func sumGPUUsage(usages []models.GPUUsage) []models.GPUUsage {
+	if len(usages) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(usages, func(i, j int) bool {
+		if usages[i].Vendor != usages[j].Vendor {
+			return usages[i].Vendor < usages[j].Vendor
+		}
+		return usages[i].Index < usages[j].Index
+	})
+
+	result := make([]models.GPUUsage, 0, len(usages))
+	for _, u := range usages {
+		last := len(result) - 1
+		if last >= 0 && result[last].Vendor == u.Vendor && result[last].Index == u.Index {
+			result[last].MemoryUsedMB += u.MemoryUsedMB
+			result[last].UtilizationPercent += u.UtilizationPercent
+			continue
+		}
+		result = append(result, u)
+	}
+
+	return result
+}
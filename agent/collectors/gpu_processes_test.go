@@ -0,0 +1,81 @@
+package collectors
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+func TestGPUProcessEnricher(t *testing.T) {
+	containerID := "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	podContainerID := "fedcba9876543210fedcba9876543210fedcba9876543210fedcba9876543210"
+
+	state := models.NewSystemState()
+	state.Hardware = &models.Hardware{
+		GPU: map[string][]models.GPUDevice{
+			"nvidia": {
+				{Index: 0, UUID: "GPU-0", Processes: []models.GPUProcess{
+					{PID: 10, MemoryUsedMB: 1000, UtilizationPercent: 40},
+					{PID: 11, MemoryUsedMB: 500, UtilizationPercent: 20},
+					{PID: 20, MemoryUsedMB: 300},
+				}},
+				{Index: 1, UUID: "GPU-1", Processes: []models.GPUProcess{
+					{PID: 11, MemoryUsedMB: 2000, UtilizationPercent: 50},
+					{PID: 30, MemoryUsedMB: 4000},
+				}},
+			},
+		},
+	}
+	state.Processes = []models.Process{
+		{PID: 10, Name: "ollama", SystemdUnit: "ollama.service"},
+		{PID: 11, Name: "ollama_llama_server", SystemdUnit: "ollama.service"},
+		{PID: 20, Name: "python3", ContainerID: containerID},
+		{PID: 30, Name: "text-generation-launcher", ContainerID: podContainerID},
+		{PID: 40, Name: "bash"},
+	}
+	state.Services = []models.Service{
+		{Name: "ollama", Type: "systemd", PID: 10, Systemd: &models.SystemdUnit{Unit: "ollama.service"}},
+		{Name: "vllm", Type: "docker", ID: containerID, PID: 20},
+		{Name: "llm/tgi-0", Type: "k8s-pod", Kubernetes: &models.KubernetesPod{
+			Containers: []models.KubernetesContainer{{Name: "tgi", ContainerID: "containerd://" + podContainerID}},
+		}},
+		{Name: "nginx", Type: "systemd", PID: 50, Systemd: &models.SystemdUnit{Unit: "nginx.service"}},
+	}
+
+	if err := NewGPUProcessEnricher().Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd przypisania GPU: %v", err)
+	}
+
+	// Proces korzystający z dwóch kart
+	want := []models.GPUUsage{
+		{Vendor: "nvidia", Index: 0, UUID: "GPU-0", MemoryUsedMB: 500, UtilizationPercent: 20},
+		{Vendor: "nvidia", Index: 1, UUID: "GPU-1", MemoryUsedMB: 2000, UtilizationPercent: 50},
+	}
+	if got := state.Processes[1].GPU; !reflect.DeepEqual(got, want) {
+		t.Errorf("Niepoprawne użycie GPU procesu: got %+v, want %+v", got, want)
+	}
+	if state.Processes[4].GPU != nil {
+		t.Errorf("Nieoczekiwane użycie GPU: got %+v", state.Processes[4].GPU)
+	}
+
+	// Usługa systemd sumuje procesy swojej jednostki
+	want = []models.GPUUsage{
+		{Vendor: "nvidia", Index: 0, UUID: "GPU-0", MemoryUsedMB: 1500, UtilizationPercent: 60},
+		{Vendor: "nvidia", Index: 1, UUID: "GPU-1", MemoryUsedMB: 2000, UtilizationPercent: 50},
+	}
+	if got := state.Services[0].GPU; !reflect.DeepEqual(got, want) {
+		t.Errorf("Niepoprawne użycie GPU usługi: got %+v, want %+v", got, want)
+	}
+
+	if got := state.Services[1].GPU; len(got) != 1 || got[0].MemoryUsedMB != 300 {
+		t.Errorf("Niepoprawne użycie GPU kontenera: got %+v", got)
+	}
+	if got := state.Services[2].GPU; len(got) != 1 || got[0].Index != 1 || got[0].MemoryUsedMB != 4000 {
+		t.Errorf("Niepoprawne użycie GPU poda: got %+v", got)
+	}
+	if state.Services[3].GPU != nil {
+		t.Errorf("Nieoczekiwane użycie GPU usługi: got %+v", state.Services[3].GPU)
+	}
+}
@@ -0,0 +1,127 @@
+package collectors
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// writeDRMFixtures tworzy w katalogu root przykładowe /sys/class/drm z kartą Intel,
+// kartą AMD i kartą NVIDIA
+func writeDRMFixtures(t *testing.T, root string) {
+	t.Helper()
+	drm := filepath.Join(root, "class", "drm")
+
+	writeCgroupFiles(t, filepath.Join(drm, "card0"), map[string]string{
+		"gt_cur_freq_mhz": "1150\n",
+		"gt_max_freq_mhz": "2400\n",
+	})
+	writeCgroupFiles(t, filepath.Join(drm, "card0", "device"), map[string]string{
+		"vendor": "0x8086\n",
+		"device": "0x56a0\n",
+		"uevent": "DRIVER=i915\nPCI_CLASS=30000\nPCI_SLOT_NAME=0000:03:00.0\n",
+	})
+
+	writeCgroupFiles(t, filepath.Join(drm, "card1", "device"), map[string]string{
+		"vendor":              "0x1002\n",
+		"device":              "0x740f\n",
+		"product_name":        "AMD Instinct MI210\n",
+		"uevent":              "DRIVER=amdgpu\nPCI_SLOT_NAME=0000:c1:00.0\n",
+		"gpu_busy_percent":    "87\n",
+		"mem_info_vram_used":  "34359738368\n",
+		"mem_info_vram_total": "68719476736\n",
+		"pp_dpm_sclk":         "0: 500Mhz\n1: 1400Mhz *\n2: 1700Mhz\n",
+	})
+	writeCgroupFiles(t, filepath.Join(drm, "card1", "device", "hwmon", "hwmon5"), map[string]string{
+		"name":        "amdgpu\n",
+		"temp1_input": "61000\n",
+		"temp1_label": "edge\n",
+		"temp2_input": "75000\n",
+		"temp2_label": "junction\n",
+	})
+	// Złącze wyświetlacza nie jest osobną kartą
+	writeCgroupFiles(t, filepath.Join(drm, "card1-DP-1", "device"), map[string]string{"vendor": "0x1002\n"})
+
+	writeCgroupFiles(t, filepath.Join(drm, "card2", "device"), map[string]string{"vendor": "0x10de\n"})
+}
+
+func TestDRMGPUBackend(t *testing.T) {
+	root := t.TempDir()
+	writeDRMFixtures(t, root)
+
+	amd := &DRMGPUBackend{root: root, vendor: "amd", vendorID: "0x1002", label: "AMD"}
+	devices, err := amd.Collect()
+	if err != nil {
+		t.Fatalf("Błąd odczytu kart AMD: %v", err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("Niepoprawna liczba kart AMD: got %+v", devices)
+	}
+	want := models.GPUDevice{
+		Index:          0,
+		Name:           "AMD Instinct MI210",
+		Temperature:    61,
+		UtilizationGPU: 87,
+		MemoryUsedMB:   32768,
+		MemoryTotalMB:  65536,
+		ClockMHz:       1400,
+		MaxClockMHz:    1700,
+		Driver:         "amdgpu",
+		PCIBusID:       "0000:c1:00.0",
+	}
+	if !reflect.DeepEqual(devices[0], want) {
+		t.Errorf("Niepoprawna karta AMD: got %+v, want %+v", devices[0], want)
+	}
+
+	intel := &DRMGPUBackend{root: root, vendor: "intel", vendorID: "0x8086", label: "Intel"}
+	devices, err = intel.Collect()
+	if err != nil || len(devices) != 1 {
+		t.Fatalf("Niepoprawne karty Intel: got %+v, %v", devices, err)
+	}
+	if got := devices[0]; got.Name != "Intel GPU [56a0]" || got.ClockMHz != 1150 || got.MaxClockMHz != 2400 || got.Driver != "i915" || got.MemoryTotalMB != 0 {
+		t.Errorf("Niepoprawna karta Intel: got %+v", got)
+	}
+
+	// Bez /sys/class/drm źródło nie zwraca kart ani błędu
+	empty := &DRMGPUBackend{root: t.TempDir(), vendor: "amd", vendorID: "0x1002", label: "AMD"}
+	if devices, err := empty.Collect(); err != nil || len(devices) != 0 {
+		t.Errorf("Oczekiwano braku kart: got %+v, %v", devices, err)
+	}
+}
+
+// fakeGPUBackend to źródło GPU zwracające ustalone dane
+type fakeGPUBackend struct {
+	vendor  string
+	devices []models.GPUDevice
+	err     error
+}
+
+func (b *fakeGPUBackend) Vendor() string {
+	return b.vendor
+}
+
+func (b *fakeGPUBackend) Collect() ([]models.GPUDevice, error) {
+	return b.devices, b.err
+}
+
+func TestCollectGPUs(t *testing.T) {
+	gpus, err := collectGPUs([]GPUBackend{
+		&fakeGPUBackend{vendor: "nvidia", err: fmt.Errorf("nie można zainicjować NVML")},
+		&fakeGPUBackend{vendor: "amd", devices: []models.GPUDevice{{Name: "MI300X"}}},
+		&fakeGPUBackend{vendor: "intel"},
+	})
+	if err == nil || err.Error() != "nvidia: nie można zainicjować NVML" {
+		t.Errorf("Niepoprawny błąd: got %v", err)
+	}
+	if len(gpus) != 1 || len(gpus["amd"]) != 1 || gpus["amd"][0].Name != "MI300X" {
+		t.Errorf("Niepoprawne karty: got %+v", gpus)
+	}
+
+	// Bez kart mapa pozostaje pusta (pole gpu jest pomijane w JSON)
+	if gpus, err := collectGPUs([]GPUBackend{&fakeGPUBackend{vendor: "amd"}}); gpus != nil || err != nil {
+		t.Errorf("Oczekiwano braku kart: got %+v, %v", gpus, err)
+	}
+}
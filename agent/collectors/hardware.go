@@ -132,8 +132,13 @@ func CollectHardwareInfo() (*models.Hardware, error) {
 		}
 	}
 
-	// Informacje o GPU (tylko dla NVIDIA)
+	// Informacje o GPU: NVIDIA przez nvidia-smi, AMD i Intel z sysfs
 	hardware.GPU = collectGPUInfo()
+	for _, backend := range []GPUBackend{NewAMDGPUBackend(), NewIntelGPUBackend()} {
+		if devices, err := backend.Collect(); err == nil && len(devices) > 0 {
+			hardware.GPU[backend.Vendor()] = devices
+		}
+	}
 
 	return hardware, nil
 }
@@ -220,17 +225,21 @@ import (
 	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
 
-	"github.com/NVIDIA/go-nvml/pkg/nvml"
-
 	"gitlab.com/safetytwin/safetytwin/agent/models"
 )
 
 // HardwareCollector zbiera informacje o sprzęcie
-type HardwareCollector struct {}
+type HardwareCollector struct {
+	sensors     *SensorCollector
+	gpuBackends []GPUBackend
+}
 
 // NewHardwareCollector tworzy nowy kolektor informacji o sprzęcie
 func NewHardwareCollector() *HardwareCollector {
-	return &HardwareCollector{}
+	return &HardwareCollector{
+		sensors:     NewSensorCollector(),
+		gpuBackends: defaultGPUBackends(),
+	}
 }
 
 // Collect zbiera informacje o sprzęcie i zwraca wypełniony obiekt Hardware
@@ -268,6 +277,17 @@ func (c *HardwareCollector) Collect() (*models.Hardware, error) {
 		fmt.Printf("Ostrzeżenie: nie można zebrać informacji o GPU: %v\n", err)
 	}
 
+	// Zbierz wskaźniki nasycenia systemu (obciążenie, PSI, liczniki jądra)
+	kernelStats, err := collectKernelStats(procRoot())
+	if err != nil {
+		fmt.Printf("Ostrzeżenie: nie można zebrać statystyk jądra: %v\n", err)
+	} else {
+		hardware.Kernel = kernelStats
+	}
+
+	// Zbierz odczyty czujników temperatury, wentylatorów, napięć i energii
+	hardware.Sensors = c.sensors.Collect()
+
 	return hardware, nil
 }
 
@@ -504,88 +524,9 @@ func (c *HardwareCollector) collectNetworkInfo(hardware *models.Hardware) error
 	return nil
 }
 
-// collectGPUInfo zbiera informacje o kartach graficznych NVIDIA
+// collectGPUInfo zbiera informacje o kartach graficznych wszystkich producentów
 func (c *HardwareCollector) collectGPUInfo(hardware *models.Hardware) error {
-	// Inicjalizuj NVML
-	ret := nvml.Init()
-	if ret != nvml.SUCCESS {
-		// Jeśli inicjalizacja się nie powiedzie, zwróć błąd, ale nie przerywaj
-		return fmt.Errorf("nie można zainicjować NVML: %v", nvml.ErrorString(ret))
-	}
-	defer nvml.Shutdown()
-
-	// Pobierz liczbę urządzeń
-	count, ret := nvml.DeviceGetCount()
-	if ret != nvml.SUCCESS {
-		return fmt.Errorf("nie można pobrać liczby urządzeń GPU: %v", nvml.ErrorString(ret))
-	}
-
-	// Jeśli nie ma urządzeń, zakończ
-	if count == 0 {
-		return nil
-	}
-
-	// Utwórz mapę na informacje o GPU
-	gpuMap := make(map[string][]models.GPUDevice)
-	gpuMap["nvidia"] = make([]models.GPUDevice, 0, count)
-
-	// Zbierz informacje o każdym urządzeniu
-	for i := 0; i < count; i++ {
-		// Pobierz uchwyt do urządzenia
-		device, ret := nvml.DeviceGetHandleByIndex(i)
-		if ret != nvml.SUCCESS {
-			fmt.Printf("Ostrzeżenie: nie można pobrać uchwytu dla GPU %d: %v\n", i, nvml.ErrorString(ret))
-			continue
-		}
-
-		// Pobierz nazwę urządzenia
-		name, ret := device.GetName()
-		if ret != nvml.SUCCESS {
-			fmt.Printf("Ostrzeżenie: nie można pobrać nazwy dla GPU %d: %v\n", i, nvml.ErrorString(ret))
-			name = "Unknown NVIDIA GPU"
-		}
-
-		// Pobierz temperaturę
-		temp, ret := device.GetTemperature(nvml.TEMPERATURE_GPU)
-		if ret != nvml.SUCCESS {
-			fmt.Printf("Ostrzeżenie: nie można pobrać temperatury dla GPU %d: %v\n", i, nvml.ErrorString(ret))
-			temp = 0
-		}
-
-		// Pobierz wykorzystanie GPU
-		utilization, ret := device.GetUtilizationRates()
-		if ret != nvml.SUCCESS {
-			fmt.Printf("Ostrzeżenie: nie można pobrać wykorzystania dla GPU %d: %v\n", i, nvml.ErrorString(ret))
-			utilization.Gpu = 0
-		}
-
-		// Pobierz informacje o pamięci
-		memory, ret := device.GetMemoryInfo()
-		if ret != nvml.SUCCESS {
-			fmt.Printf("Ostrzeżenie: nie można pobrać informacji o pamięci dla GPU %d: %v\n", i, nvml.ErrorString(ret))
-			memory.Total = 0
-			memory.Used = 0
-		}
-
-		// Konwertuj bajty na MB
-		toMB := func(bytes uint64) float64 {
-			return float64(bytes) / (1024 * 1024)
-		}
-
-		// Utwórz i wypełnij strukturę GPUDevice
-		gpuDevice := models.GPUDevice{
-			Index:           i,
-			Name:            name,
-			Temperature:     float64(temp),
-			UtilizationGPU:  float64(utilization.Gpu),
-			MemoryUsedMB:    toMB(memory.Used),
-			MemoryTotalMB:   toMB(memory.Total),
-		}
-
-		gpuMap["nvidia"] = append(gpuMap["nvidia"], gpuDevice)
-	}
-
-	hardware.GPU = gpuMap
-
-	return nil
+	gpus, err := collectGPUs(c.gpuBackends)
+	hardware.GPU = gpus
+	return err
 }
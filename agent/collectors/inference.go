@@ -0,0 +1,541 @@
+package collectors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/classify"
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+const (
+	// inferenceTimeout ogranicza czas pojedynczego zapytania do API serwera inferencji
+	inferenceTimeout = 3 * time.Second
+	// inferenceMaxBody to największa odczytywana odpowiedź API
+	inferenceMaxBody = 8 << 20
+	// inferenceReprobeAfter to czas, po którym port bez rozpoznanego API jest sprawdzany
+	// ponownie (serwer mógł jeszcze ładować model)
+	inferenceReprobeAfter = 5 * time.Minute
+)
+
+// inferenceMetricFields przypisuje metryki Prometheus serwerów inferencji do pól
+// InferenceMetrics. Wartości z różnymi etykietami (np. kilka modeli) są sumowane.
+var inferenceMetricFields = map[string]string{
+	"vllm:num_requests_running":        "running",
+	"vllm:num_requests_waiting":        "waiting",
+	"vllm:prompt_tokens_total":         "prompt",
+	"vllm:generation_tokens_total":     "generation",
+	"vllm:gpu_cache_usage_perc":        "kv_cache",
+	"vllm:kv_cache_usage_perc":         "kv_cache",
+	"tgi_batch_current_size":           "running",
+	"tgi_queue_size":                   "waiting",
+	"tgi_request_input_length_sum":     "prompt",
+	"tgi_request_generated_tokens_sum": "generation",
+	"llamacpp:requests_processing":     "running",
+	"llamacpp:requests_deferred":       "waiting",
+	"llamacpp:prompt_tokens_total":     "prompt",
+	"llamacpp:tokens_predicted_total":  "generation",
+}
+
+// inferenceProbe to wynik rozpoznania API na porcie procesu
+type inferenceProbe struct {
+	kind string // Pusty, gdy port nie udostępnia znanego API
+	at   time.Time
+}
+
+// inferenceSample to liczniki tokenów z poprzedniego zbierania
+type inferenceSample struct {
+	prompt     float64
+	generation float64
+	at         time.Time
+}
+
+// inferenceEndpoint to adres API wykryty na porcie nasłuchującym procesu
+type inferenceEndpoint struct {
+	url     string
+	process *models.Process
+}
+
+// InferenceEnricher wykrywa lokalne serwery inferencji (Ollama, vLLM, TGI i inne API
+// zgodne z OpenAI) na portach nasłuchujących procesów związanych z LLM i odpytuje
+// ich API o załadowane modele, długość kolejki i przepustowość w tokenach.
+// Moduł jest opcjonalny i musi zostać włączony w konfiguracji ("inference").
+type InferenceEnricher struct {
+	client *http.Client
+
+	mu      sync.Mutex
+	probes  map[string]inferenceProbe  // Według PID i adresu
+	samples map[string]inferenceSample // Według PID i adresu
+}
+
+// NewInferenceEnricher tworzy nowy moduł odpytujący serwery inferencji
+func NewInferenceEnricher() *InferenceEnricher {
+	return &InferenceEnricher{
+		client:  &http.Client{Timeout: inferenceTimeout},
+		probes:  make(map[string]inferenceProbe),
+		samples: make(map[string]inferenceSample),
+	}
+}
+
+// Name zwraca nazwę modułu
+func (e *InferenceEnricher) Name() string {
+	return "inference"
+}
+
+// Enrich wypełnia listę serwerów inferencji w stanie systemu. Rodzaj API jest
+// rozpoznawany raz dla każdego procesu i portu; przepustowość jest liczona względem
+// poprzedniego zbierania, więc pierwsze zbieranie jej nie podaje.
+func (e *InferenceEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	var servers []models.InferenceServer
+
+	for _, endpoint := range inferenceEndpoints(state) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%d %s", endpoint.process.PID, endpoint.url)
+		seen[key] = true
+
+		probe, ok := e.probes[key]
+		if !ok || (probe.kind == "" && now.Sub(probe.at) >= inferenceReprobeAfter) {
+			probe = inferenceProbe{kind: e.probe(ctx, endpoint.url), at: now}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			e.probes[key] = probe
+		}
+		if probe.kind == "" {
+			continue
+		}
+
+		server := e.query(ctx, probe.kind, endpoint)
+		if server.Metrics != nil {
+			current := inferenceSample{prompt: server.Metrics.PromptTokensTotal, generation: server.Metrics.GenerationTokensTotal, at: now}
+			if previous, ok := e.samples[key]; ok {
+				server.Metrics.PromptTokensPerSecond, server.Metrics.GenerationTokensPerSecond = inferenceThroughput(previous, current)
+			}
+			e.samples[key] = current
+		}
+		servers = append(servers, server)
+	}
+
+	// Zapomnij procesy i porty, które zniknęły
+	for key := range e.probes {
+		if !seen[key] {
+			delete(e.probes, key)
+			delete(e.samples, key)
+		}
+	}
+
+	state.InferenceServers = servers
+	return nil
+}
+
+// inferenceEndpoints zwraca adresy portów nasłuchujących procesów związanych z LLM.
+// Porty procesów w kontenerach są osiągalne tylko przez porty opublikowane na hoście.
+// Adres wspólny dla kilku procesów (np. procesów roboczych serwera) jest przypisywany
+// procesowi o najniższym PID.
+func inferenceEndpoints(state *models.SystemState) []inferenceEndpoint {
+	processes := make([]*models.Process, 0, len(state.Processes))
+	for i := range state.Processes {
+		process := &state.Processes[i]
+		// Bazy wektorowe mają własne API, niezwiązane z inferencją
+		if !process.IsLLMRelated || (process.LLM != nil && process.LLM.Category == classify.CategoryVectorDB) {
+			continue
+		}
+		processes = append(processes, process)
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PID < processes[j].PID
+	})
+
+	seen := make(map[string]bool)
+	var endpoints []inferenceEndpoint
+	for _, process := range processes {
+		for _, conn := range process.Connections {
+			if conn.Status != "LISTEN" || conn.LocalAddress == nil || conn.LocalAddress.Port == 0 {
+				continue
+			}
+
+			var address string
+			if process.ContainerID != "" {
+				address = publishedAddress(state.Services, process.ContainerID, conn.LocalAddress.Port)
+			} else {
+				address = net.JoinHostPort(loopbackAddress(conn.LocalAddress.IP), strconv.Itoa(int(conn.LocalAddress.Port)))
+			}
+			if address == "" || seen[address] {
+				continue
+			}
+			seen[address] = true
+			endpoints = append(endpoints, inferenceEndpoint{url: "http://" + address, process: process})
+		}
+	}
+
+	return endpoints
+}
+
+// publishedAddress zwraca adres na hoście, pod którym opublikowano port kontenera
+func publishedAddress(services []models.Service, containerID string, port uint32) string {
+	for i := range services {
+		service := &services[i]
+		if !service.IsContainer() || service.ID != containerID {
+			continue
+		}
+		for _, published := range service.Ports {
+			if strings.TrimSuffix(published.ContainerPort, "/tcp") == strconv.Itoa(int(port)) && published.HostPort != "" {
+				return net.JoinHostPort(loopbackAddress(published.HostIP), published.HostPort)
+			}
+		}
+	}
+	return ""
+}
+
+// loopbackAddress zamienia adres nasłuchu na wszystkich interfejsach na adres pętli zwrotnej
+func loopbackAddress(ip string) string {
+	switch ip {
+	case "", "0.0.0.0", "::", "*":
+		return "127.0.0.1"
+	}
+	return ip
+}
+
+// probe rozpoznaje rodzaj API serwera: ollama, tgi, vllm lub openai; dla innych
+// usług zwraca pusty ciąg
+func (e *InferenceEnricher) probe(ctx context.Context, base string) string {
+	var version struct {
+		Version string `json:"version"`
+	}
+	if e.getJSON(ctx, base+"/api/version", &version) == nil && version.Version != "" {
+		return "ollama"
+	}
+
+	var info tgiInfo
+	if e.getJSON(ctx, base+"/info", &info) == nil && info.ModelID != "" {
+		return "tgi"
+	}
+
+	var list openAIModelList
+	if e.getJSON(ctx, base+"/v1/models", &list) == nil && list.Object == "list" {
+		for _, model := range list.Data {
+			if model.OwnedBy == "vllm" {
+				return "vllm"
+			}
+		}
+		return "openai"
+	}
+
+	return ""
+}
+
+// query odpytuje API serwera o rozpoznanym rodzaju
+func (e *InferenceEnricher) query(ctx context.Context, kind string, endpoint inferenceEndpoint) models.InferenceServer {
+	server := models.InferenceServer{
+		Kind:        kind,
+		Endpoint:    endpoint.url,
+		PID:         endpoint.process.PID,
+		ContainerID: endpoint.process.ContainerID,
+	}
+
+	var err error
+	switch kind {
+	case "ollama":
+		err = e.queryOllama(ctx, &server)
+	case "tgi":
+		err = e.queryTGI(ctx, &server)
+	default:
+		err = e.queryOpenAI(ctx, &server)
+	}
+
+	// Ollama nie udostępnia metryk; inne serwery API OpenAI (np. llama.cpp) tylko opcjonalnie
+	if err == nil && kind != "ollama" {
+		server.Metrics, err = e.queryMetrics(ctx, endpoint.url)
+		if kind == "openai" {
+			err = nil
+		}
+	}
+	if err != nil {
+		server.Error = err.Error()
+	}
+
+	// vLLM i TGI nie podają kwantyzacji przez API, ale jest ona opcją uruchomienia
+	if quantization := cmdlineFlag(endpoint.process.Cmdline, "--quantization", "--quantize", "-q"); quantization != "" {
+		for i := range server.Models {
+			server.Models[i].Quantization = quantization
+		}
+	}
+
+	return server
+}
+
+// ollamaModelList to odpowiedź /api/tags i /api/ps Ollamy
+type ollamaModelList struct {
+	Models []struct {
+		Name          string `json:"name"`
+		Size          int64  `json:"size"`
+		SizeVRAM      int64  `json:"size_vram"`
+		ExpiresAt     string `json:"expires_at"`
+		ContextLength uint64 `json:"context_length"`
+		Details       struct {
+			Family            string `json:"family"`
+			ParameterSize     string `json:"parameter_size"`
+			QuantizationLevel string `json:"quantization_level"`
+		} `json:"details"`
+	} `json:"models"`
+}
+
+// queryOllama odczytuje wersję Ollamy, dostępne modele (/api/tags) i modele
+// załadowane do pamięci (/api/ps)
+func (e *InferenceEnricher) queryOllama(ctx context.Context, server *models.InferenceServer) error {
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := e.getJSON(ctx, server.Endpoint+"/api/version", &version); err != nil {
+		return err
+	}
+	server.Version = version.Version
+
+	var tags, running ollamaModelList
+	if err := e.getJSON(ctx, server.Endpoint+"/api/tags", &tags); err != nil {
+		return err
+	}
+	if err := e.getJSON(ctx, server.Endpoint+"/api/ps", &running); err != nil {
+		return err
+	}
+
+	index := make(map[string]int)
+	for _, model := range tags.Models {
+		index[model.Name] = len(server.Models)
+		server.Models = append(server.Models, models.InferenceModel{
+			Name:          model.Name,
+			Family:        model.Details.Family,
+			ParameterSize: model.Details.ParameterSize,
+			Quantization:  model.Details.QuantizationLevel,
+			SizeBytes:     model.Size,
+		})
+	}
+	for _, model := range running.Models {
+		i, ok := index[model.Name]
+		if !ok {
+			i = len(server.Models)
+			server.Models = append(server.Models, models.InferenceModel{
+				Name:          model.Name,
+				Family:        model.Details.Family,
+				ParameterSize: model.Details.ParameterSize,
+				Quantization:  model.Details.QuantizationLevel,
+			})
+		}
+		loaded := &server.Models[i]
+		loaded.Loaded = true
+		loaded.VRAMBytes = model.SizeVRAM
+		loaded.ContextLength = model.ContextLength
+		loaded.ExpiresAt = model.ExpiresAt
+	}
+
+	sort.Slice(server.Models, func(i, j int) bool {
+		return server.Models[i].Name < server.Models[j].Name
+	})
+	return nil
+}
+
+// tgiInfo to odpowiedź /info serwera Text Generation Inference
+type tgiInfo struct {
+	ModelID        string `json:"model_id"`
+	ModelDType     string `json:"model_dtype"`
+	MaxTotalTokens uint64 `json:"max_total_tokens"`
+	Version        string `json:"version"`
+}
+
+// queryTGI odczytuje obsługiwany model i wersję serwera TGI
+func (e *InferenceEnricher) queryTGI(ctx context.Context, server *models.InferenceServer) error {
+	var info tgiInfo
+	if err := e.getJSON(ctx, server.Endpoint+"/info", &info); err != nil {
+		return err
+	}
+
+	server.Version = info.Version
+	server.Models = []models.InferenceModel{{
+		Name:          info.ModelID,
+		Loaded:        true,
+		Quantization:  strings.TrimPrefix(info.ModelDType, "torch."),
+		ContextLength: info.MaxTotalTokens,
+	}}
+	return nil
+}
+
+// openAIModelList to odpowiedź /v1/models API zgodnego z OpenAI
+type openAIModelList struct {
+	Object string `json:"object"`
+	Data   []struct {
+		ID          string `json:"id"`
+		OwnedBy     string `json:"owned_by"`
+		MaxModelLen uint64 `json:"max_model_len"` // Tylko vLLM
+	} `json:"data"`
+}
+
+// queryOpenAI odczytuje modele serwera API zgodnego z OpenAI (np. vLLM)
+func (e *InferenceEnricher) queryOpenAI(ctx context.Context, server *models.InferenceServer) error {
+	var list openAIModelList
+	if err := e.getJSON(ctx, server.Endpoint+"/v1/models", &list); err != nil {
+		return err
+	}
+
+	for _, model := range list.Data {
+		server.Models = append(server.Models, models.InferenceModel{
+			Name:          model.ID,
+			Loaded:        true,
+			ContextLength: model.MaxModelLen,
+		})
+	}
+
+	if server.Kind == "vllm" {
+		var version struct {
+			Version string `json:"version"`
+		}
+		if e.getJSON(ctx, server.Endpoint+"/version", &version) == nil {
+			server.Version = version.Version
+		}
+	}
+	return nil
+}
+
+// queryMetrics odczytuje obciążenie serwera z metryk Prometheus (/metrics)
+func (e *InferenceEnricher) queryMetrics(ctx context.Context, base string) (*models.InferenceMetrics, error) {
+	body, err := e.get(ctx, base+"/metrics")
+	if err != nil {
+		return nil, err
+	}
+	return parseInferenceMetrics(body), nil
+}
+
+// parseInferenceMetrics wybiera z metryk w formacie tekstowym Prometheus wartości
+// opisujące obciążenie serwera; zwraca nil, gdy nie ma żadnej znanej metryki
+func parseInferenceMetrics(body []byte) *models.InferenceMetrics {
+	var metrics models.InferenceMetrics
+	found := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// nazwa{etykiety} wartość [znacznik czasu]
+		var name string
+		var rest []string
+		if i := strings.IndexByte(line, '{'); i >= 0 {
+			end := strings.LastIndexByte(line, '}')
+			if end < i {
+				continue
+			}
+			name, rest = line[:i], strings.Fields(line[end+1:])
+		} else {
+			fields := strings.Fields(line)
+			name, rest = fields[0], fields[1:]
+		}
+
+		field, ok := inferenceMetricFields[name]
+		if !ok || len(rest) == 0 {
+			continue
+		}
+		value, err := strconv.ParseFloat(rest[0], 64)
+		if err != nil {
+			continue
+		}
+
+		found = true
+		switch field {
+		case "running":
+			metrics.RunningRequests += value
+		case "waiting":
+			metrics.QueueDepth += value
+		case "prompt":
+			metrics.PromptTokensTotal += value
+		case "generation":
+			metrics.GenerationTokensTotal += value
+		case "kv_cache":
+			if value > metrics.KVCacheUsage {
+				metrics.KVCacheUsage = value
+			}
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return &metrics
+}
+
+// inferenceThroughput oblicza liczbę tokenów wejściowych i wygenerowanych na sekundę
+// między dwoma próbkami; po restarcie serwera (spadek liczników) zwraca zera
+func inferenceThroughput(previous, current inferenceSample) (float64, float64) {
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 || current.prompt < previous.prompt || current.generation < previous.generation {
+		return 0, 0
+	}
+	return (current.prompt - previous.prompt) / elapsed, (current.generation - previous.generation) / elapsed
+}
+
+// cmdlineFlag zwraca wartość opcji wiersza poleceń w postaci "--opcja wartość" lub "--opcja=wartość"
+func cmdlineFlag(cmdline []string, names ...string) string {
+	for i, arg := range cmdline {
+		for _, name := range names {
+			if arg == name && i+1 < len(cmdline) {
+				return cmdline[i+1]
+			}
+			if strings.HasPrefix(arg, name+"=") {
+				return strings.TrimPrefix(arg, name+"=")
+			}
+		}
+	}
+	return ""
+}
+
+// getJSON pobiera i dekoduje odpowiedź JSON
+func (e *InferenceEnricher) getJSON(ctx context.Context, url string, v interface{}) error {
+	body, err := e.get(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("niepoprawna odpowiedź %s: %v", url, err)
+	}
+	return nil
+}
+
+// get pobiera odpowiedź API o ograniczonym rozmiarze
+func (e *InferenceEnricher) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nieoczekiwany status odpowiedzi %s: %s", url, resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, inferenceMaxBody))
+}
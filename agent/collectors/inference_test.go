@@ -0,0 +1,162 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// stubInferenceServer uruchamia serwer HTTP zwracający podane odpowiedzi według ścieżki
+func stubInferenceServer(t *testing.T, responses map[string]string) (*httptest.Server, uint32) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, _ := strconv.Atoi(port)
+	return server, uint32(n)
+}
+
+// listening zwraca połączenie nasłuchujące na podanym adresie
+func listening(ip string, port uint32) []models.Connection {
+	return []models.Connection{{Status: "LISTEN", LocalAddress: &models.SocketAddress{IP: ip, Port: port}}}
+}
+
+func TestInferenceEnricher(t *testing.T) {
+	ollama, ollamaPort := stubInferenceServer(t, map[string]string{
+		"/api/version": `{"version":"0.5.7"}`,
+		"/api/tags": `{"models":[
+			{"name":"llama3.1:8b","size":4920753328,"details":{"family":"llama","parameter_size":"8.0B","quantization_level":"Q4_K_M"}},
+			{"name":"nomic-embed-text:latest","size":274302450,"details":{"family":"nomic-bert","parameter_size":"137M","quantization_level":"F16"}}]}`,
+		"/api/ps": `{"models":[{"name":"llama3.1:8b","size":6654289920,"size_vram":6654289920,"context_length":8192,"expires_at":"2026-10-17T12:05:00Z"}]}`,
+	})
+	_, vllmPort := stubInferenceServer(t, map[string]string{
+		"/v1/models": `{"object":"list","data":[{"id":"meta-llama/Llama-3.1-8B-Instruct","owned_by":"vllm","max_model_len":32768}]}`,
+		"/version":   `{"version":"0.6.3"}`,
+		"/metrics": "# HELP vllm:num_requests_waiting Number of requests waiting.\n" +
+			"# TYPE vllm:num_requests_waiting gauge\n" +
+			`vllm:num_requests_waiting{model_name="meta-llama/Llama-3.1-8B-Instruct"} 3.0` + "\n" +
+			`vllm:num_requests_running{model_name="meta-llama/Llama-3.1-8B-Instruct"} 8.0` + "\n" +
+			`vllm:gpu_cache_usage_perc{model_name="meta-llama/Llama-3.1-8B-Instruct"} 0.42` + "\n" +
+			`vllm:prompt_tokens_total{model_name="meta-llama/Llama-3.1-8B-Instruct"} 1500.0` + "\n" +
+			`vllm:generation_tokens_total{model_name="meta-llama/Llama-3.1-8B-Instruct"} 4000.0` + "\n",
+	})
+	_, tgiPort := stubInferenceServer(t, map[string]string{
+		"/info":    `{"model_id":"mistralai/Mistral-7B-Instruct-v0.3","model_dtype":"torch.float16","max_total_tokens":8192,"version":"2.4.0"}`,
+		"/metrics": "tgi_queue_size 2\ntgi_batch_current_size 4\ntgi_request_input_length_sum 100\ntgi_request_generated_tokens_sum 900\n",
+	})
+	_, otherPort := stubInferenceServer(t, map[string]string{"/": "ok"})
+
+	state := models.NewSystemState()
+	state.Processes = []models.Process{
+		{PID: 10, Name: "ollama", IsLLMRelated: true, Connections: listening("127.0.0.1", ollamaPort)},
+		// Proces roboczy z tym samym gniazdem nie tworzy drugiego serwera
+		{PID: 11, Name: "ollama", IsLLMRelated: true, Connections: listening("127.0.0.1", ollamaPort)},
+		{PID: 20, Name: "python3", IsLLMRelated: true, ContainerID: "abc123", Cmdline: []string{"python3", "-m", "vllm.entrypoints.openai.api_server", "--quantization=awq"},
+			Connections: listening("0.0.0.0", 8000)},
+		{PID: 30, Name: "text-generation-launcher", IsLLMRelated: true, Cmdline: []string{"text-generation-launcher", "--quantize", "bitsandbytes-nf4"},
+			Connections: listening("127.0.0.1", tgiPort)},
+		{PID: 40, Name: "python3", IsLLMRelated: true, Connections: listening("127.0.0.1", otherPort)},
+		{PID: 50, Name: "nginx", Connections: listening("127.0.0.1", ollamaPort)},
+	}
+	state.Services = []models.Service{
+		{Name: "vllm", Type: "docker", ID: "abc123", Ports: []models.Port{{ContainerPort: "8000/tcp", HostIP: "0.0.0.0", HostPort: strconv.Itoa(int(vllmPort))}}},
+	}
+
+	e := NewInferenceEnricher()
+	if err := e.Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd odpytania serwerów inferencji: %v", err)
+	}
+
+	if len(state.InferenceServers) != 3 {
+		t.Fatalf("Niepoprawna liczba serwerów: got %d, want %d (%+v)", len(state.InferenceServers), 3, state.InferenceServers)
+	}
+
+	got := state.InferenceServers[0]
+	if got.Kind != "ollama" || got.Endpoint != ollama.URL || got.PID != 10 || got.Version != "0.5.7" || got.Metrics != nil || got.Error != "" {
+		t.Errorf("Niepoprawny serwer Ollama: got %+v", got)
+	}
+	wantModels := []models.InferenceModel{
+		{Name: "llama3.1:8b", Loaded: true, Family: "llama", ParameterSize: "8.0B", Quantization: "Q4_K_M", ContextLength: 8192,
+			SizeBytes: 4920753328, VRAMBytes: 6654289920, ExpiresAt: "2026-10-17T12:05:00Z"},
+		{Name: "nomic-embed-text:latest", Family: "nomic-bert", ParameterSize: "137M", Quantization: "F16", SizeBytes: 274302450},
+	}
+	if !reflect.DeepEqual(got.Models, wantModels) {
+		t.Errorf("Niepoprawne modele Ollamy: got %+v, want %+v", got.Models, wantModels)
+	}
+
+	got = state.InferenceServers[1]
+	if got.Kind != "vllm" || got.ContainerID != "abc123" || got.Version != "0.6.3" || got.Endpoint != "http://127.0.0.1:"+strconv.Itoa(int(vllmPort)) {
+		t.Errorf("Niepoprawny serwer vLLM: got %+v", got)
+	}
+	wantModels = []models.InferenceModel{{Name: "meta-llama/Llama-3.1-8B-Instruct", Loaded: true, Quantization: "awq", ContextLength: 32768}}
+	if !reflect.DeepEqual(got.Models, wantModels) {
+		t.Errorf("Niepoprawne modele vLLM: got %+v, want %+v", got.Models, wantModels)
+	}
+	wantMetrics := &models.InferenceMetrics{RunningRequests: 8, QueueDepth: 3, PromptTokensTotal: 1500, GenerationTokensTotal: 4000, KVCacheUsage: 0.42}
+	if !reflect.DeepEqual(got.Metrics, wantMetrics) {
+		t.Errorf("Niepoprawne metryki vLLM: got %+v, want %+v", got.Metrics, wantMetrics)
+	}
+
+	got = state.InferenceServers[2]
+	wantModels = []models.InferenceModel{{Name: "mistralai/Mistral-7B-Instruct-v0.3", Loaded: true, Quantization: "bitsandbytes-nf4", ContextLength: 8192}}
+	if got.Kind != "tgi" || got.Version != "2.4.0" || !reflect.DeepEqual(got.Models, wantModels) {
+		t.Errorf("Niepoprawny serwer TGI: got %+v", got)
+	}
+	if got.Metrics == nil || got.Metrics.QueueDepth != 2 || got.Metrics.RunningRequests != 4 || got.Metrics.GenerationTokensTotal != 900 {
+		t.Errorf("Niepoprawne metryki TGI: got %+v", got.Metrics)
+	}
+
+	// Przepustowość względem poprzedniej próbki sprzed dwóch sekund
+	key := fmt.Sprintf("%d %s", 30, got.Endpoint)
+	e.samples[key] = inferenceSample{prompt: 0, generation: 100, at: time.Now().Add(-2 * time.Second)}
+	state.Processes = state.Processes[3:5]
+	if err := e.Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd odpytania serwerów inferencji: %v", err)
+	}
+	if len(state.InferenceServers) != 1 {
+		t.Fatalf("Niepoprawna liczba serwerów: got %d, want %d", len(state.InferenceServers), 1)
+	}
+	if rate := state.InferenceServers[0].Metrics.GenerationTokensPerSecond; rate < 380 || rate > 401 {
+		t.Errorf("Niepoprawna przepustowość: got %v, want około %v", rate, 400)
+	}
+	if len(e.probes) != 2 || len(e.samples) != 1 {
+		t.Errorf("Niepoprawna pamięć podręczna: got %d rozpoznań, %d próbek", len(e.probes), len(e.samples))
+	}
+}
+
+func TestParseInferenceMetrics(t *testing.T) {
+	body := []byte("# TYPE llamacpp:prompt_tokens_total counter\n" +
+		"llamacpp:prompt_tokens_total 120\n" +
+		"llamacpp:tokens_predicted_total 340 1697040000000\n" +
+		"llamacpp:requests_processing 1\n" +
+		"llamacpp:requests_deferred 0\n" +
+		"process_open_fds 12\n")
+
+	want := &models.InferenceMetrics{RunningRequests: 1, PromptTokensTotal: 120, GenerationTokensTotal: 340}
+	if got := parseInferenceMetrics(body); !reflect.DeepEqual(got, want) {
+		t.Errorf("Niepoprawne metryki: got %+v, want %+v", got, want)
+	}
+
+	if got := parseInferenceMetrics([]byte("process_open_fds 12\n")); got != nil {
+		t.Errorf("Oczekiwano braku metryk: got %+v", got)
+	}
+}
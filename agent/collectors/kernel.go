@@ -0,0 +1,63 @@
+package collectors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// collectKernelStats odczytuje z systemu plików proc średnie obciążenie, liczniki
+// z /proc/stat i /proc/vmstat oraz PSI całego systemu (/proc/pressure)
+func collectKernelStats(root string) (*models.KernelStats, error) {
+	stats := &models.KernelStats{}
+
+	// Średnie obciążenie, np. "0.52 0.58 0.59 2/1234 5678"
+	data, err := os.ReadFile(filepath.Join(root, "loadavg"))
+	if err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("niepoprawny format loadavg: %q", string(data))
+	}
+	for i, target := range []*float64{&stats.LoadAvg1, &stats.LoadAvg5, &stats.LoadAvg15} {
+		if *target, err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return nil, fmt.Errorf("niepoprawna wartość loadavg %q: %v", fields[i], err)
+		}
+	}
+
+	// /proc/stat zawiera również wiersze cpu z wieloma wartościami, które są pomijane
+	if stat, err := readKeyedFile(filepath.Join(root, "stat")); err == nil {
+		stats.ContextSwitches = stat["ctxt"]
+		stats.ProcsRunning = stat["procs_running"]
+		stats.ProcsBlocked = stat["procs_blocked"]
+	}
+
+	// Licznik oom_kill jest dostępny od jądra 4.13
+	if vmstat, err := readKeyedFile(filepath.Join(root, "vmstat")); err == nil {
+		stats.MajorPageFaults = vmstat["pgmajfault"]
+		stats.OOMKills = vmstat["oom_kill"]
+	}
+
+	// PSI wymaga jądra 4.20 z włączonym CONFIG_PSI
+	for _, resource := range []string{"cpu", "memory", "io"} {
+		data, err := os.ReadFile(filepath.Join(root, "pressure", resource))
+		if err != nil {
+			continue
+		}
+		pressure, err := parsePressure(string(data))
+		if err != nil {
+			continue
+		}
+		if stats.Pressure == nil {
+			stats.Pressure = make(map[string]models.PressureStats)
+		}
+		stats.Pressure[resource] = pressure
+	}
+
+	return stats, nil
+}
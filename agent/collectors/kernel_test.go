@@ -0,0 +1,45 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectKernelStats(t *testing.T) {
+	root := t.TempDir()
+	writeCgroupFiles(t, root, map[string]string{
+		"loadavg": "12.50 8.25 4.00 17/2345 67890\n",
+		"stat":    "cpu  100 0 50 1000 10 0 5 0 0 0\ncpu0 100 0 50 1000 10 0 5 0 0 0\nctxt 987654321\nbtime 1700000000\nprocesses 12345\nprocs_running 17\nprocs_blocked 3\n",
+		"vmstat":  "nr_free_pages 1000\npgmajfault 4321\noom_kill 2\n",
+	})
+	writeCgroupFiles(t, filepath.Join(root, "pressure"), map[string]string{
+		"cpu":    "some avg10=35.00 avg60=20.00 avg300=10.00 total=1000\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n",
+		"memory": "some avg10=5.00 avg60=1.00 avg300=0.50 total=200\nfull avg10=2.00 avg60=0.50 avg300=0.10 total=100\n",
+	})
+
+	stats, err := collectKernelStats(root)
+	if err != nil {
+		t.Fatalf("Błąd odczytu statystyk jądra: %v", err)
+	}
+	if stats.LoadAvg1 != 12.5 || stats.LoadAvg5 != 8.25 || stats.LoadAvg15 != 4 {
+		t.Errorf("Niepoprawne średnie obciążenie: got %v %v %v", stats.LoadAvg1, stats.LoadAvg5, stats.LoadAvg15)
+	}
+	if stats.ContextSwitches != 987654321 || stats.ProcsRunning != 17 || stats.ProcsBlocked != 3 {
+		t.Errorf("Niepoprawne liczniki /proc/stat: got %+v", stats)
+	}
+	if stats.MajorPageFaults != 4321 || stats.OOMKills != 2 {
+		t.Errorf("Niepoprawne liczniki /proc/vmstat: got %+v", stats)
+	}
+	if len(stats.Pressure) != 2 || stats.Pressure["cpu"].Some.Avg10 != 35 || stats.Pressure["memory"].Full.Avg10 != 2 {
+		t.Errorf("Niepoprawne PSI: got %+v", stats.Pressure)
+	}
+
+	// Bez /proc/loadavg statystyki są niedostępne
+	if err := os.Remove(filepath.Join(root, "loadavg")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := collectKernelStats(root); err == nil {
+		t.Error("Oczekiwano błędu bez pliku loadavg")
+	}
+}
@@ -0,0 +1,172 @@
+package collectors
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kubeconfig to fragment pliku kubeconfig potrzebny do połączenia z serwerem API
+type kubeconfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster string `yaml:"cluster"`
+			User    string `yaml:"user"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+}
+
+// apiServer to serwer API Kubernetesa wraz z klientem HTTP i tokenem
+type apiServer struct {
+	url    string
+	client *http.Client
+	token  string
+
+	// Plik kubeconfig i czas jego modyfikacji, z których wczytano serwer
+	path    string
+	modTime time.Time
+}
+
+// loadKubeconfig wczytuje bieżący kontekst z pliku kubeconfig. Względne ścieżki
+// certyfikatów są liczone od katalogu pliku, tak jak w kubectl.
+func loadKubeconfig(path string) (*apiServer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config kubeconfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("niepoprawny format: %v", err)
+	}
+
+	// Znajdź kontekst; bez current-context użyj pierwszego
+	if len(config.Contexts) == 0 {
+		return nil, fmt.Errorf("brak kontekstów")
+	}
+	current := config.Contexts[0].Context
+	for _, c := range config.Contexts {
+		if c.Name == config.CurrentContext {
+			current = c.Context
+			break
+		}
+	}
+
+	server := &apiServer{}
+	tlsConfig := &tls.Config{}
+	dir := filepath.Dir(path)
+
+	found := false
+	for _, c := range config.Clusters {
+		if c.Name != current.Cluster {
+			continue
+		}
+		found = true
+		server.url = strings.TrimSuffix(c.Cluster.Server, "/")
+		tlsConfig.InsecureSkipVerify = c.Cluster.InsecureSkipTLSVerify
+
+		ca, err := kubeconfigData(c.Cluster.CertificateAuthorityData, c.Cluster.CertificateAuthority, dir)
+		if err != nil {
+			return nil, fmt.Errorf("nie można wczytać certyfikatu CA: %v", err)
+		}
+		if ca != nil {
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(ca) {
+				return nil, fmt.Errorf("niepoprawny certyfikat CA klastra %s", c.Name)
+			}
+			tlsConfig.RootCAs = pool
+		}
+	}
+	if !found || server.url == "" {
+		return nil, fmt.Errorf("brak klastra %q", current.Cluster)
+	}
+
+	for _, u := range config.Users {
+		if u.Name != current.User {
+			continue
+		}
+		server.token = u.User.Token
+		if server.token == "" && u.User.TokenFile != "" {
+			token, err := os.ReadFile(resolvePath(u.User.TokenFile, dir))
+			if err != nil {
+				return nil, fmt.Errorf("nie można wczytać tokenu: %v", err)
+			}
+			server.token = strings.TrimSpace(string(token))
+		}
+
+		cert, err := kubeconfigData(u.User.ClientCertificateData, u.User.ClientCertificate, dir)
+		if err != nil {
+			return nil, fmt.Errorf("nie można wczytać certyfikatu klienta: %v", err)
+		}
+		key, err := kubeconfigData(u.User.ClientKeyData, u.User.ClientKey, dir)
+		if err != nil {
+			return nil, fmt.Errorf("nie można wczytać klucza klienta: %v", err)
+		}
+		if cert != nil && key != nil {
+			pair, err := tls.X509KeyPair(cert, key)
+			if err != nil {
+				return nil, fmt.Errorf("niepoprawny certyfikat klienta: %v", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{pair}
+		}
+	}
+
+	server.client = &http.Client{
+		Timeout: kubernetesTimeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+			IdleConnTimeout: kubernetesIdleConnTimeout,
+		},
+	}
+	return server, nil
+}
+
+// kubeconfigData zwraca dane zakodowane w base64 lub zawartość wskazanego pliku;
+// nil, gdy żadne z nich nie jest podane
+func kubeconfigData(data, file, dir string) ([]byte, error) {
+	if data != "" {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if file != "" {
+		return os.ReadFile(resolvePath(file, dir))
+	}
+	return nil, nil
+}
+
+// resolvePath zamienia ścieżkę względną na bezwzględną względem katalogu dir
+func resolvePath(path, dir string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
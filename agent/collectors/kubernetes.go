@@ -0,0 +1,462 @@
+package collectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// kubernetesTimeout ogranicza czas pojedynczego zapytania do kubeleta lub serwera API
+const kubernetesTimeout = 10 * time.Second
+
+// kubernetesIdleConnTimeout zamyka bezczynne połączenia z serwerem API między
+// kolejnymi zbieraniami danych
+const kubernetesIdleConnTimeout = 90 * time.Second
+
+// defaultKubeletURL to adres lokalnego API kubeleta tylko do odczytu
+const defaultKubeletURL = "http://127.0.0.1:10255"
+
+// kubeconfigPaths to domyślne pliki kubeconfig: k3s, kubelet i użytkownika
+var kubeconfigPaths = []string{
+	"/etc/rancher/k3s/k3s.yaml",
+	"/etc/kubernetes/kubelet.conf",
+	"/etc/kubernetes/admin.conf",
+}
+
+// KubernetesCollector zbiera informacje o podach uruchomionych na hoście. Najpierw
+// odpytuje lokalne API kubeleta tylko do odczytu, a gdy jest ono wyłączone, serwer API
+// z pliku kubeconfig, ograniczając listę do podów bieżącego węzła.
+type KubernetesCollector struct {
+	kubeletURL  string
+	kubeconfigs []string
+	nodeName    string
+	client      *http.Client
+
+	// Serwer API z ostatnio wczytanego pliku kubeconfig; jego klient HTTP jest
+	// używany ponownie, dopóki plik się nie zmieni
+	server *apiServer
+}
+
+// NewKubernetesCollector tworzy nowy kolektor podów Kubernetesa. Adres kubeleta pochodzi
+// ze zmiennej KUBELET_URL, plik kubeconfig ze zmiennej KUBECONFIG lub z kubeconfigPaths,
+// a nazwa węzła ze zmiennej NODE_NAME lub z nazwy hosta.
+func NewKubernetesCollector() *KubernetesCollector {
+	kubeletURL := os.Getenv("KUBELET_URL")
+	if kubeletURL == "" {
+		kubeletURL = defaultKubeletURL
+	}
+
+	var kubeconfigs []string
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		kubeconfigs = append(kubeconfigs, filepath.SplitList(env)...)
+	}
+	kubeconfigs = append(kubeconfigs, kubeconfigPaths...)
+	if home, err := os.UserHomeDir(); err == nil {
+		kubeconfigs = append(kubeconfigs, filepath.Join(home, ".kube", "config"))
+	}
+
+	nodeName := os.Getenv("NODE_NAME")
+	if nodeName == "" {
+		if hostname, err := os.Hostname(); err == nil {
+			nodeName = strings.ToLower(hostname)
+		}
+	}
+
+	return &KubernetesCollector{
+		kubeletURL:  kubeletURL,
+		kubeconfigs: kubeconfigs,
+		nodeName:    nodeName,
+		client:      &http.Client{Timeout: kubernetesTimeout},
+	}
+}
+
+// Collect zbiera pody bieżącego węzła jako usługi typu "k8s-pod". Na hostach bez
+// Kubernetesa kolektor nie zwraca danych ani błędu.
+func (c *KubernetesCollector) Collect(ctx context.Context) ([]models.Service, error) {
+	pods, err := c.listPods(ctx)
+	if err != nil || pods == nil {
+		return nil, err
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	// Aktualny czas
+	currentTime := time.Now()
+	timestamp := currentTime.Format(time.RFC3339)
+
+	serviceModels := make([]models.Service, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		serviceModels = append(serviceModels, c.podService(pod, hostname, timestamp, currentTime))
+	}
+
+	return serviceModels, nil
+}
+
+// listPods pobiera listę podów z kubeleta lub z serwera API. Zwraca nil bez błędu,
+// gdy żadne z nich nie jest dostępne.
+func (c *KubernetesCollector) listPods(ctx context.Context) (*k8sPodList, error) {
+	// Lokalne API kubeleta nie wymaga uwierzytelniania i zwraca tylko pody węzła
+	pods, kubeletErr := c.fetchPods(ctx, c.client, c.kubeletURL+"/pods", "")
+	if kubeletErr == nil {
+		return pods, nil
+	}
+
+	path := c.kubeconfigPath()
+	if path == "" {
+		return nil, nil
+	}
+
+	server, err := c.apiServer(path)
+	if err != nil {
+		return nil, fmt.Errorf("nie można wczytać pliku %s: %v", path, err)
+	}
+
+	query := url.Values{}
+	if c.nodeName != "" {
+		query.Set("fieldSelector", "spec.nodeName="+c.nodeName)
+	}
+	pods, err = c.fetchPods(ctx, server.client, server.url+"/api/v1/pods?"+query.Encode(), server.token)
+	if err != nil {
+		return nil, fmt.Errorf("błąd podczas pobierania podów z serwera API (kubelet: %v): %v", kubeletErr, err)
+	}
+
+	return pods, nil
+}
+
+// apiServer zwraca serwer API z pliku kubeconfig. Plik jest wczytywany ponownie tylko
+// po zmianie ścieżki lub czasu modyfikacji, a bezczynne połączenia poprzedniego
+// klienta są wtedy zamykane.
+func (c *KubernetesCollector) apiServer(path string) (*apiServer, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if c.server != nil && c.server.path == path && c.server.modTime.Equal(info.ModTime()) {
+		return c.server, nil
+	}
+
+	server, err := loadKubeconfig(path)
+	if err != nil {
+		return nil, err
+	}
+	server.path = path
+	server.modTime = info.ModTime()
+
+	if c.server != nil {
+		c.server.client.CloseIdleConnections()
+	}
+	c.server = server
+	return server, nil
+}
+
+// kubeconfigPath zwraca pierwszy istniejący plik kubeconfig
+func (c *KubernetesCollector) kubeconfigPath() string {
+	for _, path := range c.kubeconfigs {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// fetchPods pobiera listę podów spod podanego adresu
+func (c *KubernetesCollector) fetchPods(ctx context.Context, client *http.Client, address, token string) (*k8sPodList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, address, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nieoczekiwany status odpowiedzi: %s", resp.Status)
+	}
+
+	var pods k8sPodList
+	if err := json.NewDecoder(resp.Body).Decode(&pods); err != nil {
+		return nil, fmt.Errorf("niepoprawna lista podów: %v", err)
+	}
+
+	return &pods, nil
+}
+
+// podService tworzy model usługi na podstawie poda
+func (c *KubernetesCollector) podService(pod k8sPod, hostname, timestamp string, currentTime time.Time) models.Service {
+	service := models.Service{
+		Name:      pod.Metadata.Namespace + "/" + pod.Metadata.Name,
+		Type:      "k8s-pod",
+		ID:        pod.Metadata.UID,
+		Hostname:  hostname,
+		Timestamp: timestamp,
+		Status:    strings.ToLower(pod.Status.Phase),
+		Ports:     make([]models.Port, 0),
+		Volumes:   make([]models.Volume, 0),
+		Labels:    pod.Metadata.Labels,
+		Kubernetes: &models.KubernetesPod{
+			Namespace:      pod.Metadata.Namespace,
+			Name:           pod.Metadata.Name,
+			UID:            pod.Metadata.UID,
+			Node:           pod.Spec.NodeName,
+			Phase:          pod.Status.Phase,
+			QOSClass:       pod.Status.QOSClass,
+			ServiceAccount: pod.Spec.ServiceAccountName,
+			PodIP:          pod.Status.PodIP,
+			Workload:       podWorkload(pod),
+			Containers:     make([]models.KubernetesContainer, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers)),
+		},
+	}
+
+	// Czas uruchomienia działającego poda
+	if pod.Status.StartTime != "" && pod.Status.Phase == "Running" {
+		if startTime, err := time.Parse(time.RFC3339, pod.Status.StartTime); err == nil {
+			service.StartTime = startTime.Format(time.RFC3339)
+			service.UptimeSeconds = int64(currentTime.Sub(startTime).Seconds())
+		}
+	}
+
+	// Obraz i polecenie pochodzą z pierwszego (głównego) kontenera
+	if len(pod.Spec.Containers) > 0 {
+		service.Image = pod.Spec.Containers[0].Image
+		service.Command = append(append([]string(nil), pod.Spec.Containers[0].Command...), pod.Spec.Containers[0].Args...)
+	}
+
+	statuses := make(map[string]k8sContainerStatus)
+	for _, status := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		statuses[status.Name] = status
+	}
+
+	volumes := make(map[string]k8sVolume, len(pod.Spec.Volumes))
+	for _, volume := range pod.Spec.Volumes {
+		volumes[volume.Name] = volume
+	}
+	seenMounts := make(map[string]bool)
+
+	for i, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		status := statuses[container.Name]
+		service.Kubernetes.Containers = append(service.Kubernetes.Containers, models.KubernetesContainer{
+			Name:         container.Name,
+			Image:        container.Image,
+			ContainerID:  status.ContainerID,
+			Init:         i < len(pod.Spec.InitContainers),
+			State:        status.state(),
+			Ready:        status.Ready,
+			RestartCount: status.RestartCount,
+			Requests:     container.Resources.Requests,
+			Limits:       container.Resources.Limits,
+		})
+
+		for _, port := range container.Ports {
+			protocol := strings.ToLower(port.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			service.Ports = append(service.Ports, models.Port{
+				ContainerPort: fmt.Sprintf("%d/%s", port.ContainerPort, protocol),
+				HostIP:        port.HostIP,
+				HostPort:      fmt.Sprintf("%d", port.HostPort),
+			})
+		}
+
+		for _, mount := range container.VolumeMounts {
+			key := mount.Name + ":" + mount.MountPath
+			if seenMounts[key] {
+				continue
+			}
+			seenMounts[key] = true
+			service.Volumes = append(service.Volumes, podVolume(volumes[mount.Name], mount))
+		}
+
+		// Zmienne środowiskowe z wartością wprost; wartości z sekretów i ConfigMap są pomijane
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				service.Environment = append(service.Environment, env.Name+"="+env.Value)
+			}
+		}
+	}
+
+	// Sprawdź, czy pod jest związany z LLM
+	classifyService(&service)
+
+	return service
+}
+
+// podVolume zamienia montowanie wolumenu poda na model wolumenu. Źródłem jest ścieżka
+// hosta, nazwa PVC, ConfigMap lub sekretu, zależnie od typu wolumenu.
+func podVolume(volume k8sVolume, mount k8sVolumeMount) models.Volume {
+	volumeModel := models.Volume{
+		Name:        mount.Name,
+		Destination: mount.MountPath,
+		ReadOnly:    mount.ReadOnly,
+	}
+
+	switch {
+	case volume.HostPath != nil:
+		volumeModel.Type = "hostPath"
+		volumeModel.Source = volume.HostPath.Path
+	case volume.PersistentVolumeClaim != nil:
+		volumeModel.Type = "persistentVolumeClaim"
+		volumeModel.Source = volume.PersistentVolumeClaim.ClaimName
+		volumeModel.ReadOnly = volumeModel.ReadOnly || volume.PersistentVolumeClaim.ReadOnly
+	case volume.ConfigMap != nil:
+		volumeModel.Type = "configMap"
+		volumeModel.Source = volume.ConfigMap.Name
+	case volume.Secret != nil:
+		volumeModel.Type = "secret"
+		volumeModel.Source = volume.Secret.SecretName
+	case volume.EmptyDir != nil:
+		volumeModel.Type = "emptyDir"
+	case volume.Projected != nil:
+		volumeModel.Type = "projected"
+	}
+
+	return volumeModel
+}
+
+// podWorkload zwraca obiekt zarządzający podem. Pody ReplicaSet utworzone przez
+// Deployment są przypisywane do Deploymentu na podstawie etykiety pod-template-hash.
+func podWorkload(pod k8sPod) *models.KubernetesWorkload {
+	owners := pod.Metadata.OwnerReferences
+	if len(owners) == 0 {
+		return nil
+	}
+
+	owner := owners[0]
+	for _, candidate := range owners {
+		if candidate.Controller {
+			owner = candidate
+			break
+		}
+	}
+
+	workload := &models.KubernetesWorkload{Kind: owner.Kind, Name: owner.Name}
+	if hash := pod.Metadata.Labels["pod-template-hash"]; owner.Kind == "ReplicaSet" && hash != "" {
+		if name, ok := strings.CutSuffix(owner.Name, "-"+hash); ok {
+			workload.Kind = "Deployment"
+			workload.Name = name
+		}
+	}
+
+	return workload
+}
+
+// k8sPodList to fragment odpowiedzi API Kubernetesa z listą podów
+type k8sPodList struct {
+	Items []k8sPod `json:"items"`
+}
+
+// k8sPod to fragment obiektu Pod potrzebny kolektorowi
+type k8sPod struct {
+	Metadata struct {
+		Name            string            `json:"name"`
+		Namespace       string            `json:"namespace"`
+		UID             string            `json:"uid"`
+		Labels          map[string]string `json:"labels"`
+		OwnerReferences []struct {
+			Kind       string `json:"kind"`
+			Name       string `json:"name"`
+			Controller bool   `json:"controller"`
+		} `json:"ownerReferences"`
+	} `json:"metadata"`
+	Spec struct {
+		NodeName           string         `json:"nodeName"`
+		ServiceAccountName string         `json:"serviceAccountName"`
+		InitContainers     []k8sContainer `json:"initContainers"`
+		Containers         []k8sContainer `json:"containers"`
+		Volumes            []k8sVolume    `json:"volumes"`
+	} `json:"spec"`
+	Status struct {
+		Phase                 string               `json:"phase"`
+		QOSClass              string               `json:"qosClass"`
+		PodIP                 string               `json:"podIP"`
+		StartTime             string               `json:"startTime"`
+		InitContainerStatuses []k8sContainerStatus `json:"initContainerStatuses"`
+		ContainerStatuses     []k8sContainerStatus `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+// k8sContainer to specyfikacja kontenera poda
+type k8sContainer struct {
+	Name    string   `json:"name"`
+	Image   string   `json:"image"`
+	Command []string `json:"command"`
+	Args    []string `json:"args"`
+	Env     []struct {
+		Name      string          `json:"name"`
+		Value     string          `json:"value"`
+		ValueFrom json.RawMessage `json:"valueFrom"`
+	} `json:"env"`
+	Ports []struct {
+		ContainerPort int32  `json:"containerPort"`
+		HostPort      int32  `json:"hostPort"`
+		HostIP        string `json:"hostIP"`
+		Protocol      string `json:"protocol"`
+	} `json:"ports"`
+	Resources struct {
+		Requests map[string]string `json:"requests"`
+		Limits   map[string]string `json:"limits"`
+	} `json:"resources"`
+	VolumeMounts []k8sVolumeMount `json:"volumeMounts"`
+}
+
+// k8sVolumeMount to montowanie wolumenu w kontenerze poda
+type k8sVolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly"`
+}
+
+// k8sVolume to wolumen poda; ustawione jest tylko pole odpowiadające jego typowi
+type k8sVolume struct {
+	Name     string `json:"name"`
+	HostPath *struct {
+		Path string `json:"path"`
+	} `json:"hostPath"`
+	PersistentVolumeClaim *struct {
+		ClaimName string `json:"claimName"`
+		ReadOnly  bool   `json:"readOnly"`
+	} `json:"persistentVolumeClaim"`
+	ConfigMap *struct {
+		Name string `json:"name"`
+	} `json:"configMap"`
+	Secret *struct {
+		SecretName string `json:"secretName"`
+	} `json:"secret"`
+	EmptyDir  json.RawMessage `json:"emptyDir"`
+	Projected json.RawMessage `json:"projected"`
+}
+
+// k8sContainerStatus to stan kontenera poda
+type k8sContainerStatus struct {
+	Name         string                     `json:"name"`
+	ContainerID  string                     `json:"containerID"`
+	Ready        bool                       `json:"ready"`
+	RestartCount int32                      `json:"restartCount"`
+	State        map[string]json.RawMessage `json:"state"`
+}
+
+// state zwraca nazwę stanu kontenera (running, waiting lub terminated)
+func (s k8sContainerStatus) state() string {
+	for state := range s.State {
+		return state
+	}
+	return ""
+}
@@ -0,0 +1,221 @@
+package collectors
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPodList to lista podów w formacie API Kubernetesa
+const testPodList = `{
+  "kind": "PodList",
+  "items": [{
+    "metadata": {
+      "name": "ollama-7c9d8f6b5-x2k4p",
+      "namespace": "llm",
+      "uid": "0f1e2d3c",
+      "labels": {"app": "ollama", "pod-template-hash": "7c9d8f6b5"},
+      "ownerReferences": [{"kind": "ReplicaSet", "name": "ollama-7c9d8f6b5", "controller": true}]
+    },
+    "spec": {
+      "nodeName": "gpu-node",
+      "serviceAccountName": "default",
+      "initContainers": [{"name": "fetch-model", "image": "busybox"}],
+      "containers": [{
+        "name": "server",
+        "image": "ollama/ollama:0.3",
+        "command": ["ollama"],
+        "args": ["serve"],
+        "env": [
+          {"name": "OLLAMA_HOST", "value": "0.0.0.0"},
+          {"name": "HF_TOKEN", "valueFrom": {"secretKeyRef": {"name": "hf", "key": "token"}}}
+        ],
+        "ports": [{"containerPort": 11434, "protocol": "TCP"}],
+        "resources": {
+          "requests": {"cpu": "2", "memory": "8Gi"},
+          "limits": {"memory": "16Gi", "nvidia.com/gpu": "1"}
+        },
+        "volumeMounts": [
+          {"name": "models", "mountPath": "/root/.ollama"},
+          {"name": "config", "mountPath": "/etc/ollama", "readOnly": true}
+        ]
+      }],
+      "volumes": [
+        {"name": "models", "persistentVolumeClaim": {"claimName": "ollama-models"}},
+        {"name": "config", "configMap": {"name": "ollama-config"}}
+      ]
+    },
+    "status": {
+      "phase": "Running",
+      "qosClass": "Burstable",
+      "podIP": "10.42.0.15",
+      "startTime": "2025-01-01T12:00:00Z",
+      "initContainerStatuses": [{"name": "fetch-model", "state": {"terminated": {"exitCode": 0}}, "ready": true}],
+      "containerStatuses": [{
+        "name": "server",
+        "containerID": "containerd://abc123",
+        "ready": true,
+        "restartCount": 2,
+        "state": {"running": {"startedAt": "2025-01-01T12:00:05Z"}}
+      }]
+    }
+  }]
+}`
+
+func TestKubernetesCollectorKubelet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pods" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, testPodList)
+	}))
+	defer server.Close()
+
+	c := NewKubernetesCollector()
+	c.kubeletURL = server.URL
+	c.kubeconfigs = nil
+
+	services, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania podów: %v", err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("Niepoprawna liczba podów: got %v, want %v", len(services), 1)
+	}
+
+	pod := services[0]
+	if pod.Type != "k8s-pod" || pod.Name != "llm/ollama-7c9d8f6b5-x2k4p" || pod.ID != "0f1e2d3c" || pod.Status != "running" {
+		t.Errorf("Niepoprawna identyfikacja poda: got %+v", pod)
+	}
+	if !pod.IsLLMRelated || pod.Image != "ollama/ollama:0.3" || len(pod.Command) != 2 {
+		t.Errorf("Niepoprawny główny kontener: got %v, %v, %v", pod.IsLLMRelated, pod.Image, pod.Command)
+	}
+	if len(pod.Environment) != 1 || pod.Environment[0] != "OLLAMA_HOST=0.0.0.0" {
+		t.Errorf("Niepoprawne zmienne środowiskowe: got %v", pod.Environment)
+	}
+	if len(pod.Ports) != 1 || pod.Ports[0].ContainerPort != "11434/tcp" {
+		t.Errorf("Niepoprawne porty: got %+v", pod.Ports)
+	}
+	if len(pod.Volumes) != 2 || pod.Volumes[0].Type != "persistentVolumeClaim" || pod.Volumes[0].Source != "ollama-models" ||
+		pod.Volumes[1].Type != "configMap" || !pod.Volumes[1].ReadOnly {
+		t.Errorf("Niepoprawne wolumeny: got %+v", pod.Volumes)
+	}
+
+	k8s := pod.Kubernetes
+	if k8s == nil || k8s.Workload == nil || k8s.Workload.Kind != "Deployment" || k8s.Workload.Name != "ollama" {
+		t.Fatalf("Niepoprawny obiekt zarządzający podem: got %+v", k8s)
+	}
+	if k8s.QOSClass != "Burstable" || k8s.Node != "gpu-node" || len(k8s.Containers) != 2 {
+		t.Errorf("Niepoprawny opis poda: got %+v", k8s)
+	}
+	if init := k8s.Containers[0]; !init.Init || init.State != "terminated" {
+		t.Errorf("Niepoprawny kontener inicjujący: got %+v", init)
+	}
+	server0 := k8s.Containers[1]
+	if server0.State != "running" || server0.RestartCount != 2 || server0.ContainerID != "containerd://abc123" ||
+		server0.Requests["cpu"] != "2" || server0.Limits["nvidia.com/gpu"] != "1" {
+		t.Errorf("Niepoprawny kontener poda: got %+v", server0)
+	}
+}
+
+func TestKubernetesCollectorAPIServer(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret-token" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/v1/pods" || r.URL.Query().Get("fieldSelector") != "spec.nodeName=gpu-node" {
+			http.Error(w, "niepoprawne zapytanie: "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, testPodList)
+	}))
+	defer server.Close()
+
+	// Kubeconfig z certyfikatem CA serwera testowego i tokenem w osobnym pliku
+	dir := t.TempDir()
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(filepath.Join(dir, "token"), []byte("secret-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	kubeconfig := fmt.Sprintf(`apiVersion: v1
+kind: Config
+current-context: default
+clusters:
+- name: default
+  cluster:
+    server: %s
+    certificate-authority-data: %s
+contexts:
+- name: other
+  context: {cluster: missing, user: nobody}
+- name: default
+  context: {cluster: default, user: agent}
+users:
+- name: agent
+  user:
+    tokenFile: token
+`, server.URL, base64.StdEncoding.EncodeToString(ca))
+	path := filepath.Join(dir, "config")
+	if err := os.WriteFile(path, []byte(kubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Kubelet bez API tylko do odczytu
+	kubelet := httptest.NewServer(http.NotFoundHandler())
+	defer kubelet.Close()
+
+	c := NewKubernetesCollector()
+	c.kubeletURL = kubelet.URL
+	c.kubeconfigs = []string{filepath.Join(dir, "missing"), path}
+	c.nodeName = "gpu-node"
+
+	services, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania podów: %v", err)
+	}
+	if len(services) != 1 || services[0].Kubernetes.PodIP != "10.42.0.15" {
+		t.Errorf("Niepoprawne pody: got %+v", services)
+	}
+
+	// Kolejne zbieranie używa tego samego klienta, dopóki plik się nie zmieni
+	first := c.server
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Błąd zbierania podów: %v", err)
+	}
+	if c.server != first {
+		t.Error("Oczekiwano ponownego użycia klienta serwera API")
+	}
+	modTime := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Collect(context.Background()); err != nil {
+		t.Fatalf("Błąd zbierania podów: %v", err)
+	}
+	if c.server == first {
+		t.Error("Oczekiwano ponownego wczytania zmienionego pliku kubeconfig")
+	}
+}
+
+func TestKubernetesCollectorUnavailable(t *testing.T) {
+	kubelet := httptest.NewServer(http.NotFoundHandler())
+	kubelet.Close()
+
+	c := NewKubernetesCollector()
+	c.kubeletURL = kubelet.URL
+	c.kubeconfigs = []string{filepath.Join(t.TempDir(), "config")}
+
+	services, err := c.Collect(context.Background())
+	if err != nil || services != nil {
+		t.Errorf("Oczekiwano braku danych bez Kubernetesa: got %v, %v", services, err)
+	}
+}
@@ -0,0 +1,141 @@
+package collectors
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gitlab.com/safetytwin/safetytwin/agent/classify"
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Klasyfikator LLM wspólny dla wszystkich kolektorów; podmieniany po przeładowaniu konfiguracji
+var (
+	classifierMu  sync.RWMutex
+	llmClassifier = classify.Default()
+)
+
+// SetLLMClassifier ustawia klasyfikator używany przez kolektory i moduł "llm"
+func SetLLMClassifier(classifier *classify.Classifier) {
+	classifierMu.Lock()
+	defer classifierMu.Unlock()
+	llmClassifier = classifier
+}
+
+// currentClassifier zwraca aktualny klasyfikator LLM
+func currentClassifier() *classify.Classifier {
+	classifierMu.RLock()
+	defer classifierMu.RUnlock()
+	return llmClassifier
+}
+
+// classifyProcess ustawia klasyfikację LLM procesu
+func classifyProcess(process *models.Process) {
+	process.LLM = currentClassifier().Classify(processSubject(process))
+	process.IsLLMRelated = process.LLM != nil
+}
+
+// classifyService ustawia klasyfikację LLM usługi, kontenera lub poda
+func classifyService(service *models.Service) {
+	service.LLM = currentClassifier().Classify(serviceSubject(service))
+	service.IsLLMRelated = service.LLM != nil
+}
+
+// processSubject zbiera dane procesu sprawdzane przez reguły klasyfikacji
+func processSubject(process *models.Process) classify.Subject {
+	subject := classify.Subject{
+		Names:   []string{process.Name},
+		Cmdline: strings.Join(process.Cmdline, " "),
+		Env:     process.Environment,
+		GPU:     len(process.GPU) > 0,
+	}
+
+	for _, file := range process.OpenFiles {
+		subject.Files = append(subject.Files, file.Path)
+	}
+	for _, conn := range process.Connections {
+		if conn.Status == "LISTEN" && conn.LocalAddress != nil {
+			subject.Ports = append(subject.Ports, conn.LocalAddress.Port)
+		}
+	}
+
+	return subject
+}
+
+// serviceSubject zbiera dane usługi sprawdzane przez reguły klasyfikacji. Plikami
+// usługi są ścieżki jej wolumenów, a portami porty kontenera i hosta.
+func serviceSubject(service *models.Service) classify.Subject {
+	subject := classify.Subject{
+		Names:   []string{service.Name},
+		Cmdline: strings.Join(service.Command, " "),
+		Env:     service.Environment,
+		GPU:     len(service.GPU) > 0,
+	}
+	if service.Image != "" {
+		subject.Images = append(subject.Images, service.Image)
+	}
+	subject.Labels = classify.LabelList(service.Labels)
+
+	// Linia poleceń głównego procesu usługi systemd
+	if cmdline, ok := service.Extra["cmdline"].(string); ok && subject.Cmdline == "" {
+		subject.Cmdline = cmdline
+	}
+	if service.Systemd != nil {
+		subject.Names = append(subject.Names, service.Systemd.Description)
+		subject.Env = append(append([]string(nil), subject.Env...), service.Systemd.Environment...)
+		if subject.Cmdline == "" {
+			subject.Cmdline = strings.Join(service.Systemd.Exec["ExecStart"], " ")
+		}
+	}
+	if service.Kubernetes != nil {
+		for _, container := range service.Kubernetes.Containers {
+			subject.Names = append(subject.Names, container.Name)
+			subject.Images = append(subject.Images, container.Image)
+		}
+	}
+
+	for _, volume := range service.Volumes {
+		subject.Files = append(subject.Files, volume.Source, volume.Destination)
+	}
+	for _, port := range service.Ports {
+		for _, value := range []string{port.ContainerPort, port.HostPort} {
+			// Port kontenera ma postać "8080/tcp"
+			if number, err := strconv.ParseUint(strings.Split(value, "/")[0], 10, 32); err == nil && number > 0 {
+				subject.Ports = append(subject.Ports, uint32(number))
+			}
+		}
+	}
+
+	return subject
+}
+
+// LLMEnricher ponownie klasyfikuje procesy i usługi po zebraniu pełnego stanu,
+// gdy znane są już porty nasłuchujące, otwarte pliki i użycie GPU
+type LLMEnricher struct{}
+
+// NewLLMEnricher tworzy nowy moduł klasyfikacji LLM
+func NewLLMEnricher() *LLMEnricher {
+	return &LLMEnricher{}
+}
+
+// Name zwraca nazwę modułu
+func (e *LLMEnricher) Name() string {
+	return "llm"
+}
+
+// Enrich klasyfikuje procesy i usługi stanu
+func (e *LLMEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	for i := range state.Processes {
+		classifyProcess(&state.Processes[i])
+	}
+
+	for i := range state.Services {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		classifyService(&state.Services[i])
+	}
+
+	return nil
+}
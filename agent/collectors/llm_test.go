@@ -0,0 +1,68 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/classify"
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+func TestLLMEnricher(t *testing.T) {
+	classifier, err := classify.New([]classify.Rule{
+		{Name: "rag-api", Category: classify.CategoryOther, Names: []string{`^rag-api$`}},
+	})
+	if err != nil {
+		t.Fatalf("Błąd tworzenia klasyfikatora: %v", err)
+	}
+	SetLLMClassifier(classifier)
+	defer SetLLMClassifier(classify.Default())
+
+	state := models.NewSystemState()
+	state.Processes = []models.Process{
+		{PID: 10, Name: "server", Connections: []models.Connection{
+			{Status: "ESTABLISHED", LocalAddress: &models.SocketAddress{Port: 6333}},
+			{Status: "LISTEN", LocalAddress: &models.SocketAddress{Port: 11434}},
+		}},
+		{PID: 20, Name: "python3", Cmdline: []string{"python3", "serve.py"}, GPU: []models.GPUUsage{{Vendor: "nvidia", MemoryUsedMB: 512}}},
+		{PID: 30, Name: "mail", IsLLMRelated: true},
+	}
+	state.Services = []models.Service{
+		{Name: "llm-api", Type: "docker", Volumes: []models.Volume{{Source: "/srv/models/llama-3-8b.safetensors", Destination: "/model.safetensors"}}},
+		{Name: "search", Type: "docker", Ports: []models.Port{{ContainerPort: "6333/tcp", HostPort: "16333"}}},
+		{Name: "rag-api", Type: "systemd", Systemd: &models.SystemdUnit{Unit: "rag-api.service"}},
+		{Name: "web", Type: "systemd", Systemd: &models.SystemdUnit{Unit: "web.service", Description: "HTML mail frontend"}},
+	}
+
+	if err := NewLLMEnricher().Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd klasyfikacji: %v", err)
+	}
+
+	// Port nasłuchujący i użycie GPU są znane dopiero po zebraniu stanu
+	wantProcesses := []string{"ollama-port", "python-gpu", ""}
+	for i, want := range wantProcesses {
+		process := state.Processes[i]
+		if got := ruleName(process.LLM); got != want || process.IsLLMRelated != (want != "") {
+			t.Errorf("Niepoprawna klasyfikacja procesu %s: got %q, %v, want %q", process.Name, got, process.IsLLMRelated, want)
+		}
+	}
+
+	wantServices := []string{"model-files", "qdrant-port", "rag-api", ""}
+	for i, want := range wantServices {
+		service := state.Services[i]
+		if got := ruleName(service.LLM); got != want || service.IsLLMRelated != (want != "") {
+			t.Errorf("Niepoprawna klasyfikacja usługi %s: got %q, %v, want %q", service.Name, got, service.IsLLMRelated, want)
+		}
+	}
+	if state.Services[1].LLM.Category != classify.CategoryVectorDB {
+		t.Errorf("Niepoprawna kategoria: got %v, want %v", state.Services[1].LLM.Category, classify.CategoryVectorDB)
+	}
+}
+
+// ruleName zwraca nazwę dopasowanej reguły lub pusty ciąg
+func ruleName(llm *models.LLMClassification) string {
+	if llm == nil {
+		return ""
+	}
+	return llm.Rule
+}
@@ -0,0 +1,298 @@
+package collectors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+const (
+	// fingerprintChunk to rozmiar początku i końca pliku uwzględnianych w odcisku
+	fingerprintChunk = 1 << 20
+	// minSniffedModelSize to najmniejszy plik bez rozszerzenia modelu, którego zawartość
+	// jest sprawdzana w procesach związanych z LLM (np. pliki blob Ollamy)
+	minSniffedModelSize = 16 << 20
+)
+
+// modelExtensions to formaty plików modeli rozpoznawane po rozszerzeniu
+var modelExtensions = map[string]string{
+	".gguf":        "gguf",
+	".ggml":        "ggml",
+	".safetensors": "safetensors",
+	".onnx":        "onnx",
+	".pt":          "pytorch",
+	".pth":         "pytorch",
+	".ckpt":        "pytorch",
+	".bin":         "", // Tylko z rozpoznaną zawartością
+}
+
+// ggmlMagics to sygnatury starszych formatów GGML zapisane w kolejności bajtów pliku
+var ggmlMagics = map[string]bool{"lmgg": true, "fmgg": true, "tjgg": true, "algg": true}
+
+// cachedModel to wynik analizy pliku modelu, ważny dopóki nie zmieni się rozmiar i czas modyfikacji
+type cachedModel struct {
+	size        int64
+	modTime     time.Time
+	format      string
+	fingerprint string
+	gguf        *models.GGUFMetadata
+}
+
+// ModelInventoryEnricher tworzy spis plików modeli otwartych lub zmapowanych w pamięci
+// przez procesy. Pliki są odczytywane przez /proc/<pid>/root, więc ścieżki procesów
+// w kontenerach wskazują właściwe pliki. Odcisk i metadane GGUF są obliczane tylko
+// dla nowych lub zmienionych plików.
+type ModelInventoryEnricher struct {
+	procRoot string
+
+	mu    sync.Mutex
+	cache map[string]*cachedModel // Według urządzenia i numeru i-węzła
+}
+
+// NewModelInventoryEnricher tworzy nowy moduł spisu plików modeli
+func NewModelInventoryEnricher() *ModelInventoryEnricher {
+	return &ModelInventoryEnricher{
+		procRoot: procRoot(),
+		cache:    make(map[string]*cachedModel),
+	}
+}
+
+// Name zwraca nazwę modułu
+func (e *ModelInventoryEnricher) Name() string {
+	return "models"
+}
+
+// Enrich wypełnia listę plików modeli w stanie systemu
+func (e *ModelInventoryEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	processes := make([]*models.Process, 0, len(state.Processes))
+	for i := range state.Processes {
+		processes = append(processes, &state.Processes[i])
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		return processes[i].PID < processes[j].PID
+	})
+
+	artifacts := make(map[string]*models.ModelArtifact)
+	for _, process := range processes {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		paths := make([]string, 0, len(process.OpenFiles))
+		for _, file := range process.OpenFiles {
+			paths = append(paths, file.Path)
+		}
+		// Modele ładowane przez mmap (np. llama.cpp) nie muszą mieć otwartego deskryptora
+		if process.IsLLMRelated {
+			paths = append(paths, readMappedFiles(e.procRoot, process.PID)...)
+		}
+
+		seen := make(map[string]bool, len(paths))
+		for _, path := range paths {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			key, model := e.inspect(process, path)
+			if model == nil {
+				continue
+			}
+
+			artifact, ok := artifacts[key]
+			if !ok {
+				artifact = &models.ModelArtifact{
+					Path:         path,
+					SizeBytes:    model.size,
+					ModifiedTime: model.modTime.UTC().Format(time.RFC3339),
+					Format:       model.format,
+					Fingerprint:  model.fingerprint,
+					GGUF:         model.gguf,
+				}
+				artifacts[key] = artifact
+			}
+			if len(artifact.PIDs) == 0 || artifact.PIDs[len(artifact.PIDs)-1] != process.PID {
+				artifact.PIDs = append(artifact.PIDs, process.PID)
+			}
+			if process.ContainerID != "" && !containsString(artifact.Containers, process.ContainerID) {
+				artifact.Containers = append(artifact.Containers, process.ContainerID)
+			}
+		}
+	}
+
+	// Usuń z pamięci podręcznej pliki, których żaden proces już nie używa
+	for key := range e.cache {
+		if artifacts[key] == nil {
+			delete(e.cache, key)
+		}
+	}
+
+	state.Models = nil
+	for _, artifact := range artifacts {
+		sort.Strings(artifact.Containers)
+		state.Models = append(state.Models, *artifact)
+	}
+	sort.Slice(state.Models, func(i, j int) bool {
+		return state.Models[i].Key() < state.Models[j].Key()
+	})
+
+	return nil
+}
+
+// inspect sprawdza, czy plik otwarty przez proces jest modelem, i zwraca jego klucz
+// (urządzenie i i-węzeł) oraz opis; dla innych plików zwraca nil
+func (e *ModelInventoryEnricher) inspect(process *models.Process, path string) (string, *cachedModel) {
+	if !strings.HasPrefix(path, "/") {
+		return "", nil
+	}
+	format, known := modelExtensions[strings.ToLower(filepath.Ext(path))]
+	if !known && !process.IsLLMRelated {
+		return "", nil
+	}
+
+	// Ścieżka z przestrzeni nazw montowania procesu; dla procesów hosta bez dostępu
+	// do /proc/<pid>/root wystarczy sama ścieżka
+	fullPath := filepath.Join(e.procRoot, strconv.Itoa(int(process.PID)), "root", path)
+	info, err := os.Stat(fullPath)
+	if err != nil && process.ContainerID == "" {
+		fullPath = path
+		info, err = os.Stat(path)
+	}
+	if err != nil || !info.Mode().IsRegular() {
+		return "", nil
+	}
+	if !known && info.Size() < minSniffedModelSize {
+		return "", nil
+	}
+
+	key := path
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		key = fmt.Sprintf("%d:%d", uint64(stat.Dev), uint64(stat.Ino))
+	}
+	if cached, ok := e.cache[key]; ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return key, cached
+	}
+
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", nil
+	}
+	defer file.Close()
+
+	header := make([]byte, 16)
+	n, _ := io.ReadFull(file, header)
+	// Archiwum ZIP jest modelem PyTorch tylko przy rozszerzeniu modelu
+	if detected := detectModelFormat(header[:n]); detected != "" && (detected != "pytorch" || known) {
+		format = detected
+	}
+	if format == "" {
+		return "", nil
+	}
+
+	model := &cachedModel{size: info.Size(), modTime: info.ModTime(), format: format}
+	if model.fingerprint, err = modelFingerprint(file, info.Size()); err != nil {
+		fmt.Printf("Ostrzeżenie: nie można obliczyć odcisku pliku modelu %s: %v\n", path, err)
+	}
+	if format == "gguf" {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			if model.gguf, err = parseGGUF(file); err != nil {
+				fmt.Printf("Ostrzeżenie: nie można odczytać nagłówka GGUF %s: %v\n", path, err)
+			}
+		}
+	}
+
+	e.cache[key] = model
+	return key, model
+}
+
+// detectModelFormat rozpoznaje format modelu po sygnaturze na początku pliku
+func detectModelFormat(header []byte) string {
+	switch {
+	case bytes.HasPrefix(header, []byte("GGUF")):
+		return "gguf"
+	case len(header) >= 4 && ggmlMagics[string(header[:4])]:
+		return "ggml"
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		// Archiwum ZIP zapisywane przez torch.save
+		return "pytorch"
+	case len(header) >= 10 && string(header[8:10]) == `{"` && binary.LittleEndian.Uint64(header) < 100<<20:
+		// Safetensors: długość nagłówka JSON, a po niej sam nagłówek
+		return "safetensors"
+	}
+	return ""
+}
+
+// modelFingerprint oblicza tani odcisk zawartości pliku: SHA-256 rozmiaru oraz pierwszego
+// i ostatniego MiB. Wystarcza do wykrycia podmiany modelu bez czytania wielu GB.
+func modelFingerprint(file *os.File, size int64) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n", size)
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	if size <= 2*fingerprintChunk {
+		if _, err := io.Copy(hash, file); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	if _, err := io.CopyN(hash, file, fingerprintChunk); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(size-fingerprintChunk, io.SeekStart); err != nil {
+		return "", err
+	}
+	if _, err := io.CopyN(hash, file, fingerprintChunk); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readMappedFiles zwraca ścieżki plików zmapowanych w pamięci procesu (/proc/<pid>/maps)
+func readMappedFiles(root string, pid int32) []string {
+	file, err := os.Open(filepath.Join(root, strconv.Itoa(int(pid)), "maps"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	seen := make(map[string]bool)
+	var paths []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// adres uprawnienia przesunięcie urządzenie i-węzeł ścieżka
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[4] == "0" {
+			continue
+		}
+		path := strings.Join(fields[5:], " ")
+		if !strings.HasPrefix(path, "/") || strings.HasSuffix(path, " (deleted)") || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	return paths
+}
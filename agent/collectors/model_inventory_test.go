@@ -0,0 +1,110 @@
+package collectors
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+func TestModelInventoryEnricher(t *testing.T) {
+	root := t.TempDir()
+	gguf := testGGUF()
+
+	// Ten sam plik modelu widziany przez proces hosta i proces w kontenerze
+	writeCgroupFiles(t, filepath.Join(root, "10", "root", "models"), map[string]string{"tiny.gguf": string(gguf)})
+	if err := os.MkdirAll(filepath.Join(root, "20", "root", "models"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(filepath.Join(root, "10", "root", "models", "tiny.gguf"), filepath.Join(root, "20", "root", "models", "tiny.gguf")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plik blob bez rozszerzenia, zmapowany w pamięci przez serwer modeli
+	blob := filepath.Join(root, "30", "root", "blobs", "sha256-1234")
+	writeCgroupFiles(t, filepath.Dir(blob), map[string]string{"sha256-1234": string(gguf)})
+	if err := os.Truncate(blob, minSniffedModelSize); err != nil {
+		t.Fatal(err)
+	}
+	writeCgroupFiles(t, filepath.Join(root, "30"), map[string]string{
+		"maps": "7f0000000000-7f0100000000 r--p 00000000 fd:01 1234 /blobs/sha256-1234\n" +
+			"7f0200000000-7f0200001000 r-xp 00000000 fd:01 99 /usr/lib/libc.so.6\n" +
+			"7ffd00000000-7ffd00021000 rw-p 00000000 00:00 0 [stack]\n",
+	})
+
+	writeCgroupFiles(t, filepath.Join(root, "40", "root", "data"), map[string]string{
+		"weights.safetensors": "\x10\x00\x00\x00\x00\x00\x00\x00{\"__metadata__\":{}}",
+		"archive.bin":         "not a model",
+		"pytorch_model.bin":   "PK\x03\x04archive/data.pkl",
+	})
+
+	state := models.NewSystemState()
+	state.Processes = []models.Process{
+		{PID: 20, Name: "llama-server", IsLLMRelated: true, ContainerID: "abc123", OpenFiles: []models.OpenFile{{Path: "/models/tiny.gguf"}}},
+		{PID: 10, Name: "llama-server", IsLLMRelated: true, OpenFiles: []models.OpenFile{{Path: "/models/tiny.gguf"}, {Path: "/etc/hosts"}}},
+		{PID: 30, Name: "ollama_llama_server", IsLLMRelated: true},
+		{PID: 40, Name: "python3", OpenFiles: []models.OpenFile{
+			{Path: "/data/weights.safetensors"}, {Path: "/data/archive.bin"}, {Path: "/data/pytorch_model.bin"},
+		}},
+	}
+
+	e := &ModelInventoryEnricher{procRoot: root, cache: make(map[string]*cachedModel)}
+	if err := e.Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd spisu modeli: %v", err)
+	}
+
+	paths := make([]string, 0, len(state.Models))
+	for _, model := range state.Models {
+		paths = append(paths, model.Path)
+	}
+	wantPaths := []string{"/blobs/sha256-1234", "/data/pytorch_model.bin", "/data/weights.safetensors", "/models/tiny.gguf"}
+	if !reflect.DeepEqual(paths, wantPaths) {
+		t.Fatalf("Niepoprawne pliki modeli: got %v, want %v", paths, wantPaths)
+	}
+
+	tiny := state.Models[3]
+	if !reflect.DeepEqual(tiny.PIDs, []int32{10, 20}) || !reflect.DeepEqual(tiny.Containers, []string{"abc123"}) {
+		t.Errorf("Niepoprawni użytkownicy modelu: got %v, %v", tiny.PIDs, tiny.Containers)
+	}
+	if tiny.Format != "gguf" || tiny.SizeBytes != int64(len(gguf)) || len(tiny.Fingerprint) != 64 || tiny.ModifiedTime == "" {
+		t.Errorf("Niepoprawny opis modelu: got %+v", tiny)
+	}
+	if tiny.GGUF == nil || tiny.GGUF.Architecture != "llama" || tiny.GGUF.Quantization != "Q4_K_M" || tiny.GGUF.ContextLength != 2048 {
+		t.Errorf("Niepoprawne metadane GGUF: got %+v", tiny.GGUF)
+	}
+
+	blobModel := state.Models[0]
+	if blobModel.Format != "gguf" || blobModel.SizeBytes != minSniffedModelSize || !reflect.DeepEqual(blobModel.PIDs, []int32{30}) {
+		t.Errorf("Niepoprawny model zmapowany w pamięci: got %+v", blobModel)
+	}
+	if state.Models[1].Format != "pytorch" || state.Models[2].Format != "safetensors" {
+		t.Errorf("Niepoprawne formaty: got %v, %v", state.Models[1].Format, state.Models[2].Format)
+	}
+
+	// Podmiana pliku zmienia odcisk; plik, którego nikt nie używa, znika z pamięci podręcznej
+	fingerprint := tiny.Fingerprint
+	modified := append([]byte(nil), gguf...)
+	modified[len(modified)-1] ^= 0xff
+	if err := os.WriteFile(filepath.Join(root, "10", "root", "models", "tiny.gguf"), modified, 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(filepath.Join(root, "10", "root", "models", "tiny.gguf"), later, later); err != nil {
+		t.Fatal(err)
+	}
+	state.Processes = state.Processes[:2]
+
+	if err := e.Enrich(context.Background(), state); err != nil {
+		t.Fatalf("Błąd spisu modeli: %v", err)
+	}
+	if len(state.Models) != 1 || state.Models[0].Fingerprint == fingerprint {
+		t.Errorf("Oczekiwano nowego odcisku podmienionego modelu: got %+v", state.Models)
+	}
+	if len(e.cache) != 1 {
+		t.Errorf("Niepoprawna liczba plików w pamięci podręcznej: got %d, want %d", len(e.cache), 1)
+	}
+}
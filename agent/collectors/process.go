@@ -1,9 +1,8 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
-	"path/filepath"
-	"regexp"
 	"strings"
 	"time"
 
@@ -14,39 +13,22 @@ import (
 
 // ProcessCollector zbiera informacje o procesach
 type ProcessCollector struct {
-	// Lista wyrażeń regularnych dla procesów związanych z LLM
-	llmPatterns []*regexp.Regexp
+	// Katalog systemu plików proc, z którego odczytywane są cgroup procesów
+	procRoot string
 }
 
 // NewProcessCollector tworzy nowy kolektor informacji o procesach
 func NewProcessCollector() *ProcessCollector {
-	// Wzorce dla procesów związanych z LLM
-	llmPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`(?i)python.*torch`),
-		regexp.MustCompile(`(?i)python.*tensorflow`),
-		regexp.MustCompile(`(?i)python.*transformers`),
-		regexp.MustCompile(`(?i)python.*huggingface`),
-		regexp.MustCompile(`(?i)python.*llama`),
-		regexp.MustCompile(`(?i)python.*bert`),
-		regexp.MustCompile(`(?i)python.*gpt`),
-		regexp.MustCompile(`(?i)python.*t5`),
-		regexp.MustCompile(`(?i)python.*whisper`),
-		regexp.MustCompile(`(?i)llama.cpp`),
-		regexp.MustCompile(`(?i)ggml`),
-		regexp.MustCompile(`(?i)ollama`),
-		regexp.MustCompile(`(?i)text-generation-server`),
-		regexp.MustCompile(`(?i)nvidia-smi`),
-	}
-
 	return &ProcessCollector{
-		llmPatterns: llmPatterns,
+		procRoot: procRoot(),
 	}
 }
 
-// Collect zbiera informacje o procesach i zwraca slice wypełnionych obiektów Process
-func (c *ProcessCollector) Collect() ([]models.Process, error) {
+// Collect zbiera informacje o procesach i zwraca slice wypełnionych obiektów Process.
+// Po anulowaniu kontekstu zbieranie jest przerywane.
+func (c *ProcessCollector) Collect(ctx context.Context) ([]models.Process, error) {
 	// Pobierz listę wszystkich procesów
-	processes, err := process.Processes()
+	processes, err := process.ProcessesWithContext(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("błąd podczas pobierania listy procesów: %v", err)
 	}
@@ -56,6 +38,11 @@ func (c *ProcessCollector) Collect() ([]models.Process, error) {
 
 	// Zbierz informacje o każdym procesie
 	for _, proc := range processes {
+		// Przerwij, jeśli upłynął czas przeznaczony na zbieranie
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
 		// Pobierz podstawowe informacje o procesie
 		processModel, err := c.collectProcessInfo(proc)
 		if err != nil {
@@ -65,7 +52,7 @@ func (c *ProcessCollector) Collect() ([]models.Process, error) {
 		}
 
 		// Sprawdź, czy proces jest związany z LLM
-		processModel.IsLLMRelated = c.isLLMRelated(processModel)
+		classifyProcess(processModel)
 
 		// Dodaj do listy
 		processModels = append(processModels, *processModel)
@@ -224,41 +211,14 @@ func (c *ProcessCollector) collectProcessInfo(proc *process.Process) (*models.Pr
 		}
 	}
 
-	return processModel, nil
-}
-
-// isLLMRelated sprawdza, czy proces jest związany z LLM
-func (c *ProcessCollector) isLLMRelated(proc *models.Process) bool {
-	// Sprawdź nazwę procesu
-	for _, pattern := range c.llmPatterns {
-		if pattern.MatchString(proc.Name) {
-			return true
-		}
+	// Przypisz proces do kontenera i jednostki systemd na podstawie jego cgroup
+	if cgroup, err := readProcessCgroup(c.procRoot, proc.Pid); err == nil {
+		processModel.CgroupPath = cgroup.Path
+		processModel.ContainerID = cgroup.ContainerID
+		processModel.SystemdUnit = cgroup.SystemdUnit
 	}
 
-	// Sprawdź linię poleceń
-	cmdline := strings.Join(proc.Cmdline, " ")
-	for _, pattern := range c.llmPatterns {
-		if pattern.MatchString(cmdline) {
-			return true
-		}
-	}
-
-	// Sprawdź otwarte pliki pod kątem modeli LLM
-	for _, file := range proc.OpenFiles {
-		filename := filepath.Base(file.Path)
-		if strings.Contains(strings.ToLower(filename), "model") &&
-			(strings.HasSuffix(strings.ToLower(filename), ".bin") ||
-				strings.HasSuffix(strings.ToLower(filename), ".gguf") ||
-				strings.HasSuffix(strings.ToLower(filename), ".ggml") ||
-				strings.HasSuffix(strings.ToLower(filename), ".pt") ||
-				strings.HasSuffix(strings.ToLower(filename), ".pth") ||
-				strings.HasSuffix(strings.ToLower(filename), ".safetensors")) {
-			return true
-		}
-	}
-
-	return false
+	return processModel, nil
 }
 
 // getConnectionFamily zwraca rodzinę połączenia jako string
@@ -1,6 +1,7 @@
 package collectors
 
 import (
+	"safetytwin/agent/classify"
 	"safetytwin/agent/models"
 	"fmt"
 	"strings"
@@ -9,13 +10,6 @@ import (
 	"github.com/shirou/gopsutil/v3/process"
 )
 
-// Lista słów kluczowych do identyfikacji procesów LLM
-var llmKeywords = []string{
-	"python", "python3", "llama", "gpt", "bert", "transformer",
-	"pytorch", "tensorflow", "onnxruntime", "huggingface",
-	"langchain", "llm", "openai", "mistral", "vicuna", "falcon",
-}
-
 // CollectProcessesInfo zbiera informacje o procesach systemowych
 func CollectProcessesInfo() ([]models.Process, error) {
 	// Pobierz wszystkie procesy
@@ -193,36 +187,14 @@ func CollectProcessesInfo() ([]models.Process, error) {
 	return processes, nil
 }
 
-// isLLMProcess sprawdza, czy proces jest potencjalnie związany z LLM
+// isLLMProcess sprawdza, czy proces jest potencjalnie związany z LLM,
+// korzystając z reguł klasyfikacji wspólnych dla wszystkich kolektorów
 func isLLMProcess(proc *process.Process, name string, cmdline string) bool {
-	// Sprawdź nazwę procesu
-	nameLower := strings.ToLower(name)
-	for _, keyword := range llmKeywords {
-		if strings.Contains(nameLower, keyword) {
-			return true
-		}
-	}
-
-	// Sprawdź linię poleceń
-	cmdlineLower := strings.ToLower(cmdline)
-	for _, keyword := range llmKeywords {
-		if strings.Contains(cmdlineLower, keyword) {
-			return true
-		}
+	subject := classify.Subject{Names: []string{name}, Cmdline: cmdline}
+	if environ, err := proc.Environ(); err == nil {
+		subject.Env = environ
 	}
-
-	// Sprawdź zmienne środowiskowe
-	environ, err := proc.Environ()
-	if err == nil {
-		environStr := strings.ToLower(strings.Join(environ, " "))
-		for _, keyword := range llmKeywords {
-			if strings.Contains(environStr, keyword) {
-				return true
-			}
-		}
-	}
-
-	return false
+	return currentClassifier().Classify(subject) != nil
 }
 
 // processExists sprawdza, czy proces jeszcze istnieje
@@ -0,0 +1,151 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Container reprezentuje kontener niezależnie od środowiska uruchomieniowego
+type Container struct {
+	ID            string
+	Name          string
+	Image         string
+	Command       []string
+	RestartPolicy string
+	Status        string
+	PID           int32
+	StartTime     string
+	Ports         []models.Port
+	Volumes       []models.Volume
+	Environment   []string
+	Links         []string
+	Networks      []string
+	Labels        map[string]string
+	CPUPercent    float64
+	MemoryPercent float32
+	MemoryUsage   uint64 // Użycie pamięci w bajtach
+	MemoryLimit   uint64 // Limit pamięci w bajtach (0 = brak danych)
+}
+
+// ContainerRuntime to środowisko uruchomieniowe kontenerów (Docker, Podman, containerd)
+type ContainerRuntime interface {
+	// Runtime zwraca nazwę środowiska, używaną jako typ usługi
+	Runtime() string
+	// Available sprawdza, czy środowisko jest zainstalowane na hoście
+	Available() bool
+	// Collect zbiera informacje o wszystkich kontenerach środowiska
+	Collect(ctx context.Context) ([]Container, error)
+}
+
+// newRuntimeCollector tworzy kolektor kontenerów danego środowiska. Na hostach bez
+// tego środowiska kolektor nie zwraca danych ani błędu.
+func newRuntimeCollector(runtime ContainerRuntime) Collector {
+	serviceCollector := NewServiceCollector()
+	return NewCollectorFunc(runtime.Runtime(), func(ctx context.Context) (interface{}, error) {
+		if !runtime.Available() {
+			return nil, nil
+		}
+
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "unknown"
+		}
+		return serviceCollector.collectContainerServices(ctx, runtime, hostname)
+	})
+}
+
+// collectContainerServices zbiera kontenery środowiska i zamienia je na usługi
+func (c *ServiceCollector) collectContainerServices(ctx context.Context, runtime ContainerRuntime, hostname string) ([]models.Service, error) {
+	// Pobierz informacje o kontenerach
+	containers, err := runtime.Collect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("błąd podczas pobierania informacji o kontenerach %s: %v", runtime.Runtime(), err)
+	}
+
+	// Utwórz slice na informacje o usługach
+	serviceModels := make([]models.Service, 0, len(containers))
+
+	// Aktualny czas
+	currentTime := time.Now()
+	timestamp := currentTime.Format(time.RFC3339)
+
+	// Konwertuj kontenery na usługi
+	for _, container := range containers {
+		serviceModels = append(serviceModels, c.containerService(runtime.Runtime(), container, hostname, timestamp, currentTime))
+	}
+
+	return serviceModels, nil
+}
+
+// containerService tworzy model usługi na podstawie kontenera
+func (c *ServiceCollector) containerService(runtime string, container Container, hostname, timestamp string, currentTime time.Time) models.Service {
+	// Utwórz model usługi
+	service := models.Service{
+		Name:          container.Name,
+		Type:          runtime,
+		ID:            container.ID,
+		Hostname:      hostname,
+		Timestamp:     timestamp,
+		Status:        container.Status,
+		PID:           container.PID,
+		Image:         container.Image,
+		Command:       container.Command,
+		RestartPolicy: container.RestartPolicy,
+		Ports:         container.Ports,
+		Volumes:       container.Volumes,
+		Environment:   container.Environment,
+		Links:         container.Links,
+		Networks:      container.Networks,
+		Labels:        container.Labels,
+		CPUPercent:    container.CPUPercent,
+		MemoryPercent: container.MemoryPercent,
+	}
+	if service.Ports == nil {
+		service.Ports = make([]models.Port, 0)
+	}
+	if service.Volumes == nil {
+		service.Volumes = make([]models.Volume, 0)
+	}
+
+	// Czas uruchomienia działającego kontenera
+	if container.StartTime != "" && container.Status == "running" {
+		if startTime, err := time.Parse(time.RFC3339Nano, container.StartTime); err == nil {
+			service.StartTime = startTime.Format(time.RFC3339)
+			service.UptimeSeconds = int64(currentTime.Sub(startTime).Seconds())
+		}
+	}
+
+	// Użycie pamięci w bajtach (dla metryk kontenerów)
+	if container.MemoryUsage > 0 || container.MemoryLimit > 0 {
+		service.Extra = map[string]interface{}{
+			"memory_usage_bytes": container.MemoryUsage,
+		}
+		if container.MemoryLimit > 0 {
+			service.Extra["memory_limit_bytes"] = container.MemoryLimit
+		}
+	}
+
+	// Sprawdź, czy usługa jest związana z LLM
+	classifyService(&service)
+
+	return service
+}
+
+// socketAvailable sprawdza, czy adres wskazuje na istniejące gniazdo uniksowe;
+// adresy innych typów (np. tcp://) są uznawane za dostępne
+func socketAvailable(address string) bool {
+	path, ok := strings.CutPrefix(address, "unix://")
+	if !ok {
+		if strings.Contains(address, "://") {
+			return true
+		}
+		path = address
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,153 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// fakeRuntime to środowisko kontenerów o stałej zawartości
+type fakeRuntime struct {
+	containers []Container
+	err        error
+}
+
+func (r *fakeRuntime) Runtime() string { return "podman" }
+func (r *fakeRuntime) Available() bool { return true }
+func (r *fakeRuntime) Collect(ctx context.Context) ([]Container, error) {
+	return r.containers, r.err
+}
+
+func TestCollectContainerServices(t *testing.T) {
+	started := time.Now().Add(-time.Hour).UTC()
+	runtime := &fakeRuntime{containers: []Container{
+		{
+			ID:            "abc123",
+			Name:          "ollama",
+			Image:         "docker.io/ollama/ollama:0.3",
+			Status:        "running",
+			PID:           4242,
+			StartTime:     started.Format(time.RFC3339Nano),
+			Ports:         []models.Port{{ContainerPort: "8000/tcp", HostPort: "8000"}},
+			Environment:   []string{"HF_HOME=/models"},
+			Labels:        map[string]string{"io.podman.compose.project": "inference"},
+			CPUPercent:    120,
+			MemoryPercent: 25,
+			MemoryUsage:   4 << 30,
+			MemoryLimit:   16 << 30,
+		},
+		{ID: "def456", Name: "web", Image: "nginx", Status: "exited"},
+	}}
+
+	services, err := NewServiceCollector().collectContainerServices(context.Background(), runtime, "test-host")
+	if err != nil {
+		t.Fatalf("Błąd zbierania kontenerów: %v", err)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Niepoprawna liczba usług: got %v, want %v", len(services), 2)
+	}
+
+	ollama := services[0]
+	if ollama.Type != "podman" || ollama.ID != "abc123" || ollama.Hostname != "test-host" || ollama.PID != 4242 {
+		t.Errorf("Niepoprawna identyfikacja kontenera: got %+v", ollama)
+	}
+	if !ollama.IsContainer() || !ollama.IsLLMRelated {
+		t.Errorf("Oczekiwano kontenera związanego z LLM: got %v, %v", ollama.IsContainer(), ollama.IsLLMRelated)
+	}
+	if ollama.Labels["io.podman.compose.project"] != "inference" || len(ollama.Ports) != 1 {
+		t.Errorf("Niepoprawne etykiety lub porty: got %v, %v", ollama.Labels, ollama.Ports)
+	}
+	if ollama.CPUPercent != 120 || ollama.MemoryPercent != 25 {
+		t.Errorf("Niepoprawne statystyki: got %v%% CPU, %v%% pamięci", ollama.CPUPercent, ollama.MemoryPercent)
+	}
+	if ollama.Extra["memory_usage_bytes"] != uint64(4<<30) || ollama.Extra["memory_limit_bytes"] != uint64(16<<30) {
+		t.Errorf("Niepoprawne użycie pamięci: got %v", ollama.Extra)
+	}
+	if ollama.UptimeSeconds < 3500 || ollama.StartTime == "" {
+		t.Errorf("Niepoprawny czas działania: got %v (%v)", ollama.UptimeSeconds, ollama.StartTime)
+	}
+
+	web := services[1]
+	if web.IsLLMRelated || web.StartTime != "" || web.Ports == nil || web.Volumes == nil || web.Extra != nil {
+		t.Errorf("Niepoprawny zatrzymany kontener: got %+v", web)
+	}
+
+	runtime.err = errors.New("connection refused")
+	if _, err := NewServiceCollector().collectContainerServices(context.Background(), runtime, "test-host"); err == nil {
+		t.Error("Oczekiwano błędu, gdy środowisko nie zwraca kontenerów")
+	}
+}
+
+func TestCPUPercent(t *testing.T) {
+	previous := cpuSample{container: 1000, system: 10000}
+	current := cpuSample{container: 1500, system: 12000}
+
+	// 500 z 2000 jednostek czasu systemu na 4 procesorach = jeden rdzeń
+	if got := cpuPercent(previous, current, 4); got != 100 {
+		t.Errorf("Niepoprawne użycie CPU: got %v, want %v", got, 100)
+	}
+	// Licznik kontenera wyzerowany (np. restart kontenera)
+	if got := cpuPercent(current, previous, 4); got != 0 {
+		t.Errorf("Niepoprawne użycie CPU po wyzerowaniu liczników: got %v, want %v", got, 0)
+	}
+}
+
+func TestContainerdMapping(t *testing.T) {
+	names := []struct {
+		labels map[string]string
+		want   string
+	}{
+		{map[string]string{"io.kubernetes.container.name": "ollama", "io.kubernetes.pod.name": "ollama-0"}, "ollama-0/ollama"},
+		{map[string]string{"nerdctl/name": "redis"}, "redis"},
+		{nil, "0123abcd"},
+	}
+	for _, tt := range names {
+		if got := containerdName("0123abcd", tt.labels); got != tt.want {
+			t.Errorf("Niepoprawna nazwa kontenera dla %v: got %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+
+	volumes := ociVolumes([]specs.Mount{
+		{Destination: "/proc", Type: "proc", Source: "proc"},
+		{Destination: "/models", Type: "bind", Source: "/srv/models", Options: []string{"rbind", "ro"}},
+		{Destination: "/data", Source: "/var/lib/data", Options: []string{"rbind", "rw"}},
+		{Destination: "/dev/shm", Type: "tmpfs", Source: "shm"},
+	})
+	if len(volumes) != 2 {
+		t.Fatalf("Niepoprawna liczba wolumenów: got %v, want %v (%+v)", len(volumes), 2, volumes)
+	}
+	if volumes[0].Source != "/srv/models" || !volumes[0].ReadOnly || volumes[1].ReadOnly {
+		t.Errorf("Niepoprawne wolumeny: got %+v", volumes)
+	}
+
+	ports := nerdctlPorts(map[string]string{
+		"nerdctl/ports": `[{"HostPort":11434,"ContainerPort":11434,"Protocol":"tcp","HostIP":"0.0.0.0"},{"HostPort":5353,"ContainerPort":53,"Protocol":"udp","HostIP":"127.0.0.1"}]`,
+	})
+	wantPorts := []models.Port{
+		{ContainerPort: "11434/tcp", HostIP: "0.0.0.0", HostPort: "11434"},
+		{ContainerPort: "53/udp", HostIP: "127.0.0.1", HostPort: "5353"},
+	}
+	if !reflect.DeepEqual(ports, wantPorts) {
+		t.Errorf("Niepoprawne porty: got %+v, want %+v", ports, wantPorts)
+	}
+	if ports := nerdctlPorts(map[string]string{"io.cri-containerd.kind": "container"}); len(ports) != 0 {
+		t.Errorf("Oczekiwano braku portów dla kontenera CRI: got %+v", ports)
+	}
+}
+
+func TestSocketAvailable(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "runtime.sock")
+	if socketAvailable("unix://" + socket) {
+		t.Error("Nieistniejące gniazdo uznane za dostępne")
+	}
+	if !socketAvailable("tcp://127.0.0.1:2375") {
+		t.Error("Adres TCP powinien być uznany za dostępny")
+	}
+}
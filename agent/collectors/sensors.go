@@ -0,0 +1,280 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// energySample to próbka licznika energii strefy RAPL
+type energySample struct {
+	energyUJ uint64
+	at       time.Time
+}
+
+// SensorCollector odczytuje z sysfs czujniki sprzętowe: temperatury, wentylatory
+// i napięcia (hwmon), strefy termiczne oraz liczniki energii RAPL (powercap) procesorów
+// Intel i AMD. Moc w watach jest liczona między kolejnymi zbieraniami.
+type SensorCollector struct {
+	root string
+	now  func() time.Time
+
+	// Poprzednie próbki energii według katalogu strefy powercap
+	mu            sync.Mutex
+	energySamples map[string]energySample
+}
+
+// NewSensorCollector tworzy nowy kolektor czujników. Zmienna HOST_SYS pozwala
+// wskazać katalog /sys hosta (tak jak w gopsutil).
+func NewSensorCollector() *SensorCollector {
+	return &SensorCollector{
+		root:          sysRoot(),
+		now:           time.Now,
+		energySamples: make(map[string]energySample),
+	}
+}
+
+// Collect odczytuje czujniki; zwraca nil, gdy host nie udostępnia żadnego z nich
+// (np. maszyna wirtualna)
+func (c *SensorCollector) Collect() *models.Sensors {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sensors := &models.Sensors{}
+	c.collectHwmon(sensors)
+	sensors.ThermalZones = c.collectThermalZones()
+	sensors.Power = c.collectPower()
+
+	if len(sensors.Temperatures) == 0 && len(sensors.Fans) == 0 && len(sensors.Voltages) == 0 &&
+		len(sensors.ThermalZones) == 0 && len(sensors.Power) == 0 {
+		return nil
+	}
+	return sensors
+}
+
+// collectHwmon odczytuje czujniki wszystkich układów z /sys/class/hwmon
+func (c *SensorCollector) collectHwmon(sensors *models.Sensors) {
+	chips, _ := filepath.Glob(filepath.Join(c.root, "class", "hwmon", "hwmon*"))
+	sortNumerically(chips, "")
+
+	for _, dir := range chips {
+		device := hwmonDevice(dir)
+
+		// Starsze sterowniki udostępniają atrybuty w podkatalogu device
+		chip := readSysfsString(filepath.Join(dir, "name"))
+		if chip == "" {
+			dir = filepath.Join(dir, "device")
+			chip = readSysfsString(filepath.Join(dir, "name"))
+		}
+		if chip == "" {
+			continue
+		}
+
+		// Temperatury i napięcia są podawane w tysięcznych częściach (m°C, mV)
+		sensors.Temperatures = append(sensors.Temperatures, readHwmonSensors(dir, chip, device, "temp", 1000)...)
+		sensors.Fans = append(sensors.Fans, readHwmonSensors(dir, chip, device, "fan", 1)...)
+		sensors.Voltages = append(sensors.Voltages, readHwmonSensors(dir, chip, device, "in", 1000)...)
+	}
+}
+
+// hwmonDevice zwraca urządzenie, do którego należy układ hwmon (np. "nvme0",
+// "coretemp.1" lub adres PCI karty GPU), a bez dowiązania device nazwę katalogu
+// hwmonN. Nazwa układu nie wystarcza: kilka dysków NVMe zgłasza "nvme", a host
+// z dwoma procesorami dwa układy "coretemp".
+func hwmonDevice(dir string) string {
+	link := filepath.Join(dir, "device")
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if target, err := filepath.EvalSymlinks(link); err == nil {
+			return filepath.Base(target)
+		}
+	}
+	return filepath.Base(dir)
+}
+
+// readHwmonSensors odczytuje czujniki danego rodzaju (np. temp1_input, temp2_input)
+// wraz z etykietami i progami; wartości są dzielone przez scale
+func readHwmonSensors(dir, chip, device, kind string, scale float64) []models.SensorReading {
+	inputs, _ := filepath.Glob(filepath.Join(dir, kind+"*_input"))
+	sortNumerically(inputs, "_input")
+
+	readings := make([]models.SensorReading, 0, len(inputs))
+	for _, input := range inputs {
+		sensor := strings.TrimSuffix(filepath.Base(input), "_input")
+		if _, err := strconv.Atoi(strings.TrimPrefix(sensor, kind)); err != nil {
+			continue
+		}
+
+		// Odczyt czujnika może się nie powieść (np. odłączony wentylator)
+		value, err := readSysfsFloat(input)
+		if err != nil {
+			continue
+		}
+
+		reading := models.SensorReading{Chip: chip, Device: device, Label: sensor, Value: value / scale}
+		if label := readSysfsString(filepath.Join(dir, sensor+"_label")); label != "" {
+			reading.Label = label
+		}
+		if limit, err := readSysfsFloat(filepath.Join(dir, sensor+"_max")); err == nil {
+			reading.Max = limit / scale
+		}
+		if critical, err := readSysfsFloat(filepath.Join(dir, sensor+"_crit")); err == nil {
+			reading.Critical = critical / scale
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings
+}
+
+// collectThermalZones odczytuje temperatury stref termicznych z /sys/class/thermal
+func (c *SensorCollector) collectThermalZones() []models.SensorReading {
+	zones, _ := filepath.Glob(filepath.Join(c.root, "class", "thermal", "thermal_zone*"))
+	sortNumerically(zones, "")
+
+	readings := make([]models.SensorReading, 0, len(zones))
+	for _, zone := range zones {
+		temp, err := readSysfsFloat(filepath.Join(zone, "temp"))
+		if err != nil {
+			continue
+		}
+
+		reading := models.SensorReading{
+			Chip:  filepath.Base(zone),
+			Label: readSysfsString(filepath.Join(zone, "type")),
+			Value: temp / 1000,
+		}
+
+		// Temperatura krytyczna to punkt wyzwalania typu "critical"
+		trips, _ := filepath.Glob(filepath.Join(zone, "trip_point_*_type"))
+		for _, trip := range trips {
+			if readSysfsString(trip) != "critical" {
+				continue
+			}
+			if critical, err := readSysfsFloat(strings.TrimSuffix(trip, "_type") + "_temp"); err == nil {
+				reading.Critical = critical / 1000
+			}
+		}
+		readings = append(readings, reading)
+	}
+
+	return readings
+}
+
+// collectPower odczytuje liczniki energii stref RAPL z /sys/class/powercap i wylicza
+// średnią moc od poprzedniego zbierania. Od jądra 5.10 liczniki energy_uj są
+// dostępne tylko dla roota.
+func (c *SensorCollector) collectPower() []models.PowerDomain {
+	zones, _ := filepath.Glob(filepath.Join(c.root, "class", "powercap", "*"))
+	now := c.now()
+	seen := make(map[string]bool)
+
+	domains := make([]models.PowerDomain, 0)
+	for _, zone := range zones {
+		id := filepath.Base(zone)
+		// Strefy interfejsu MMIO powielają pakiety dostępne przez MSR
+		if strings.HasPrefix(id, "intel-rapl-mmio") {
+			continue
+		}
+
+		// Katalogi typów sterowania (np. "intel-rapl") nie mają licznika energii
+		energy, err := strconv.ParseUint(readSysfsString(filepath.Join(zone, "energy_uj")), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		name := readSysfsString(filepath.Join(zone, "name"))
+		if name == "" {
+			name = id
+		}
+		// Podstrefy (np. "intel-rapl:0:0") są nazywane razem ze strefą nadrzędną
+		if strings.Count(id, ":") > 1 {
+			parent := id[:strings.LastIndex(id, ":")]
+			if parentName := readSysfsString(filepath.Join(filepath.Dir(zone), parent, "name")); parentName != "" {
+				name = parentName + "/" + name
+			}
+		}
+
+		domain := models.PowerDomain{Name: name, EnergyJoules: float64(energy) / 1e6}
+		current := energySample{energyUJ: energy, at: now}
+		if previous, ok := c.energySamples[id]; ok {
+			maxRange, _ := strconv.ParseUint(readSysfsString(filepath.Join(zone, "max_energy_range_uj")), 10, 64)
+			domain.Watts = raplWatts(previous, current, maxRange)
+		}
+		c.energySamples[id] = current
+		seen[id] = true
+
+		domains = append(domains, domain)
+	}
+
+	// Zapomnij próbki stref, które zniknęły
+	for id := range c.energySamples {
+		if !seen[id] {
+			delete(c.energySamples, id)
+		}
+	}
+
+	return domains
+}
+
+// raplWatts wylicza średnią moc między dwiema próbkami licznika energii, uwzględniając
+// przekręcenie licznika po osiągnięciu maxRange
+func raplWatts(previous, current energySample, maxRange uint64) float64 {
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	var delta uint64
+	switch {
+	case current.energyUJ >= previous.energyUJ:
+		delta = current.energyUJ - previous.energyUJ
+	case maxRange >= previous.energyUJ:
+		delta = maxRange - previous.energyUJ + current.energyUJ
+	default:
+		return 0
+	}
+
+	return float64(delta) / 1e6 / elapsed
+}
+
+// sortNumerically sortuje ścieżki według numeru na końcu nazwy pliku (po usunięciu
+// sufiksu), tak aby np. hwmon10 następował po hwmon2
+func sortNumerically(paths []string, suffix string) {
+	index := func(path string) int {
+		name := strings.TrimSuffix(filepath.Base(path), suffix)
+		i := len(name)
+		for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+			i--
+		}
+		n, _ := strconv.Atoi(name[i:])
+		return n
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		return index(paths[i]) < index(paths[j])
+	})
+}
+
+// readSysfsString odczytuje atrybut sysfs; zwraca pusty napis, gdy atrybut nie istnieje
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// readSysfsFloat odczytuje liczbowy atrybut sysfs
+func readSysfsFloat(path string) (float64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(string(data)), 64)
+}
@@ -0,0 +1,173 @@
+package collectors
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// writeSensorFixtures tworzy w katalogu root przykładowe sysfs z czujnikami hwmon,
+// strefą termiczną i strefami RAPL
+func writeSensorFixtures(t *testing.T, root string) {
+	t.Helper()
+
+	hwmon := filepath.Join(root, "class", "hwmon")
+	writeCgroupFiles(t, filepath.Join(hwmon, "hwmon2"), map[string]string{
+		"name":         "coretemp\n",
+		"temp1_input":  "54000\n",
+		"temp1_label":  "Package id 0\n",
+		"temp1_max":    "80000\n",
+		"temp1_crit":   "100000\n",
+		"temp10_input": "47500\n",
+	})
+	writeCgroupFiles(t, filepath.Join(hwmon, "hwmon10"), map[string]string{
+		"name":        "nct6775\n",
+		"fan1_input":  "1200\n",
+		"fan2_input":  "0\n",
+		"fan2_label":  "SYSFAN\n",
+		"in0_input":   "1104\n",
+		"in0_max":     "1744\n",
+		"temp1_input": "invalid\n",
+	})
+	// Starszy sterownik z atrybutami w podkatalogu device
+	writeCgroupFiles(t, filepath.Join(hwmon, "hwmon0", "device"), map[string]string{
+		"name":        "it87\n",
+		"temp1_input": "30000\n",
+	})
+
+	writeCgroupFiles(t, filepath.Join(root, "class", "thermal", "thermal_zone0"), map[string]string{
+		"type":              "x86_pkg_temp\n",
+		"temp":              "55000\n",
+		"trip_point_0_type": "passive\n",
+		"trip_point_0_temp": "90000\n",
+		"trip_point_1_type": "critical\n",
+		"trip_point_1_temp": "105000\n",
+	})
+
+	powercap := filepath.Join(root, "class", "powercap")
+	writeCgroupFiles(t, filepath.Join(powercap, "intel-rapl"), map[string]string{"enabled": "1\n"})
+	writeCgroupFiles(t, filepath.Join(powercap, "intel-rapl:0"), map[string]string{
+		"name":                "package-0\n",
+		"energy_uj":           "1000000\n",
+		"max_energy_range_uj": "262143328850\n",
+	})
+	writeCgroupFiles(t, filepath.Join(powercap, "intel-rapl:0:0"), map[string]string{
+		"name":                "dram\n",
+		"energy_uj":           "500000\n",
+		"max_energy_range_uj": "262143328850\n",
+	})
+	writeCgroupFiles(t, filepath.Join(powercap, "intel-rapl-mmio:0"), map[string]string{
+		"name":      "package-0\n",
+		"energy_uj": "1000000\n",
+	})
+}
+
+func TestSensorCollector(t *testing.T) {
+	root := t.TempDir()
+	writeSensorFixtures(t, root)
+
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := &SensorCollector{
+		root:          root,
+		now:           func() time.Time { return now },
+		energySamples: make(map[string]energySample),
+	}
+
+	sensors := c.Collect()
+	if sensors == nil {
+		t.Fatal("Brak odczytów czujników")
+	}
+
+	// hwmon0 (it87) przed hwmon2 (coretemp); temp10 po temp1; niepoprawny odczyt pominięty
+	if len(sensors.Temperatures) != 3 {
+		t.Fatalf("Niepoprawna liczba temperatur: got %+v", sensors.Temperatures)
+	}
+	if got := sensors.Temperatures[0]; got.Chip != "it87" || got.Label != "temp1" || got.Value != 30 {
+		t.Errorf("Niepoprawna temperatura ze starszego sterownika: got %+v", got)
+	}
+	if got := sensors.Temperatures[1]; got.Chip != "coretemp" || got.Label != "Package id 0" || got.Value != 54 || got.Max != 80 || got.Critical != 100 {
+		t.Errorf("Niepoprawna temperatura pakietu: got %+v", got)
+	}
+	if got := sensors.Temperatures[2]; got.Label != "temp10" || got.Value != 47.5 {
+		t.Errorf("Niepoprawna kolejność temperatur: got %+v", got)
+	}
+
+	if len(sensors.Fans) != 2 || sensors.Fans[0].Value != 1200 || sensors.Fans[1].Label != "SYSFAN" {
+		t.Errorf("Niepoprawne wentylatory: got %+v", sensors.Fans)
+	}
+	if len(sensors.Voltages) != 1 || sensors.Voltages[0].Value != 1.104 || sensors.Voltages[0].Max != 1.744 {
+		t.Errorf("Niepoprawne napięcia: got %+v", sensors.Voltages)
+	}
+
+	if len(sensors.ThermalZones) != 1 {
+		t.Fatalf("Niepoprawne strefy termiczne: got %+v", sensors.ThermalZones)
+	}
+	if got := sensors.ThermalZones[0]; got.Chip != "thermal_zone0" || got.Label != "x86_pkg_temp" || got.Value != 55 || got.Critical != 105 {
+		t.Errorf("Niepoprawna strefa termiczna: got %+v", got)
+	}
+
+	// Pierwsze zbieranie zna tylko liczniki energii, bez mocy
+	if len(sensors.Power) != 2 {
+		t.Fatalf("Niepoprawne strefy RAPL: got %+v", sensors.Power)
+	}
+	if got := sensors.Power[0]; got.Name != "package-0" || got.EnergyJoules != 1 || got.Watts != 0 {
+		t.Errorf("Niepoprawna strefa pakietu: got %+v", got)
+	}
+	if got := sensors.Power[1]; got.Name != "package-0/dram" || got.EnergyJoules != 0.5 {
+		t.Errorf("Niepoprawna podstrefa DRAM: got %+v", got)
+	}
+
+	// Drugie zbieranie po 10 s: pakiet zużył 500 J (50 W), a licznik DRAM się przekręcił
+	now = now.Add(10 * time.Second)
+	writeCgroupFiles(t, filepath.Join(root, "class", "powercap", "intel-rapl:0"), map[string]string{"energy_uj": "501000000\n"})
+	writeCgroupFiles(t, filepath.Join(root, "class", "powercap", "intel-rapl:0:0"), map[string]string{
+		"energy_uj":           "400000\n",
+		"max_energy_range_uj": "100100000\n",
+	})
+
+	sensors = c.Collect()
+	if got := sensors.Power[0].Watts; got != 50 {
+		t.Errorf("Niepoprawna moc pakietu: got %v, want %v", got, 50)
+	}
+	if got := sensors.Power[1].Watts; got != 10 {
+		t.Errorf("Niepoprawna moc DRAM po przekręceniu licznika: got %v, want %v", got, 10)
+	}
+}
+
+func TestSensorCollectorDuplicateChips(t *testing.T) {
+	root := t.TempDir()
+
+	// Dwa dyski NVMe zgłaszają ten sam układ i czujnik; rozróżnia je urządzenie
+	hwmon := filepath.Join(root, "class", "hwmon")
+	for i, device := range []string{"nvme0", "nvme1"} {
+		deviceDir := filepath.Join(root, "devices", "nvme", device)
+		dir := filepath.Join(hwmon, "hwmon"+strconv.Itoa(i))
+		writeCgroupFiles(t, dir, map[string]string{"name": "nvme\n", "temp1_input": "40000\n", "temp1_label": "Composite\n"})
+		writeCgroupFiles(t, deviceDir, map[string]string{"dev": "259:0\n"})
+		if err := os.Symlink(deviceDir, filepath.Join(dir, "device")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Układ bez dowiązania device jest rozróżniany katalogiem hwmonN
+	writeCgroupFiles(t, filepath.Join(hwmon, "hwmon2"), map[string]string{"name": "acpitz\n", "temp1_input": "30000\n"})
+
+	c := &SensorCollector{root: root, now: time.Now, energySamples: make(map[string]energySample)}
+	sensors := c.Collect()
+	if sensors == nil || len(sensors.Temperatures) != 3 {
+		t.Fatalf("Niepoprawne temperatury: got %+v", sensors)
+	}
+	for i, want := range []string{"nvme0", "nvme1", "hwmon2"} {
+		if got := sensors.Temperatures[i].Device; got != want {
+			t.Errorf("Niepoprawne urządzenie czujnika %d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestSensorCollectorWithoutSensors(t *testing.T) {
+	c := &SensorCollector{root: t.TempDir(), now: time.Now, energySamples: make(map[string]energySample)}
+	if sensors := c.Collect(); sensors != nil {
+		t.Errorf("Oczekiwano braku czujników: got %+v", sensors)
+	}
+}
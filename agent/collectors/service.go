@@ -3,7 +3,6 @@ package collectors
 import (
 	"fmt"
 	"os"
-	"regexp"
 	"strings"
 	"time"
 
@@ -14,41 +13,15 @@ import (
 )
 
 // ServiceCollector zbiera informacje o usługach
-type ServiceCollector struct {
-	// Lista wyrażeń regularnych dla usług związanych z LLM
-	llmPatterns []*regexp.Regexp
-}
+type ServiceCollector struct{}
 
 // NewServiceCollector tworzy nowy kolektor informacji o usługach
 func NewServiceCollector() *ServiceCollector {
-	// Wzorce dla usług związanych z LLM
-	llmPatterns := []*regexp.Regexp{
-		regexp.MustCompile(`(?i)torch`),
-		regexp.MustCompile(`(?i)tensorflow`),
-		regexp.MustCompile(`(?i)transformers`),
-		regexp.MustCompile(`(?i)huggingface`),
-		regexp.MustCompile(`(?i)llama`),
-		regexp.MustCompile(`(?i)bert`),
-		regexp.MustCompile(`(?i)gpt`),
-		regexp.MustCompile(`(?i)t5`),
-		regexp.MustCompile(`(?i)whisper`),
-		regexp.MustCompile(`(?i)llama.cpp`),
-		regexp.MustCompile(`(?i)ggml`),
-		regexp.MustCompile(`(?i)ollama`),
-		regexp.MustCompile(`(?i)text-generation-server`),
-		regexp.MustCompile(`(?i)nvidia-smi`),
-		regexp.MustCompile(`(?i)ml`),
-		regexp.MustCompile(`(?i)ai`),
-		regexp.MustCompile(`(?i)inference`),
-		regexp.MustCompile(`(?i)model`),
-	}
-
-	return &ServiceCollector{
-		llmPatterns: llmPatterns,
-	}
+	return &ServiceCollector{}
 }
 
-// Collect zbiera informacje o usługach i zwraca slice wypełnionych obiektów Service
+// Collect zbiera informacje o usługach systemowych i zwraca slice wypełnionych obiektów Service.
+// Kontenery są zbierane osobno przez kolektory "docker", "podman" i "containerd".
 func (c *ServiceCollector) Collect() ([]models.Service, error) {
 	// Pobierz nazwę hosta
 	hostname, err := os.Hostname()
@@ -67,14 +40,6 @@ func (c *ServiceCollector) Collect() ([]models.Service, error) {
 		services = append(services, systemServices...)
 	}
 
-	// Zbierz usługi dockerowe
-	dockerServices, err := c.collectDockerServices(hostname)
-	if err != nil {
-		fmt.Printf("Ostrzeżenie: nie można zebrać informacji o usługach dockerowych: %v\n", err)
-	} else {
-		services = append(services, dockerServices...)
-	}
-
 	return services, nil
 }
 
@@ -108,41 +73,7 @@ func (c *ServiceCollector) collectSystemServices(hostname string) ([]models.Serv
 		// Pobierz informacje o procesie, jeśli usługa jest uruchomiona
 		if svc.Status == "running" && svc.PID > 0 {
 			service.PID = svc.PID
-
-			// Pobierz proces
-			proc, err := process.NewProcess(svc.PID)
-			if err == nil {
-				// Pobierz czas utworzenia
-				createTime, err := proc.CreateTime()
-				if err == nil {
-					startTime := time.Unix(createTime/1000, 0)
-					service.StartTime = startTime.Format(time.RFC3339)
-					service.UptimeSeconds = int64(currentTime.Sub(startTime).Seconds())
-				}
-
-				// Pobierz użycie CPU
-				cpuPercent, err := proc.CPUPercent()
-				if err == nil {
-					service.CPUPercent = cpuPercent
-				}
-
-				// Pobierz użycie pamięci
-				memoryPercent, err := proc.MemoryPercent()
-				if err == nil {
-					service.MemoryPercent = memoryPercent
-				}
-
-				// Pobierz linię poleceń
-				cmdline, err := proc.Cmdline()
-				if err == nil {
-					service.Extra = map[string]interface{}{
-						"cmdline": cmdline,
-					}
-				}
-
-				// Sprawdź, czy usługa jest związana z LLM
-				service.IsLLMRelated = c.isLLMRelated(service.Name, cmdline)
-			}
+			c.addProcessInfo(&service, currentTime)
 		}
 
 		serviceModels = append(serviceModels, service)
@@ -151,86 +82,47 @@ func (c *ServiceCollector) collectSystemServices(hostname string) ([]models.Serv
 	return serviceModels, nil
 }
 
-// collectDockerServices zbiera informacje o usługach dockerowych
-func (c *ServiceCollector) collectDockerServices(hostname string) ([]models.Service, error) {
-	// Tutaj można użyć klienta Docker API do pobrania informacji o kontenerach
-	// Dla uproszczenia, użyjemy DockerCollector, jeśli jest dostępny
-	// W przeciwnym razie, zwrócimy pustą listę
-
-	// Sprawdź, czy DockerCollector jest dostępny
-	dockerCollector := NewDockerCollector()
-	if dockerCollector == nil {
-		return []models.Service{}, nil
-	}
-
-	// Pobierz informacje o kontenerach
-	containers, err := dockerCollector.Collect()
+// addProcessInfo uzupełnia usługę o informacje o jej głównym procesie (service.PID)
+func (c *ServiceCollector) addProcessInfo(service *models.Service, currentTime time.Time) {
+	// Pobierz proces
+	proc, err := process.NewProcess(service.PID)
 	if err != nil {
-		return nil, fmt.Errorf("błąd podczas pobierania informacji o kontenerach: %v", err)
+		return
 	}
 
-	// Utwórz slice na informacje o usługach
-	serviceModels := make([]models.Service, 0, len(containers))
-
-	// Aktualny czas
-	timestamp := time.Now().Format(time.RFC3339)
-
-	// Konwertuj kontenery na usługi
-	for _, container := range containers {
-		// Utwórz model usługi
-		service := models.Service{
-			Name:      container.Name,
-			Type:      "docker",
-			ID:        container.ID,
-			Hostname:  hostname,
-			Timestamp: timestamp,
-			Status:    container.Status,
-			Image:     container.Image,
-			Ports:     make([]models.Port, 0),
-			Volumes:   make([]models.Volume, 0),
-		}
-
-		// Dodaj porty
-		for _, port := range container.Ports {
-			service.Ports = append(service.Ports, models.Port{
-				ContainerPort: port.ContainerPort,
-				HostIP:        port.HostIP,
-				HostPort:      port.HostPort,
-			})
-		}
-
-		// Dodaj wolumeny
-		for _, volume := range container.Volumes {
-			service.Volumes = append(service.Volumes, models.Volume{
-				Source:      volume.Source,
-				Destination: volume.Destination,
-				ReadOnly:    volume.ReadOnly,
-				Type:        volume.Type,
-			})
+	// Pobierz czas utworzenia, jeśli źródło usługi go nie podało
+	if service.StartTime == "" {
+		createTime, err := proc.CreateTime()
+		if err == nil {
+			startTime := time.Unix(createTime/1000, 0)
+			service.StartTime = startTime.Format(time.RFC3339)
+			service.UptimeSeconds = int64(currentTime.Sub(startTime).Seconds())
 		}
+	}
 
-		// Dodaj zmienne środowiskowe
-		service.Environment = container.Environment
+	// Pobierz użycie CPU
+	cpuPercent, err := proc.CPUPercent()
+	if err == nil {
+		service.CPUPercent = cpuPercent
+	}
 
-		// Dodaj linki
-		service.Links = container.Links
+	// Pobierz użycie pamięci
+	memoryPercent, err := proc.MemoryPercent()
+	if err == nil {
+		service.MemoryPercent = memoryPercent
+	}
 
-		// Sprawdź, czy usługa jest związana z LLM
-		service.IsLLMRelated = c.isLLMRelated(container.Name, container.Image)
-		if !service.IsLLMRelated {
-			// Sprawdź zmienne środowiskowe
-			for _, env := range container.Environment {
-				if c.isLLMRelated("", env) {
-					service.IsLLMRelated = true
-					break
-				}
-			}
+	// Pobierz linię poleceń
+	cmdline, err := proc.Cmdline()
+	if err == nil {
+		if service.Extra == nil {
+			service.Extra = make(map[string]interface{})
 		}
-
-		serviceModels = append(serviceModels, service)
+		service.Extra["cmdline"] = cmdline
 	}
 
-	return serviceModels, nil
+	// Sprawdź, czy usługa jest związana z LLM
+	classifyService(service)
 }
 
 // mapServiceStatus mapuje status usługi na standardowy format
@@ -254,24 +146,3 @@ func (c *ServiceCollector) mapServiceStatus(status string) string {
 		return status
 	}
 }
-
-// isLLMRelated sprawdza, czy usługa jest związana z LLM
-func (c *ServiceCollector) isLLMRelated(name, description string) bool {
-	// Sprawdź nazwę
-	for _, pattern := range c.llmPatterns {
-		if pattern.MatchString(name) {
-			return true
-		}
-	}
-
-	// Sprawdź opis
-	if description != "" {
-		for _, pattern := range c.llmPatterns {
-			if pattern.MatchString(description) {
-				return true
-			}
-		}
-	}
-
-	return false
-}
@@ -1,12 +1,14 @@
 package collectors
 
 import (
-	"safetytwin/agent/models"
+	"context"
 	"fmt"
 	"os"
-	"os/exec"
 	"strings"
-	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"safetytwin/agent/models"
 )
 
 // CollectServicesInfo zbiera informacje o usługach systemowych
@@ -33,176 +35,15 @@ func CollectServicesInfo() ([]models.Service, error) {
 	return services, nil
 }
 
-// collectSystemdServices zbiera informacje o usługach systemd
+// collectSystemdServices zbiera informacje o usługach systemd przez D-Bus
+// (SystemdCollector), bez uruchamiania systemctl
 func collectSystemdServices() ([]models.Service, error) {
-	services := make([]models.Service, 0)
-	hostname, _ := getHostname()
-
-	// Sprawdź, czy systemd jest dostępny
-	if _, err := os.Stat("/run/systemd/system"); os.IsNotExist(err) {
-		return services, fmt.Errorf("systemd nie jest dostępny na tym systemie")
-	}
-
-	// Użyj systemctl do pobrania listy usług
-	cmd := exec.Command("systemctl", "list-units", "--type=service", "--all", "--no-pager", "--plain")
-	output, err := cmd.Output()
-	if err != nil {
-		return services, fmt.Errorf("błąd podczas wykonywania komendy systemctl: %v", err)
+	collector := NewSystemdCollector()
+	if !collector.Available() {
+		return make([]models.Service, 0), fmt.Errorf("systemd nie jest dostępny na tym systemie")
 	}
 
-	// Przetwórz wynik
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) < 3 {
-			continue
-		}
-
-		// Pobierz nazwę usługi (usuwając '.service')
-		serviceName := fields[0]
-		if !strings.HasSuffix(serviceName, ".service") {
-			continue
-		}
-		serviceName = strings.TrimSuffix(serviceName, ".service")
-
-		// Utwórz podstawowy obiekt usługi
-		service := models.Service{
-			Name:      serviceName,
-			Type:      "systemd",
-			Hostname:  hostname,
-			Timestamp: time.Now().Format(time.RFC3339),
-		}
-
-		// Pobierz status usługi
-		serviceStatus := fields[3]
-		if serviceStatus == "running" || serviceStatus == "active" {
-			service.Status = "active"
-		} else {
-			service.Status = "inactive"
-		}
-
-		// Pobierz więcej informacji o usłudze
-		cmd = exec.Command("systemctl", "show", serviceName, "--property=MainPID", "--property=ExecMainStartTimestamp")
-		detailOutput, err := cmd.Output()
-		if err == nil {
-			details := string(detailOutput)
-
-			// Pobierz PID
-			pidLine := extractProperty(details, "MainPID")
-			if pidLine != "" && pidLine != "0" {
-				var pid int32
-				if _, err := fmt.Sscanf(pidLine, "%d", &pid); err == nil && pid > 0 {
-					service.PID = pid
-				}
-			}
-
-			// Pobierz czas uruchomienia
-			startTimeLine := extractProperty(details, "ExecMainStartTimestamp")
-			if startTimeLine != "" {
-				startTime, err := parseSystemdTime(startTimeLine)
-				if err == nil {
-					service.StartTime = startTime.Format(time.RFC3339)
-					service.UptimeSeconds = int64(time.Since(startTime).Seconds())
-				}
-			}
-		}
-
-		// Sprawdź, czy usługa jest związana z LLM
-		service.IsLLMRelated = isLLMService(serviceName)
-
-		// Dodaj usługę do listy
-		services = append(services, service)
-	}
-
-	return services, nil
-}
-
-// extractProperty wyciąga wartość właściwości z wyjścia systemctl show
-func extractProperty(output, property string) string {
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		if strings.HasPrefix(line, property+"=") {
-			return strings.TrimPrefix(line, property+"=")
-		}
-	}
-	return ""
-}
-
-// parseSystemdTime parsuje czas systemd, np. "Tue 2021-05-04 12:34:56 CEST"
-func parseSystemdTime(timeStr string) (time.Time, error) {
-	formats := []string{
-		"Mon 2006-01-02 15:04:05 MST",
-		"2006-01-02 15:04:05 MST",
-		"Mon 2006-01-02 15:04:05",
-		"2006-01-02 15:04:05",
-	}
-
-	var lastErr error
-	for _, format := range formats {
-		t, err := time.Parse(format, timeStr)
-		if err == nil {
-			return t, nil
-		}
-		lastErr = err
-	}
-
-	return time.Time{}, lastErr
-}
-
-// isLLMService sprawdza, czy usługa systemd jest potencjalnie związana z LLM
-func isLLMService(serviceName string) bool {
-	serviceLower := strings.ToLower(serviceName)
-	for _, keyword := range llmKeywords {
-		if strings.Contains(serviceLower, keyword) {
-			return true
-		}
-	}
-
-	// Sprawdź, czy usługa ma proces, który jest związany z LLM
-	cmd := exec.Command("systemctl", "show", serviceName+".service", "--property=MainPID")
-	output, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-
-	pidLine := strings.TrimSpace(string(output))
-	if !strings.HasPrefix(pidLine, "MainPID=") {
-		return false
-	}
-
-	pidStr := strings.TrimPrefix(pidLine, "MainPID=")
-	if pidStr == "0" {
-		return false
-	}
-
-	var pid int32
-	if _, err := fmt.Sscanf(pidStr, "%d", &pid); err != nil {
-		return false
-	}
-
-	// Sprawdź, czy proces o danym PID jest związany z LLM
-	processes, err := process.Processes()
-	if err != nil {
-		return false
-	}
-
-	for _, proc := range processes {
-		if proc.Pid == int32(pid) {
-			name, err := proc.Name()
-			if err != nil {
-				continue
-			}
-
-			cmdline, err := proc.Cmdline()
-			if err != nil {
-				continue
-			}
-
-			return isLLMProcess(proc, name, cmdline)
-		}
-	}
-
-	return false
+	return collector.Collect(context.Background())
 }
 
 // getHostname zwraca nazwę hosta
@@ -214,45 +55,45 @@ func getHostname() (string, error) {
 	return hostname, nil
 }
 
-// FindServiceForPID znajduje usługę systemd dla danego PID
+// FindServiceForPID znajduje usługę systemd dla danego PID na podstawie jego cgroup
 func FindServiceForPID(pid int32) (string, error) {
-	cmd := exec.Command("systemctl", "status", fmt.Sprintf("%d", pid))
-	output, err := cmd.Output()
+	cgroup, err := readProcessCgroup(procRoot(), pid)
 	if err != nil {
-		// Ignorujemy błąd, ponieważ komenda zwraca kod błędu, gdy proces nie jest usługą
-		if len(output) == 0 {
-			return "", fmt.Errorf("nie znaleziono usługi dla PID %d", pid)
-		}
+		return "", fmt.Errorf("nie można odczytać cgroup procesu %d: %v", pid, err)
 	}
 
-	// Przetwórz wynik
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		if strings.Contains(line, ".service") {
-			parts := strings.Fields(line)
-			for _, part := range parts {
-				if strings.HasSuffix(part, ".service") {
-					return strings.TrimSuffix(part, ".service"), nil
-				}
-			}
-		}
+	if strings.HasSuffix(cgroup.SystemdUnit, ".service") {
+		return strings.TrimSuffix(cgroup.SystemdUnit, ".service"), nil
 	}
 
 	return "", fmt.Errorf("nie znaleziono usługi dla PID %d", pid)
 }
 
-// GetSystemdServiceStatus pobiera aktualny status usługi systemd
+// GetSystemdServiceStatus pobiera aktualny status usługi systemd (ActiveState) przez D-Bus
 func GetSystemdServiceStatus(serviceName string) (string, error) {
-	cmd := exec.Command("systemctl", "is-active", serviceName)
-	output, err := cmd.Output()
+	conn, err := dbus.ConnectSystemBus()
 	if err != nil {
-		// Ignorujemy błąd, ponieważ komenda zwraca kod błędu, gdy usługa nie jest aktywna
-		if len(output) == 0 {
-			return "inactive", nil
-		}
+		return "", fmt.Errorf("nie można połączyć się z systemd przez D-Bus: %v", err)
 	}
+	defer conn.Close()
 
-	return strings.TrimSpace(string(output)), nil
+	if !strings.Contains(serviceName, ".") {
+		serviceName += ".service"
+	}
+
+	// LoadUnit zwraca obiekt także dla jednostek nieistniejących (stan "inactive")
+	var path dbus.ObjectPath
+	if err := conn.Object(systemdDest, systemdPath).Call(systemdManagerIface+".LoadUnit", 0, serviceName).Store(&path); err != nil {
+		return "", fmt.Errorf("nie można odczytać jednostki %s: %v", serviceName, err)
+	}
+
+	state, err := conn.Object(systemdDest, path).GetProperty(systemdUnitIface + ".ActiveState")
+	if err != nil {
+		return "", fmt.Errorf("nie można odczytać stanu jednostki %s: %v", serviceName, err)
+	}
+
+	status, _ := state.Value().(string)
+	return status, nil
 }
 
 // CollectLLMRelatedServices zbiera informacje tylko o usługach związanych z LLM
@@ -270,4 +111,4 @@ func CollectLLMRelatedServices() ([]models.Service, error) {
 	}
 
 	return llmServices, nil
-}
\ No newline at end of file
+}
@@ -1,59 +1,358 @@
 package collectors
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"gitlab.com/safetytwin/safetytwin/agent/models"
 )
 
-// SystemCollector zbiera informacje o całym systemie
+// DefaultCollectorTimeout to domyślny limit czasu pojedynczego kolektora
+const DefaultCollectorTimeout = 30 * time.Second
+
+// SystemCollector zbiera informacje o całym systemie za pomocą włączonych kolektorów.
+// Kolektory działają równolegle, każdy z własnym limitem czasu.
 type SystemCollector struct {
-	hardwareCollector *HardwareCollector
-	processCollector  *ProcessCollector
-	serviceCollector  *ServiceCollector
+	mu             sync.Mutex
+	collectors     []Collector
+	defaultTimeout time.Duration
+	timeouts       map[string]time.Duration
+
+	// Kolektory, których poprzednie wywołanie jeszcze się nie zakończyło
+	inFlight map[string]bool
+
+	// Żądania natychmiastowego zbierania danych zgłaszane przez kolektory
+	triggers chan string
+}
+
+// collectorResult przechowuje wynik pojedynczego kolektora
+type collectorResult struct {
+	name     string
+	value    interface{}
+	err      error
+	duration time.Duration
+}
+
+// NewSystemCollector tworzy nowy kolektor informacji o systemie. Podane nazwy
+// wybierają kolektory z rejestru; wszystkie zarejestrowane kolektory zwraca Registered.
+// Pusta lista oznacza kolektor bez kolektorów, tak jak w SetCollectors.
+func NewSystemCollector(names ...string) *SystemCollector {
+	c := &SystemCollector{
+		collectors:     make([]Collector, 0, len(names)),
+		defaultTimeout: DefaultCollectorTimeout,
+		timeouts:       make(map[string]time.Duration),
+		inFlight:       make(map[string]bool),
+		triggers:       make(chan string, 1),
+	}
+
+	c.SetCollectors(names...)
+
+	return c
 }
 
-// NewSystemCollector tworzy nowy kolektor informacji o systemie
-func NewSystemCollector() *SystemCollector {
-	return &SystemCollector{
-		hardwareCollector: NewHardwareCollector(),
-		processCollector:  NewProcessCollector(),
-		serviceCollector:  NewServiceCollector(),
+// SetCollectors zmienia zestaw kolektorów z rejestru uruchamianych przez kolektor systemu.
+// Kolektory, które pozostają włączone, zachowują swoje instancje (i ich stan),
+// a kolektory dodane przez Add są zachowywane tylko, jeśli ich nazwa jest na liście.
+// Wyłączone kolektory implementujące io.Closer są zamykane.
+func (c *SystemCollector) SetCollectors(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing := make(map[string]Collector, len(c.collectors))
+	for _, collector := range c.collectors {
+		existing[collector.Name()] = collector
+	}
+
+	collectors := make([]Collector, 0, len(names))
+	for _, name := range names {
+		if collector, ok := existing[name]; ok {
+			collectors = append(collectors, collector)
+			continue
+		}
+
+		collector, ok := newCollector(name)
+		if !ok {
+			fmt.Printf("Ostrzeżenie: nieznany kolektor %q, pomijam\n", name)
+			continue
+		}
+		c.connectTrigger(collector)
+		collectors = append(collectors, collector)
+	}
+
+	// Zamknij kolektory, które nie są już używane
+	kept := make(map[Collector]bool, len(collectors))
+	for _, collector := range collectors {
+		kept[collector] = true
+	}
+	for _, collector := range c.collectors {
+		if closer, ok := collector.(io.Closer); ok && !kept[collector] {
+			closer.Close()
+		}
 	}
+
+	c.collectors = collectors
+}
+
+// Add dodaje do kolektora systemu instancję kolektora spoza rejestru
+func (c *SystemCollector) Add(collector Collector) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connectTrigger(collector)
+	c.collectors = append(c.collectors, collector)
 }
 
-// Collect zbiera informacje o systemie i zwraca wypełniony obiekt SystemState
-func (c *SystemCollector) Collect() (*models.SystemState, error) {
+// Triggers zwraca kanał żądań natychmiastowego zbierania danych. Wartością jest
+// przyczyna żądania; żądania zgłoszone przed odebraniem poprzedniego są łączone.
+func (c *SystemCollector) Triggers() <-chan string {
+	return c.triggers
+}
+
+// connectTrigger przekazuje kolektorowi funkcję zgłaszania żądań zbierania danych
+func (c *SystemCollector) connectTrigger(collector Collector) {
+	if triggerer, ok := collector.(Triggerer); ok {
+		triggerer.SetTrigger(c.trigger)
+	}
+}
+
+// trigger zgłasza żądanie natychmiastowego zbierania danych bez blokowania kolektora
+func (c *SystemCollector) trigger(reason string) {
+	select {
+	case c.triggers <- reason:
+	default:
+	}
+}
+
+// Collectors zwraca nazwy kolektorów uruchamianych przez ten kolektor systemu
+func (c *SystemCollector) Collectors() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := make([]string, 0, len(c.collectors))
+	for _, collector := range c.collectors {
+		names = append(names, collector.Name())
+	}
+	return names
+}
+
+// SetDefaultTimeout ustawia limit czasu dla kolektorów bez własnego limitu
+func (c *SystemCollector) SetDefaultTimeout(timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.defaultTimeout = timeout
+}
+
+// SetTimeout ustawia limit czasu dla kolektora o podanej nazwie
+func (c *SystemCollector) SetTimeout(name string, timeout time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.timeouts == nil {
+		c.timeouts = make(map[string]time.Duration)
+	}
+	c.timeouts[name] = timeout
+}
+
+// SetTimeouts zastępuje limity czasu wszystkich kolektorów z własnym limitem
+func (c *SystemCollector) SetTimeouts(timeouts map[string]time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.timeouts = make(map[string]time.Duration, len(timeouts))
+	for name, timeout := range timeouts {
+		c.timeouts[name] = timeout
+	}
+}
+
+// timeoutFor zwraca limit czasu dla kolektora o podanej nazwie
+func (c *SystemCollector) timeoutFor(name string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if timeout, ok := c.timeouts[name]; ok && timeout > 0 {
+		return timeout
+	}
+	if c.defaultTimeout > 0 {
+		return c.defaultTimeout
+	}
+	return DefaultCollectorTimeout
+}
+
+// Collect zbiera informacje o systemie i zwraca wypełniony obiekt SystemState.
+// Błąd jednego kolektora nie przerywa zbierania: stan zawiera wyniki pozostałych,
+// a SystemState.Collection opisuje błędy i czasy działania każdego kolektora.
+// Błąd jest zwracany tylko wtedy, gdy żaden kolektor nie dostarczył danych.
+func (c *SystemCollector) Collect(ctx context.Context) (*models.SystemState, error) {
 	// Utwórz nowy obiekt stanu systemu
 	systemState := models.NewSystemState()
 
-	// Zbierz informacje o sprzęcie
-	fmt.Println("Zbieranie informacji o sprzęcie...")
-	hardware, err := c.hardwareCollector.Collect()
-	if err != nil {
-		return nil, fmt.Errorf("błąd podczas zbierania informacji o sprzęcie: %v", err)
+	// Oddziel moduły uzupełniające stan od kolektorów
+	c.mu.Lock()
+	var enabled []Collector
+	var enrichers []Enricher
+	for _, collector := range c.collectors {
+		if enricher, ok := collector.(Enricher); ok {
+			enrichers = append(enrichers, enricher)
+			continue
+		}
+		enabled = append(enabled, collector)
 	}
-	systemState.Hardware = hardware
+	c.mu.Unlock()
 
-	// Zbierz informacje o procesach
-	fmt.Println("Zbieranie informacji o procesach...")
-	processes, err := c.processCollector.Collect()
-	if err != nil {
-		return nil, fmt.Errorf("błąd podczas zbierania informacji o procesach: %v", err)
+	// Uruchom równolegle wszystkie włączone kolektory
+	results := make([]collectorResult, len(enabled))
+	var wg sync.WaitGroup
+	for i, collector := range enabled {
+		wg.Add(1)
+		go func(i int, collector Collector) {
+			defer wg.Done()
+			results[i] = c.runCollector(ctx, collector)
+		}(i, collector)
 	}
-	systemState.Processes = processes
+	wg.Wait()
 
-	// Zbierz informacje o usługach
-	fmt.Println("Zbieranie informacji o usługach...")
-	services, err := c.serviceCollector.Collect()
-	if err != nil {
-		return nil, fmt.Errorf("błąd podczas zbierania informacji o usługach: %v", err)
+	// Umieść wyniki w stanie w kolejności kolektorów
+	report := &models.CollectionReport{
+		Durations: make(map[string]float64, len(results)),
+	}
+	failed := 0
+	for _, result := range results {
+		report.Durations[result.name] = result.duration.Seconds()
+		if result.err != nil {
+			if report.Errors == nil {
+				report.Errors = make(map[string]string)
+			}
+			report.Errors[result.name] = result.err.Error()
+			failed++
+			continue
+		}
+		applyResult(systemState, result.name, result.value)
 	}
-	systemState.Services = services
+
+	// Uzupełnij stan na podstawie wyników wszystkich kolektorów
+	for _, enricher := range enrichers {
+		result := c.runEnricher(ctx, enricher, systemState)
+		report.Durations[result.name] = result.duration.Seconds()
+		if result.err != nil {
+			if report.Errors == nil {
+				report.Errors = make(map[string]string)
+			}
+			report.Errors[result.name] = result.err.Error()
+		}
+	}
+	systemState.Collection = report
 
 	// Aktualizuj timestamp
 	systemState.Timestamp = time.Now().Format(time.RFC3339)
 
+	if len(results) > 0 && failed == len(results) {
+		return systemState, fmt.Errorf("żaden kolektor nie dostarczył danych (%d błędów)", failed)
+	}
+
 	return systemState, nil
 }
+
+// runCollector uruchamia kolektor z limitem czasu. Kolektor, który nie reaguje
+// na anulowanie kontekstu, jest porzucany po upływie limitu, a do czasu jego
+// zakończenia kolejne wywołania są pomijane.
+func (c *SystemCollector) runCollector(ctx context.Context, collector Collector) collectorResult {
+	name := collector.Name()
+	result := collectorResult{name: name}
+	startTime := time.Now()
+
+	if !c.acquire(name) {
+		result.err = fmt.Errorf("poprzednie zbieranie danych nadal trwa")
+		return result
+	}
+
+	timeout := c.timeoutFor(name)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan collectorResult, 1)
+	go func() {
+		defer c.release(name)
+		defer func() {
+			if r := recover(); r != nil {
+				done <- collectorResult{err: fmt.Errorf("panic w kolektorze: %v", r)}
+			}
+		}()
+
+		value, err := collector.Collect(ctx)
+		done <- collectorResult{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		result.value = r.value
+		result.err = r.err
+	case <-ctx.Done():
+		result.err = fmt.Errorf("przekroczono limit czasu %v: %v", timeout, ctx.Err())
+	}
+
+	result.duration = time.Since(startTime)
+	return result
+}
+
+// runEnricher uruchamia moduł uzupełniający stan z limitem czasu. Moduł działa
+// synchronicznie, bo modyfikuje stan, więc musi sam reagować na anulowanie kontekstu.
+func (c *SystemCollector) runEnricher(ctx context.Context, enricher Enricher, state *models.SystemState) (result collectorResult) {
+	result.name = enricher.Name()
+	startTime := time.Now()
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeoutFor(result.name))
+	defer cancel()
+
+	defer func() {
+		if r := recover(); r != nil {
+			result.err = fmt.Errorf("panic w module uzupełniającym: %v", r)
+		}
+		result.duration = time.Since(startTime)
+	}()
+
+	result.err = enricher.Enrich(ctx, state)
+	return result
+}
+
+// acquire oznacza kolektor jako uruchomiony; zwraca false, jeśli już działa
+func (c *SystemCollector) acquire(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.inFlight == nil {
+		c.inFlight = make(map[string]bool)
+	}
+	if c.inFlight[name] {
+		return false
+	}
+	c.inFlight[name] = true
+	return true
+}
+
+// release oznacza kolektor jako zakończony
+func (c *SystemCollector) release(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inFlight, name)
+}
+
+// applyResult umieszcza wynik kolektora w odpowiednim polu stanu systemu
+func applyResult(state *models.SystemState, name string, result interface{}) {
+	switch value := result.(type) {
+	case nil:
+		return
+	case *models.Hardware:
+		state.Hardware = value
+	case []models.Process:
+		state.Processes = append(state.Processes, value...)
+	case []models.Service:
+		state.Services = append(state.Services, value...)
+	default:
+		if state.Extra == nil {
+			state.Extra = make(map[string]interface{})
+		}
+		state.Extra[name] = value
+	}
+}
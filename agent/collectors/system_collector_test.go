@@ -0,0 +1,270 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+func TestSystemCollectorAppliesResults(t *testing.T) {
+	// Utwórz kolektor systemu z kolektorami testowymi
+	c := &SystemCollector{}
+	c.Add(NewCollectorFunc("hardware", func(ctx context.Context) (interface{}, error) {
+		return &models.Hardware{Hostname: "test-host"}, nil
+	}))
+	c.Add(NewCollectorFunc("processes", func(ctx context.Context) (interface{}, error) {
+		return []models.Process{{PID: 1, Name: "init"}}, nil
+	}))
+	c.Add(NewCollectorFunc("services", func(ctx context.Context) (interface{}, error) {
+		return []models.Service{{Name: "sshd", Type: "system"}}, nil
+	}))
+	c.Add(NewCollectorFunc("site", func(ctx context.Context) (interface{}, error) {
+		return map[string]string{"rack": "A1"}, nil
+	}))
+
+	state, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania stanu: %v", err)
+	}
+
+	if state.Hardware == nil || state.Hardware.Hostname != "test-host" {
+		t.Errorf("Niepoprawny sprzęt: got %+v, want hostname test-host", state.Hardware)
+	}
+	if len(state.Processes) != 1 || state.Processes[0].Name != "init" {
+		t.Errorf("Niepoprawne procesy: got %+v", state.Processes)
+	}
+	if len(state.Services) != 1 || state.Services[0].Name != "sshd" {
+		t.Errorf("Niepoprawne usługi: got %+v", state.Services)
+	}
+	if _, ok := state.Extra["site"]; !ok {
+		t.Errorf("Brak wyniku kolektora site w Extra: got %+v", state.Extra)
+	}
+}
+
+func TestRegisterDuplicatePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Oczekiwano panic przy ponownej rejestracji kolektora")
+		}
+	}()
+
+	Register("hardware", func() Collector { return nil })
+}
+
+func TestCollectorsNames(t *testing.T) {
+	c := &SystemCollector{}
+	c.Add(NewCollectorFunc("a", func(ctx context.Context) (interface{}, error) { return nil, nil }))
+	c.Add(NewCollectorFunc("b", func(ctx context.Context) (interface{}, error) { return nil, nil }))
+
+	names := c.Collectors()
+	if len(names) != 2 || names[0] != "a" || names[1] != "b" {
+		t.Errorf("Niepoprawne nazwy kolektorów: got %v, want [a b]", names)
+	}
+}
+
+func TestSetCollectorsKeepsInstances(t *testing.T) {
+	c := &SystemCollector{}
+	a := NewCollectorFunc("a", func(ctx context.Context) (interface{}, error) { return nil, nil })
+	c.Add(a)
+	c.Add(NewCollectorFunc("b", func(ctx context.Context) (interface{}, error) { return nil, nil }))
+
+	// Włącz zarejestrowany kolektor "hardware", wyłącz "b", zachowaj "a"
+	c.SetCollectors("hardware", "a", "unknown")
+
+	names := c.Collectors()
+	if len(names) != 2 || names[0] != "hardware" || names[1] != "a" {
+		t.Fatalf("Niepoprawne nazwy kolektorów: got %v, want [hardware a]", names)
+	}
+	if c.collectors[1] != a {
+		t.Error("Oczekiwano zachowania instancji kolektora a")
+	}
+}
+
+func TestSystemCollectorPartialResults(t *testing.T) {
+	// Kolektor usług zawiesza się, kolektor procesów zwraca błąd
+	block := make(chan struct{})
+	defer close(block)
+
+	c := &SystemCollector{}
+	c.SetTimeout("services", 50*time.Millisecond)
+	c.Add(NewCollectorFunc("hardware", func(ctx context.Context) (interface{}, error) {
+		return &models.Hardware{Hostname: "test-host"}, nil
+	}))
+	c.Add(NewCollectorFunc("processes", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("brak dostępu do /proc")
+	}))
+	c.Add(NewCollectorFunc("services", func(ctx context.Context) (interface{}, error) {
+		<-block // Ignoruje kontekst, jak zawieszony systemctl
+		return nil, nil
+	}))
+
+	startTime := time.Now()
+	state, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Oczekiwano częściowego stanu bez błędu, otrzymano: %v", err)
+	}
+	if elapsed := time.Since(startTime); elapsed > time.Second {
+		t.Errorf("Zbieranie trwało zbyt długo: %v", elapsed)
+	}
+
+	if state.Hardware == nil || state.Hardware.Hostname != "test-host" {
+		t.Errorf("Niepoprawny sprzęt: got %+v, want hostname test-host", state.Hardware)
+	}
+	if state.Collection == nil {
+		t.Fatal("Brak raportu zbierania danych")
+	}
+	if _, ok := state.Collection.Errors["processes"]; !ok {
+		t.Errorf("Brak błędu kolektora processes: got %v", state.Collection.Errors)
+	}
+	if _, ok := state.Collection.Errors["services"]; !ok {
+		t.Errorf("Brak błędu przekroczenia czasu kolektora services: got %v", state.Collection.Errors)
+	}
+	if _, ok := state.Collection.Errors["hardware"]; ok {
+		t.Errorf("Nieoczekiwany błąd kolektora hardware: %v", state.Collection.Errors["hardware"])
+	}
+	for _, name := range []string{"hardware", "processes", "services"} {
+		if _, ok := state.Collection.Durations[name]; !ok {
+			t.Errorf("Brak czasu działania kolektora %s", name)
+		}
+	}
+
+	// Zawieszony kolektor nie jest uruchamiany ponownie, dopóki się nie zakończy
+	state, _ = c.Collect(context.Background())
+	if msg := state.Collection.Errors["services"]; msg != "poprzednie zbieranie danych nadal trwa" {
+		t.Errorf("Niepoprawny błąd zawieszonego kolektora: got %q", msg)
+	}
+}
+
+func TestSystemCollectorAllFailed(t *testing.T) {
+	c := &SystemCollector{}
+	c.Add(NewCollectorFunc("hardware", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("błąd")
+	}))
+	c.Add(NewCollectorFunc("panicking", func(ctx context.Context) (interface{}, error) {
+		panic("nieoczekiwany stan")
+	}))
+
+	state, err := c.Collect(context.Background())
+	if err == nil {
+		t.Fatal("Oczekiwano błędu, gdy żaden kolektor nie dostarczył danych")
+	}
+	if state == nil || len(state.Collection.Errors) != 2 {
+		t.Errorf("Oczekiwano raportu z dwoma błędami, otrzymano: %+v", state)
+	}
+}
+
+// closingCollector to kolektor testowy zgłaszający żądania zbierania i zamknięcie
+type closingCollector struct {
+	name    string
+	trigger func(reason string)
+	closed  bool
+}
+
+func (c *closingCollector) Name() string { return c.name }
+
+func (c *closingCollector) Collect(ctx context.Context) (interface{}, error) { return nil, nil }
+
+func (c *closingCollector) SetTrigger(trigger func(reason string)) { c.trigger = trigger }
+
+func (c *closingCollector) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestSystemCollectorTriggersAndClose(t *testing.T) {
+	c := NewSystemCollector("hardware")
+	watcher := &closingCollector{name: "watcher"}
+	c.Add(watcher)
+
+	if watcher.trigger == nil {
+		t.Fatal("Kolektor nie otrzymał funkcji zgłaszania żądań")
+	}
+
+	// Żądania zgłoszone przed odebraniem poprzedniego są łączone
+	watcher.trigger("pierwsze")
+	watcher.trigger("drugie")
+	select {
+	case reason := <-c.Triggers():
+		if reason != "pierwsze" {
+			t.Errorf("Niepoprawna przyczyna: got %v, want %v", reason, "pierwsze")
+		}
+	default:
+		t.Fatal("Brak żądania zbierania danych")
+	}
+	select {
+	case reason := <-c.Triggers():
+		t.Errorf("Nieoczekiwane drugie żądanie: %v", reason)
+	default:
+	}
+
+	// Wyłączony kolektor jest zamykany
+	c.SetCollectors("hardware")
+	if !watcher.closed {
+		t.Error("Wyłączony kolektor nie został zamknięty")
+	}
+}
+
+// countingEnricher to moduł testowy zapisujący liczbę usług w stanie
+type countingEnricher struct {
+	err error
+}
+
+func (e *countingEnricher) Name() string { return "counter" }
+
+func (e *countingEnricher) Enrich(ctx context.Context, state *models.SystemState) error {
+	if e.err != nil {
+		return e.err
+	}
+	if state.Extra == nil {
+		state.Extra = make(map[string]interface{})
+	}
+	state.Extra["counter"] = len(state.Services)
+	return nil
+}
+
+func TestSystemCollectorEnrichers(t *testing.T) {
+	c := &SystemCollector{}
+	c.Add(NewCollectorFunc("services", func(ctx context.Context) (interface{}, error) {
+		return []models.Service{{Name: "sshd"}, {Name: "cron"}}, nil
+	}))
+	enricher := &countingEnricher{}
+	c.Add(&enricherCollector{Enricher: enricher})
+
+	// Moduł uzupełniający widzi wyniki wszystkich kolektorów
+	state, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania stanu: %v", err)
+	}
+	if state.Extra["counter"] != 2 {
+		t.Errorf("Niepoprawny wynik modułu uzupełniającego: got %v, want %v", state.Extra["counter"], 2)
+	}
+	if _, ok := state.Collection.Durations["counter"]; !ok {
+		t.Error("Brak czasu działania modułu uzupełniającego")
+	}
+
+	// Błąd modułu trafia do raportu, ale nie przerywa zbierania
+	enricher.err = errors.New("błąd")
+	state, err = c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania stanu: %v", err)
+	}
+	if state.Collection.Errors["counter"] != "błąd" || len(state.Services) != 2 {
+		t.Errorf("Niepoprawny raport błędu modułu: got %+v", state.Collection)
+	}
+}
+
+func TestNewSystemCollectorWithoutNames(t *testing.T) {
+	// Konfiguracja wyłączająca wszystkie kolektory nie może uruchomić wszystkich
+	c := NewSystemCollector()
+	if len(c.collectors) != 0 {
+		t.Errorf("Niepoprawna liczba kolektorów: got %d, want %d", len(c.collectors), 0)
+	}
+
+	c = NewSystemCollector("hardware")
+	if len(c.collectors) != 1 {
+		t.Errorf("Niepoprawna liczba kolektorów: got %d, want %d", len(c.collectors), 1)
+	}
+}
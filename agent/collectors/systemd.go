@@ -0,0 +1,472 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Nazwy obiektów i interfejsów systemd na magistrali D-Bus
+const (
+	systemdDest         = "org.freedesktop.systemd1"
+	systemdPath         = dbus.ObjectPath("/org/freedesktop/systemd1")
+	systemdManagerIface = "org.freedesktop.systemd1.Manager"
+	systemdUnitIface    = "org.freedesktop.systemd1.Unit"
+	systemdServiceIface = "org.freedesktop.systemd1.Service"
+)
+
+// systemdDependencies to właściwości jednostki opisujące jej zależności
+var systemdDependencies = []string{
+	"Requires", "Requisite", "Wants", "BindsTo", "PartOf",
+	"Conflicts", "Before", "After",
+}
+
+// systemdExecProperties to polecenia usługi zapisywane w definicji jednostki
+var systemdExecProperties = []string{
+	"ExecStartPre", "ExecStart", "ExecStartPost", "ExecReload",
+	"ExecStop", "ExecStopPost", "ExecCondition",
+}
+
+// systemdRlimits to limity zasobów procesu (setrlimit); miękki limit ma przyrostek "Soft"
+var systemdRlimits = []string{
+	"LimitNOFILE", "LimitNPROC", "LimitMEMLOCK", "LimitCORE", "LimitAS", "LimitSTACK",
+}
+
+// systemdCgroupLimits to limity cgroup zapisywane tylko, gdy są ustawione
+var systemdCgroupLimits = []string{"MemoryMax", "MemoryHigh", "TasksMax"}
+
+// systemdInfinity to wartość, którą systemd oznacza brak limitu
+const systemdInfinity = ^uint64(0)
+
+// systemdUnitStatus to wpis zwracany przez metodę ListUnits menedżera systemd
+type systemdUnitStatus struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+// propertyRequest opisuje zapytanie o wszystkie właściwości interfejsu obiektu
+type propertyRequest struct {
+	path  dbus.ObjectPath
+	iface string
+}
+
+// systemdBus to połączenie z systemd; interfejs pozwala testować kolektor bez D-Bus
+type systemdBus interface {
+	// ListUnits zwraca wszystkie jednostki załadowane przez systemd
+	ListUnits(ctx context.Context) ([]systemdUnitStatus, error)
+	// GetAllProperties pobiera właściwości wielu obiektów; wynik ma kolejność zapytań,
+	// a obiekty, których nie udało się odczytać, mają pustą mapę
+	GetAllProperties(ctx context.Context, requests []propertyRequest) ([]map[string]interface{}, error)
+	Close() error
+}
+
+// SystemdCollector zbiera informacje o usługach systemd przez D-Bus, bez uruchamiania
+// systemctl. Po pobraniu listy jednostek zapytania o właściwości wszystkich usług są
+// wysyłane potokowo, więc całość zajmuje jeden przebieg zamiast wywołania na jednostkę.
+type SystemdCollector struct {
+	connect          func() (systemdBus, error)
+	serviceCollector *ServiceCollector
+}
+
+// NewSystemdCollector tworzy nowy kolektor usług systemd
+func NewSystemdCollector() *SystemdCollector {
+	return &SystemdCollector{
+		connect:          connectSystemdBus,
+		serviceCollector: NewServiceCollector(),
+	}
+}
+
+// Available sprawdza, czy system jest uruchomiony pod kontrolą systemd
+func (c *SystemdCollector) Available() bool {
+	_, err := os.Stat("/run/systemd/system")
+	return err == nil
+}
+
+// Collect zbiera informacje o usługach systemd i zwraca slice wypełnionych obiektów Service
+func (c *SystemdCollector) Collect(ctx context.Context) ([]models.Service, error) {
+	bus, err := c.connect()
+	if err != nil {
+		return nil, fmt.Errorf("nie można połączyć się z systemd przez D-Bus: %v", err)
+	}
+	defer bus.Close()
+
+	// Pobierz listę jednostek
+	units, err := bus.ListUnits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("błąd podczas pobierania listy jednostek systemd: %v", err)
+	}
+
+	// Wybierz usługi i przygotuj zapytania o ich właściwości
+	serviceUnits := make([]systemdUnitStatus, 0, len(units))
+	requests := make([]propertyRequest, 0, 2*len(units))
+	for _, unit := range units {
+		if !strings.HasSuffix(unit.Name, ".service") {
+			continue
+		}
+		serviceUnits = append(serviceUnits, unit)
+		requests = append(requests,
+			propertyRequest{path: unit.Path, iface: systemdUnitIface},
+			propertyRequest{path: unit.Path, iface: systemdServiceIface},
+		)
+	}
+
+	properties, err := bus.GetAllProperties(ctx, requests)
+	if err != nil {
+		return nil, fmt.Errorf("błąd podczas pobierania właściwości jednostek systemd: %v", err)
+	}
+
+	// Pobierz nazwę hosta
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	// Aktualny czas
+	currentTime := time.Now()
+	timestamp := currentTime.Format(time.RFC3339)
+
+	services := make([]models.Service, 0, len(serviceUnits))
+	for i, unit := range serviceUnits {
+		systemdUnit := newSystemdUnit(unit, properties[2*i], properties[2*i+1])
+
+		// Utwórz model usługi
+		service := models.Service{
+			Name:      strings.TrimSuffix(unit.Name, ".service"),
+			Type:      "systemd",
+			ID:        unit.Name,
+			Hostname:  hostname,
+			Timestamp: timestamp,
+			Status:    unit.ActiveState,
+			PID:       systemdUnit.MainPID,
+			Systemd:   systemdUnit,
+		}
+
+		if systemdUnit.ExecMainStartTimestamp != "" && unit.ActiveState == "active" {
+			if startTime, err := time.Parse(time.RFC3339, systemdUnit.ExecMainStartTimestamp); err == nil {
+				service.StartTime = systemdUnit.ExecMainStartTimestamp
+				service.UptimeSeconds = int64(currentTime.Sub(startTime).Seconds())
+			}
+		}
+
+		// Pobierz informacje o głównym procesie usługi
+		if service.PID > 0 && c.serviceCollector != nil {
+			c.serviceCollector.addProcessInfo(&service, currentTime)
+		} else {
+			classifyService(&service)
+		}
+
+		services = append(services, service)
+	}
+
+	// Kolejność ListUnits nie jest określona, więc sortuj dla powtarzalności
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].ID < services[j].ID
+	})
+
+	return services, nil
+}
+
+// newSystemdUnit tworzy model jednostki z wpisu ListUnits i właściwości
+// interfejsów Unit i Service
+func newSystemdUnit(unit systemdUnitStatus, unitProps, serviceProps map[string]interface{}) *models.SystemdUnit {
+	systemdUnit := &models.SystemdUnit{
+		Unit:         unit.Name,
+		Description:  unit.Description,
+		LoadState:    unit.LoadState,
+		ActiveState:  unit.ActiveState,
+		SubState:     unit.SubState,
+		MainPID:      int32(propUint32(serviceProps, "MainPID")),
+		ControlGroup: propString(serviceProps, "ControlGroup"),
+		NRestarts:    propUint32(serviceProps, "NRestarts"),
+	}
+
+	// Znaczniki czasu systemd są podawane w mikrosekundach od epoki
+	if usec := propUint64(serviceProps, "ExecMainStartTimestamp"); usec > 0 {
+		systemdUnit.ExecMainStartTimestamp = time.UnixMicro(int64(usec)).Format(time.RFC3339)
+	}
+
+	for _, dependency := range systemdDependencies {
+		if units := propStrings(unitProps, dependency); len(units) > 0 {
+			if systemdUnit.Dependencies == nil {
+				systemdUnit.Dependencies = make(map[string][]string)
+			}
+			systemdUnit.Dependencies[dependency] = units
+		}
+	}
+
+	addUnitDefinition(systemdUnit, unitProps, serviceProps)
+
+	return systemdUnit
+}
+
+// addUnitDefinition uzupełnia model jednostki o jej definicję: pliki jednostki,
+// polecenia Exec*, użytkownika, środowisko, limity zasobów i aktywację
+func addUnitDefinition(systemdUnit *models.SystemdUnit, unitProps, serviceProps map[string]interface{}) {
+	systemdUnit.FragmentPath = propString(unitProps, "FragmentPath")
+	systemdUnit.DropInPaths = propStrings(unitProps, "DropInPaths")
+	systemdUnit.WantedBy = propStrings(unitProps, "WantedBy")
+	systemdUnit.RequiredBy = propStrings(unitProps, "RequiredBy")
+	systemdUnit.TriggeredBy = propStrings(unitProps, "TriggeredBy")
+
+	systemdUnit.ServiceType = propString(serviceProps, "Type")
+	systemdUnit.Restart = propString(serviceProps, "Restart")
+	systemdUnit.User = propString(serviceProps, "User")
+	systemdUnit.Group = propString(serviceProps, "Group")
+	systemdUnit.WorkingDirectory = propString(serviceProps, "WorkingDirectory")
+	systemdUnit.Environment = propStrings(serviceProps, "Environment")
+
+	// EnvironmentFiles ma typ a(sb): ścieżka i flaga "brak pliku nie jest błędem"
+	for _, entry := range propStructs(serviceProps, "EnvironmentFiles") {
+		if len(entry) < 2 {
+			continue
+		}
+		path, _ := entry[0].(string)
+		optional, _ := entry[1].(bool)
+		if optional {
+			path = "-" + path
+		}
+		systemdUnit.EnvironmentFiles = append(systemdUnit.EnvironmentFiles, path)
+	}
+
+	for _, property := range systemdExecProperties {
+		if lines := execLines(propStructs(serviceProps, property)); len(lines) > 0 {
+			if systemdUnit.Exec == nil {
+				systemdUnit.Exec = make(map[string][]string)
+			}
+			systemdUnit.Exec[property] = lines
+		}
+	}
+
+	systemdUnit.Limits = unitLimits(serviceProps)
+}
+
+// execLines zamienia właściwość Exec* o typie a(sasbttttuii) na linie w formacie pliku jednostki
+func execLines(commands [][]interface{}) []string {
+	var lines []string
+	for _, command := range commands {
+		if len(command) < 3 {
+			continue
+		}
+		path, _ := command[0].(string)
+		argv, _ := command[1].([]string)
+		ignoreFailure, _ := command[2].(bool)
+		if path == "" {
+			continue
+		}
+
+		// Gdy argv[0] różni się od ścieżki, systemd wymaga formy "@ścieżka argv0"
+		words := []string{quoteExecArg(path)}
+		if len(argv) > 0 && argv[0] != path {
+			words = []string{"@" + quoteExecArg(path), quoteExecArg(argv[0])}
+		}
+		if len(argv) > 1 {
+			for _, arg := range argv[1:] {
+				words = append(words, quoteExecArg(arg))
+			}
+		}
+
+		line := strings.Join(words, " ")
+		if ignoreFailure {
+			line = "-" + line
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// quoteExecArg zapisuje argument polecenia według składni pliku jednostki: "%" i "$"
+// są podwajane, aby systemd nie rozwinął specyfikatorów ani zmiennych, samodzielny
+// średnik jest poprzedzany ukośnikiem, a argumenty z białymi znakami, cudzysłowami
+// lub ukośnikami są ujmowane w cudzysłów z sekwencjami ucieczki języka C.
+func quoteExecArg(arg string) string {
+	arg = strings.ReplaceAll(arg, "%", "%%")
+	arg = strings.ReplaceAll(arg, "$", "$$")
+
+	if arg == ";" {
+		return `\;`
+	}
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\r\"'\\") && !containsControl(arg) {
+		return arg
+	}
+
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range arg {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r == '\r':
+			b.WriteString(`\r`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// containsControl sprawdza, czy tekst zawiera znaki sterujące
+func containsControl(s string) bool {
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// unitLimits zwraca limity zasobów jednostki w formacie pliku jednostki.
+// Limity cgroup są pomijane, gdy nie są ustawione (wartość nieskończona).
+func unitLimits(serviceProps map[string]interface{}) map[string]string {
+	limits := make(map[string]string)
+
+	for _, name := range systemdRlimits {
+		hard, ok := serviceProps[name].(uint64)
+		if !ok {
+			continue
+		}
+		soft, ok := serviceProps[name+"Soft"].(uint64)
+		if !ok || soft == hard {
+			limits[name] = formatLimit(hard)
+		} else {
+			limits[name] = formatLimit(soft) + ":" + formatLimit(hard)
+		}
+	}
+
+	for _, name := range systemdCgroupLimits {
+		if value, ok := serviceProps[name].(uint64); ok && value != systemdInfinity {
+			limits[name] = strconv.FormatUint(value, 10)
+		}
+	}
+
+	// Limit CPU jest podawany w mikrosekundach na sekundę
+	if quota, ok := serviceProps["CPUQuotaPerSecUSec"].(uint64); ok && quota != systemdInfinity {
+		limits["CPUQuota"] = strconv.FormatUint(quota/10000, 10) + "%"
+	}
+
+	if len(limits) == 0 {
+		return nil
+	}
+	return limits
+}
+
+// formatLimit zapisuje wartość limitu tak jak w pliku jednostki
+func formatLimit(value uint64) string {
+	if value == systemdInfinity {
+		return "infinity"
+	}
+	return strconv.FormatUint(value, 10)
+}
+
+// propStructs zwraca właściwość będącą tablicą struktur D-Bus
+func propStructs(props map[string]interface{}, key string) [][]interface{} {
+	value, _ := props[key].([][]interface{})
+	return value
+}
+
+// propString zwraca właściwość tekstową lub pusty tekst
+func propString(props map[string]interface{}, key string) string {
+	value, _ := props[key].(string)
+	return value
+}
+
+// propUint32 zwraca właściwość liczbową uint32 lub zero
+func propUint32(props map[string]interface{}, key string) uint32 {
+	value, _ := props[key].(uint32)
+	return value
+}
+
+// propUint64 zwraca właściwość liczbową uint64 lub zero
+func propUint64(props map[string]interface{}, key string) uint64 {
+	value, _ := props[key].(uint64)
+	return value
+}
+
+// propStrings zwraca właściwość będącą listą tekstów
+func propStrings(props map[string]interface{}, key string) []string {
+	value, _ := props[key].([]string)
+	return value
+}
+
+// dbusSystemdBus to połączenie z systemd przez systemową magistralę D-Bus
+type dbusSystemdBus struct {
+	conn *dbus.Conn
+}
+
+// connectSystemdBus łączy się z systemową magistralą D-Bus
+func connectSystemdBus() (systemdBus, error) {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, err
+	}
+	return &dbusSystemdBus{conn: conn}, nil
+}
+
+// ListUnits wywołuje metodę ListUnits menedżera systemd
+func (b *dbusSystemdBus) ListUnits(ctx context.Context) ([]systemdUnitStatus, error) {
+	var units []systemdUnitStatus
+	err := b.conn.Object(systemdDest, systemdPath).
+		CallWithContext(ctx, systemdManagerIface+".ListUnits", 0).
+		Store(&units)
+	return units, err
+}
+
+// GetAllProperties wysyła wszystkie zapytania Properties.GetAll naraz i dopiero
+// potem czeka na odpowiedzi
+func (b *dbusSystemdBus) GetAllProperties(ctx context.Context, requests []propertyRequest) ([]map[string]interface{}, error) {
+	calls := make([]*dbus.Call, len(requests))
+	for i, request := range requests {
+		calls[i] = b.conn.Object(systemdDest, request.path).
+			GoWithContext(ctx, "org.freedesktop.DBus.Properties.GetAll", 0, nil, request.iface)
+	}
+
+	results := make([]map[string]interface{}, len(requests))
+	for i, call := range calls {
+		select {
+		case <-call.Done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		results[i] = make(map[string]interface{})
+
+		// Jednostka mogła zniknąć między ListUnits a zapytaniem o właściwości
+		var props map[string]dbus.Variant
+		if call.Err != nil || call.Store(&props) != nil {
+			continue
+		}
+		for key, variant := range props {
+			results[i][key] = variant.Value()
+		}
+	}
+
+	return results, nil
+}
+
+// Close zamyka połączenie z magistralą
+func (b *dbusSystemdBus) Close() error {
+	return b.conn.Close()
+}
@@ -0,0 +1,226 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// fakeSystemdBus to magistrala testowa z ustalonymi jednostkami i właściwościami
+type fakeSystemdBus struct {
+	units      []systemdUnitStatus
+	properties map[propertyRequest]map[string]interface{}
+	listErr    error
+	requests   []propertyRequest
+	closed     bool
+}
+
+func (b *fakeSystemdBus) ListUnits(ctx context.Context) ([]systemdUnitStatus, error) {
+	return b.units, b.listErr
+}
+
+func (b *fakeSystemdBus) GetAllProperties(ctx context.Context, requests []propertyRequest) ([]map[string]interface{}, error) {
+	b.requests = append(b.requests, requests...)
+	results := make([]map[string]interface{}, len(requests))
+	for i, request := range requests {
+		results[i] = b.properties[request]
+		if results[i] == nil {
+			results[i] = make(map[string]interface{})
+		}
+	}
+	return results, nil
+}
+
+func (b *fakeSystemdBus) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestSystemdCollectorCollect(t *testing.T) {
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	sshdPath := dbus.ObjectPath("/org/freedesktop/systemd1/unit/sshd_2eservice")
+	ollamaPath := dbus.ObjectPath("/org/freedesktop/systemd1/unit/ollama_2eservice")
+
+	bus := &fakeSystemdBus{
+		units: []systemdUnitStatus{
+			{Name: "sshd.service", Description: "OpenSSH Daemon", LoadState: "loaded", ActiveState: "active", SubState: "running", Path: sshdPath},
+			{Name: "dbus.socket", LoadState: "loaded", ActiveState: "active", SubState: "running", Path: "/org/freedesktop/systemd1/unit/dbus_2esocket"},
+			{Name: "ollama.service", Description: "Ollama Service", LoadState: "loaded", ActiveState: "failed", SubState: "failed", Path: ollamaPath},
+		},
+		properties: map[propertyRequest]map[string]interface{}{
+			{path: sshdPath, iface: systemdUnitIface}: {
+				"Requires": []string{"system.slice"},
+				"After":    []string{"network.target", "sshd-keygen.target"},
+				"Wants":    []string{},
+			},
+			{path: sshdPath, iface: systemdServiceIface}: {
+				"MainPID":                uint32(4194303),
+				"ControlGroup":           "/system.slice/sshd.service",
+				"NRestarts":              uint32(2),
+				"ExecMainStartTimestamp": uint64(started.UnixMicro()),
+			},
+		},
+	}
+
+	c := &SystemdCollector{
+		connect:          func() (systemdBus, error) { return bus, nil },
+		serviceCollector: NewServiceCollector(),
+	}
+
+	services, err := c.Collect(context.Background())
+	if err != nil {
+		t.Fatalf("Błąd zbierania usług systemd: %v", err)
+	}
+
+	if !bus.closed {
+		t.Error("Połączenie z magistralą nie zostało zamknięte")
+	}
+	if len(bus.requests) != 4 {
+		t.Errorf("Niepoprawna liczba zapytań o właściwości: got %v, want %v", len(bus.requests), 4)
+	}
+	if len(services) != 2 {
+		t.Fatalf("Niepoprawna liczba usług: got %v, want %v", len(services), 2)
+	}
+
+	// Usługi są posortowane według nazwy jednostki
+	ollama, sshd := services[0], services[1]
+
+	if sshd.Name != "sshd" || sshd.Type != "systemd" || sshd.ID != "sshd.service" {
+		t.Errorf("Niepoprawna identyfikacja usługi: got %v/%v/%v", sshd.Name, sshd.Type, sshd.ID)
+	}
+	if sshd.Status != "active" || sshd.PID != 4194303 {
+		t.Errorf("Niepoprawny stan usługi: got %v (PID %v)", sshd.Status, sshd.PID)
+	}
+	if sshd.StartTime != started.Local().Format(time.RFC3339) {
+		t.Errorf("Niepoprawny czas uruchomienia: got %v, want %v", sshd.StartTime, started.Local().Format(time.RFC3339))
+	}
+
+	unit := sshd.Systemd
+	if unit == nil {
+		t.Fatal("Brak informacji o jednostce systemd")
+	}
+	if unit.LoadState != "loaded" || unit.ActiveState != "active" || unit.SubState != "running" {
+		t.Errorf("Niepoprawne stany jednostki: got %v/%v/%v", unit.LoadState, unit.ActiveState, unit.SubState)
+	}
+	if unit.ControlGroup != "/system.slice/sshd.service" || unit.NRestarts != 2 {
+		t.Errorf("Niepoprawne właściwości usługi: got %v, %v restartów", unit.ControlGroup, unit.NRestarts)
+	}
+	if len(unit.Dependencies["After"]) != 2 || len(unit.Dependencies["Requires"]) != 1 {
+		t.Errorf("Niepoprawne zależności: got %v", unit.Dependencies)
+	}
+	if _, ok := unit.Dependencies["Wants"]; ok {
+		t.Errorf("Puste zależności nie powinny być zapisywane: got %v", unit.Dependencies)
+	}
+
+	if ollama.Status != "failed" || ollama.PID != 0 || ollama.Systemd.Dependencies != nil {
+		t.Errorf("Niepoprawna usługa bez właściwości: got %+v", ollama)
+	}
+}
+
+func TestSystemdCollectorListError(t *testing.T) {
+	bus := &fakeSystemdBus{listErr: errors.New("access denied")}
+	c := &SystemdCollector{
+		connect: func() (systemdBus, error) { return bus, nil },
+	}
+
+	if _, err := c.Collect(context.Background()); err == nil {
+		t.Error("Oczekiwano błędu, gdy systemd nie zwraca listy jednostek")
+	}
+	if !bus.closed {
+		t.Error("Połączenie z magistralą nie zostało zamknięte po błędzie")
+	}
+}
+
+func TestSystemdUnitDefinition(t *testing.T) {
+	unitProps := map[string]interface{}{
+		"FragmentPath": "/lib/systemd/system/ollama.service",
+		"DropInPaths":  []string{"/etc/systemd/system/ollama.service.d/override.conf"},
+		"WantedBy":     []string{"multi-user.target"},
+		"TriggeredBy":  []string{"ollama.socket"},
+	}
+	serviceProps := map[string]interface{}{
+		"Type":             "simple",
+		"Restart":          "always",
+		"User":             "ollama",
+		"Group":            "ollama",
+		"WorkingDirectory": "/var/lib/ollama",
+		"Environment":      []string{"OLLAMA_HOST=0.0.0.0"},
+		"EnvironmentFiles": [][]interface{}{{"/etc/default/ollama", true}},
+		"ExecStart": [][]interface{}{
+			{"/usr/bin/ollama", []string{"/usr/bin/ollama", "serve", "--name", "my model"}, false,
+				uint64(0), uint64(0), uint64(0), uint64(0), uint32(0), int32(0), int32(0)},
+		},
+		"ExecStartPre": [][]interface{}{
+			{"/bin/mkdir", []string{"/bin/mkdir", "-p", "/var/lib/ollama"}, true,
+				uint64(0), uint64(0), uint64(0), uint64(0), uint32(0), int32(0), int32(0)},
+		},
+		"LimitNOFILE":        uint64(524288),
+		"LimitNOFILESoft":    uint64(1024),
+		"LimitCORE":          systemdInfinity,
+		"LimitCORESoft":      systemdInfinity,
+		"MemoryMax":          uint64(8 << 30),
+		"TasksMax":           systemdInfinity,
+		"CPUQuotaPerSecUSec": uint64(1500000),
+	}
+
+	unit := newSystemdUnit(systemdUnitStatus{Name: "ollama.service"}, unitProps, serviceProps)
+
+	if unit.FragmentPath != "/lib/systemd/system/ollama.service" || len(unit.DropInPaths) != 1 {
+		t.Errorf("Niepoprawne pliki jednostki: got %v, %v", unit.FragmentPath, unit.DropInPaths)
+	}
+	if unit.ServiceType != "simple" || unit.Restart != "always" || unit.User != "ollama" || unit.Group != "ollama" {
+		t.Errorf("Niepoprawne ustawienia usługi: got %+v", unit)
+	}
+	if unit.WorkingDirectory != "/var/lib/ollama" || len(unit.Environment) != 1 {
+		t.Errorf("Niepoprawne środowisko: got %v, %v", unit.WorkingDirectory, unit.Environment)
+	}
+	if len(unit.EnvironmentFiles) != 1 || unit.EnvironmentFiles[0] != "-/etc/default/ollama" {
+		t.Errorf("Niepoprawne pliki środowiska: got %v", unit.EnvironmentFiles)
+	}
+
+	wantExec := map[string]string{
+		"ExecStart":    `/usr/bin/ollama serve --name "my model"`,
+		"ExecStartPre": "-/bin/mkdir -p /var/lib/ollama",
+	}
+	for property, want := range wantExec {
+		if lines := unit.Exec[property]; len(lines) != 1 || lines[0] != want {
+			t.Errorf("Niepoprawne %s: got %v, want %v", property, lines, want)
+		}
+	}
+
+	// Specyfikatory, zmienne, cudzysłowy i własny argv[0] muszą dać się odczytać przez systemd
+	lines := execLines([][]interface{}{
+		{"/usr/bin/python3", []string{"vllm-worker", "-c", `print("100%")`, "--env=$HOME", ";", `C:\models`}, true},
+		{"/bin/true", []string{}, false},
+	})
+	wantLines := []string{
+		`-@/usr/bin/python3 vllm-worker -c "print(\"100%%\")" --env=$$HOME \; "C:\\models"`,
+		"/bin/true",
+	}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("Niepoprawne linie poleceń: got %q, want %q", lines, wantLines)
+	}
+
+	wantLimits := map[string]string{
+		"LimitNOFILE": "1024:524288",
+		"LimitCORE":   "infinity",
+		"MemoryMax":   "8589934592",
+		"CPUQuota":    "150%",
+	}
+	if len(unit.Limits) != len(wantLimits) {
+		t.Errorf("Niepoprawne limity: got %v, want %v", unit.Limits, wantLimits)
+	}
+	for name, want := range wantLimits {
+		if unit.Limits[name] != want {
+			t.Errorf("Niepoprawny limit %s: got %v, want %v", name, unit.Limits[name], want)
+		}
+	}
+
+	if len(unit.WantedBy) != 1 || len(unit.TriggeredBy) != 1 || unit.TriggeredBy[0] != "ollama.socket" {
+		t.Errorf("Niepoprawna aktywacja: got %v, %v", unit.WantedBy, unit.TriggeredBy)
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/diff"
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+	"gitlab.com/safetytwin/safetytwin/agent/utils"
+)
+
+// Kody wyjścia polecenia diff (jak w diff(1))
+const (
+	exitNoChanges = 0
+	exitChanges   = 1
+	exitTrouble   = 2
+)
+
+// changeLabels zawiera czytelne opisy rodzajów zmian
+var changeLabels = map[diff.ChangeKind]string{
+	diff.HostnameChanged:         "zmieniono nazwę hosta",
+	diff.KernelChanged:           "zmieniono jądro",
+	diff.PlatformChanged:         "zmieniono platformę",
+	diff.ProcessStarted:          "uruchomiono proces",
+	diff.ProcessExited:           "zakończono proces",
+	diff.ProcessRestarted:        "zrestartowano proces",
+	diff.ServiceAdded:            "dodano usługę",
+	diff.ServiceRemoved:          "usunięto usługę",
+	diff.ServiceStatusChanged:    "zmieniono status usługi",
+	diff.ServiceRestarted:        "zrestartowano usługę",
+	diff.ContainerImageChanged:   "zmieniono obraz kontenera",
+	diff.ContainerRecreated:      "odtworzono kontener",
+	diff.MountAdded:              "zamontowano",
+	diff.MountRemoved:            "odmontowano",
+	diff.MountChanged:            "zmieniono montowanie",
+	diff.InterfaceAdded:          "dodano interfejs",
+	diff.InterfaceRemoved:        "usunięto interfejs",
+	diff.InterfaceAddressChanged: "zmieniono adresy interfejsu",
+	diff.InterfaceMACChanged:     "zmieniono adres MAC interfejsu",
+	diff.GPUAdded:                "dodano GPU",
+	diff.GPURemoved:              "usunięto GPU",
+	diff.ModelAdded:              "dodano plik modelu",
+	diff.ModelRemoved:            "usunięto plik modelu",
+	diff.ModelChanged:            "zmieniono zawartość pliku modelu",
+}
+
+// runInspect wyświetla podsumowanie zapisanego stanu systemu
+func runInspect(args []string) {
+	flags := flag.NewFlagSet("inspect", flag.ExitOnError)
+	configPath := flags.String("config", "", "Ścieżka do pliku konfiguracyjnego (katalog stanów)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Użycie: safetytwin-agent inspect [flagi] <stan.json|latest|previous>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(exitTrouble)
+	}
+
+	config, err := loadConfig(resolveConfigPath(*configPath), nil)
+	if err != nil {
+		fmt.Printf("Błąd wczytywania konfiguracji: %v\n", err)
+		os.Exit(1)
+	}
+
+	state, path, err := loadState(flags.Arg(0), config.StateDir)
+	if err != nil {
+		fmt.Printf("Błąd: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Plik stanu: %s\n", path)
+	fmt.Printf("Czas zebrania: %s\n\n", state.Timestamp)
+	printStateSummary(os.Stdout, state)
+}
+
+// runDiff wyświetla zmiany między dwoma zapisanymi stanami systemu.
+// Kod wyjścia: 0 - brak zmian, 1 - są zmiany, 2 - błąd.
+func runDiff(args []string) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	configPath := flags.String("config", "", "Ścieżka do pliku konfiguracyjnego (katalog stanów)")
+	asJSON := flags.Bool("json", false, "Wyświetl zmiany w formacie JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Użycie: safetytwin-agent diff [flagi] <stary.json> <nowy.json>")
+		fmt.Fprintln(os.Stderr, "Nazwy latest i previous oznaczają dwa najnowsze stany w katalogu stanów.")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(exitTrouble)
+	}
+
+	config, err := loadConfig(resolveConfigPath(*configPath), nil)
+	if err != nil {
+		fmt.Printf("Błąd wczytywania konfiguracji: %v\n", err)
+		os.Exit(exitTrouble)
+	}
+
+	oldState, oldPath, err := loadState(flags.Arg(0), config.StateDir)
+	if err != nil {
+		fmt.Printf("Błąd: %v\n", err)
+		os.Exit(exitTrouble)
+	}
+	newState, newPath, err := loadState(flags.Arg(1), config.StateDir)
+	if err != nil {
+		fmt.Printf("Błąd: %v\n", err)
+		os.Exit(exitTrouble)
+	}
+
+	changes := diff.Compare(oldState, newState)
+
+	if *asJSON {
+		jsonData, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			fmt.Printf("Błąd podczas serializacji do JSON: %v\n", err)
+			os.Exit(exitTrouble)
+		}
+		fmt.Println(string(jsonData))
+	} else {
+		fmt.Printf("--- %s (%s)\n", oldPath, oldState.Timestamp)
+		fmt.Printf("+++ %s (%s)\n\n", newPath, newState.Timestamp)
+		printChanges(os.Stdout, changes)
+	}
+
+	if !changes.Empty() {
+		os.Exit(exitChanges)
+	}
+	os.Exit(exitNoChanges)
+}
+
+// loadState wczytuje stan systemu wskazany nazwą pliku; zwraca także ścieżkę pliku
+func loadState(name, stateDir string) (*models.SystemState, string, error) {
+	path, err := resolveStatePath(name, stateDir)
+	if err != nil {
+		return nil, "", err
+	}
+
+	state, err := utils.LoadStateFromFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return state, path, nil
+}
+
+// resolveStatePath zamienia nazwę stanu na ścieżkę pliku. Nazwy "latest" i "previous"
+// oznaczają najnowszy i przedostatni stan w katalogu stanów; ścieżki względne,
+// które nie istnieją w bieżącym katalogu, są szukane w katalogu stanów.
+func resolveStatePath(name, stateDir string) (string, error) {
+	switch name {
+	case "latest", "previous":
+		files, err := utils.ListStateFiles(stateDir)
+		if err != nil {
+			return "", err
+		}
+		index := len(files) - 1
+		if name == "previous" {
+			index--
+		}
+		if index < 0 {
+			return "", fmt.Errorf("za mało zapisanych stanów w katalogu %s dla %q", stateDir, name)
+		}
+		return files[index], nil
+	}
+
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	if !filepath.IsAbs(name) {
+		candidate := filepath.Join(stateDir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", fmt.Errorf("nie znaleziono pliku stanu %s (szukano także w %s)", name, stateDir)
+}
+
+// printStateSummary wyświetla podstawowe informacje o stanie systemu
+func printStateSummary(w io.Writer, systemState *models.SystemState) {
+	hardware := systemState.Hardware
+	if hardware == nil {
+		hardware = &models.Hardware{}
+	}
+
+	fmt.Fprintln(w, "Podstawowe informacje o systemie:")
+	fmt.Fprintf(w, "Hostname: %s\n", hardware.Hostname)
+	fmt.Fprintf(w, "Platform: %s %s\n", hardware.Platform, hardware.PlatformVersion)
+	fmt.Fprintf(w, "Kernel: %s\n", hardware.KernelVersion)
+	if hardware.CPU != nil {
+		fmt.Fprintf(w, "CPU: %s (%d rdzeni fizycznych, %d rdzeni logicznych)\n",
+			hardware.CPU.Model,
+			hardware.CPU.PhysicalCores,
+			hardware.CPU.LogicalCores)
+	}
+	if hardware.Memory != nil {
+		fmt.Fprintf(w, "Pamięć: %.2f GB (użycie: %.1f%%)\n",
+			hardware.Memory.TotalGB,
+			hardware.Memory.Percent)
+	}
+	fmt.Fprintf(w, "Liczba dysków: %d\n", len(hardware.Disks))
+	fmt.Fprintf(w, "Liczba interfejsów sieciowych: %d\n", len(hardware.Network))
+	fmt.Fprintf(w, "Liczba procesów: %d\n", len(systemState.Processes))
+	fmt.Fprintf(w, "Liczba usług: %d\n", len(systemState.Services))
+
+	// Policz procesy i usługi związane z LLM
+	llmProcesses := 0
+	for _, proc := range systemState.Processes {
+		if proc.IsLLMRelated {
+			llmProcesses++
+		}
+	}
+
+	llmServices := 0
+	for _, svc := range systemState.Services {
+		if svc.IsLLMRelated {
+			llmServices++
+		}
+	}
+
+	fmt.Fprintf(w, "Procesy związane z LLM: %d\n", llmProcesses)
+	fmt.Fprintf(w, "Usługi związane z LLM: %d\n", llmServices)
+
+	// Wyświetl kolektory, które nie dostarczyły danych
+	if systemState.Collection != nil {
+		names := make([]string, 0, len(systemState.Collection.Errors))
+		for name := range systemState.Collection.Errors {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "Ostrzeżenie: kolektor %s nie dostarczył danych: %s\n", name, systemState.Collection.Errors[name])
+		}
+	}
+}
+
+// printChanges wyświetla zbiór zmian w czytelnej postaci
+func printChanges(w io.Writer, changes *diff.ChangeSet) {
+	if len(changes.Unknown) > 0 {
+		fmt.Fprintf(w, "Nie porównano danych kolektorów z błędem: %s\n", strings.Join(changes.Unknown, ", "))
+	}
+	if changes.Empty() {
+		fmt.Fprintln(w, "Brak zmian")
+		return
+	}
+
+	fmt.Fprintf(w, "Zmiany (%d):\n", len(changes.Changes))
+	for _, change := range changes.Changes {
+		label, ok := changeLabels[change.Kind]
+		if !ok {
+			label = string(change.Kind)
+		}
+
+		switch {
+		case change.Old != "" && change.New != "":
+			fmt.Fprintf(w, "  %s %s: %s -> %s\n", label, change.Subject, change.Old, change.New)
+		case change.New != "":
+			fmt.Fprintf(w, "  %s %s: %s\n", label, change.Subject, change.New)
+		case change.Old != "":
+			fmt.Fprintf(w, "  %s %s: %s\n", label, change.Subject, change.Old)
+		default:
+			fmt.Fprintf(w, "  %s %s\n", label, change.Subject)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/classify"
+	"gitlab.com/safetytwin/safetytwin/agent/collectors"
+	"gitlab.com/safetytwin/safetytwin/agent/health"
+	"gitlab.com/safetytwin/safetytwin/agent/metrics"
+	"gitlab.com/safetytwin/safetytwin/agent/utils"
+)
+
+// configPollInterval określa, jak często sprawdzana jest zmiana pliku konfiguracyjnego
+const configPollInterval = 5 * time.Second
+
+// minTriggerGap to najkrótszy odstęp między zbieraniem wyzwolonym przez zdarzenie
+// a poprzednim zbieraniem; chroni przed lawiną zbierań przy serii zdarzeń. Zdarzenia
+// z tego okna są łączone w jedno zbieranie wykonywane po jego upływie.
+const minTriggerGap = 5 * time.Second
+
+// daemon to agent działający w trybie ciągłym. Przechowuje stan, który musi
+// przetrwać przeładowanie konfiguracji: kolektory, kolejkę, koder aktualizacji
+// przyrostowych, eksporter metryk i stan agenta.
+type daemon struct {
+	configPath    string
+	configFlags   *utils.ConfigFlags
+	configModTime time.Time
+	config        *utils.Config
+
+	sender          *utils.Sender
+	systemCollector *collectors.SystemCollector
+	exporter        *metrics.Exporter
+	tracker         *health.Tracker
+
+	lastCollection time.Time
+}
+
+// newDaemon przygotowuje agenta do pracy w trybie ciągłym
+func newDaemon(configPath string, configFlags *utils.ConfigFlags, config *utils.Config) *daemon {
+	d := &daemon{
+		configPath:    configPath,
+		configFlags:   configFlags,
+		configModTime: fileModTime(configPath),
+		config:        config,
+	}
+
+	// Przygotowanie nadawcy danych
+	d.sender = utils.NewSender(config.BridgeURL)
+
+	// Stan agenta raportowany przez endpoint /health
+	d.tracker = health.NewTracker(time.Duration(config.Interval) * time.Second)
+	if config.HealthStaleAfter > 0 {
+		d.tracker.SetStaleAfter(time.Duration(config.HealthStaleAfter) * time.Second)
+	}
+
+	// Kolejka stanów, których nie udało się dostarczyć do VM Bridge
+	spool, err := utils.NewSpool(config.SpoolDir, int64(config.SpoolMaxMB)*1024*1024)
+	if err != nil {
+		log.Printf("Nie można otworzyć kolejki stanów, niedostarczone stany będą tracone: %v", err)
+	} else {
+		d.sender.Spool = spool
+		d.tracker.SetSpool(spool)
+	}
+
+	// Aktualizacje przyrostowe: pełny stan co FullStateEvery aktualizacji, pomiędzy nimi zmiany
+	if config.DeltaUpdates {
+		d.sender.Delta = utils.NewDeltaEncoder(config.FullStateEvery)
+	}
+
+	// Lokalne endpointy HTTP; metryki i stan agenta pod tym samym adresem
+	// są obsługiwane przez jeden serwer
+	muxes := make(map[string]*http.ServeMux)
+	if config.MetricsListen != "" {
+		d.exporter = metrics.NewExporter()
+		handleHTTP(muxes, config.MetricsListen, "/metrics", d.exporter)
+	}
+	if config.HealthListen != "" {
+		handleHTTP(muxes, config.HealthListen, "/health", d.tracker)
+		handleHTTP(muxes, config.HealthListen, "/ready", d.tracker.ReadyHandler())
+	}
+	startHTTPServers(muxes)
+
+	// Kolektor systemu jest współdzielony między kolejnymi zbieraniami, dzięki
+	// czemu zawieszony kolektor nie jest uruchamiany ponownie, dopóki nie zakończy pracy
+	d.systemCollector = collectors.NewSystemCollector(config.EnabledCollectors(collectors.Registered())...)
+	configureSystemCollector(d.systemCollector, config)
+
+	return d
+}
+
+// run uruchamia proces zbierania danych w pętli. Sygnał na reloadChan (SIGHUP)
+// lub zmiana pliku konfiguracyjnego powodują przeładowanie konfiguracji.
+func (d *daemon) run(stopChan, reloadChan <-chan struct{}) {
+	// Interwał zbierania danych
+	interval := time.Duration(d.config.Interval) * time.Second
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// Obserwowanie zmian pliku konfiguracyjnego
+	var pollChan <-chan time.Time
+	if d.configPath != "" {
+		poll := time.NewTicker(configPollInterval)
+		defer poll.Stop()
+		pollChan = poll.C
+	}
+
+	log.Printf("Agent uruchomiony. Interwał zbierania danych: %d sekund", d.config.Interval)
+
+	// Zbieranie odłożone do końca minTriggerGap; nil, gdy żadne nie oczekuje
+	var deferredChan <-chan time.Time
+	var deferredReason string
+	var deferredAt time.Time
+
+	// Natychmiastowe pierwsze zbieranie
+	d.collectAndSendState()
+
+	// Główna pętla zbierania danych
+	for {
+		select {
+		case <-ticker.C:
+			d.collectAndSendState()
+		case reason := <-d.systemCollector.Triggers():
+			if !d.config.EventTriggers {
+				continue
+			}
+			if wait := minTriggerGap - time.Since(d.lastCollection); wait > 0 {
+				// Kolejne zdarzenia z tego samego okna dołączają do odłożonego zbierania
+				if deferredChan == nil {
+					log.Printf("Zbieranie wyzwolone zdarzeniem (%s) odłożone o %v: poprzednie zbieranie było przed chwilą", reason, wait.Round(time.Millisecond))
+					deferredChan = time.After(wait)
+					deferredReason = reason
+					deferredAt = time.Now()
+				}
+				continue
+			}
+			log.Printf("Zbieranie danych wyzwolone zdarzeniem: %s", reason)
+			d.collectAndSendState()
+		case <-deferredChan:
+			deferredChan = nil
+			// Zbieranie okresowe rozpoczęte po zdarzeniu już je uwzględniło
+			if d.lastCollection.After(deferredAt) {
+				continue
+			}
+			log.Printf("Zbieranie danych wyzwolone zdarzeniem: %s", deferredReason)
+			d.collectAndSendState()
+		case <-reloadChan:
+			log.Println("Przeładowanie konfiguracji na żądanie (SIGHUP)")
+			d.reload(ticker)
+		case <-pollChan:
+			if modTime := fileModTime(d.configPath); !modTime.Equal(d.configModTime) {
+				log.Printf("Plik konfiguracyjny %s zmienił się, przeładowanie konfiguracji", d.configPath)
+				d.reload(ticker)
+			}
+		case <-stopChan:
+			log.Println("Zatrzymanie procesu zbierania danych")
+			return
+		}
+	}
+}
+
+// collectAndSendState zbiera i wysyła stan systemu
+func (d *daemon) collectAndSendState() {
+	startTime := time.Now()
+	d.lastCollection = startTime
+	log.Println("Rozpoczęcie zbierania danych o systemie...")
+
+	// Zbierz informacje o systemie
+	systemState, err := d.systemCollector.Collect(context.Background())
+	d.tracker.RecordCollection(systemState, time.Since(startTime), err)
+	if err != nil {
+		log.Printf("Błąd podczas zbierania informacji o systemie: %v", err)
+		return
+	}
+
+	// Odnotuj kolektory, które nie dostarczyły danych
+	for name, msg := range systemState.Collection.Errors {
+		log.Printf("Kolektor %s nie dostarczył danych: %s", name, msg)
+	}
+
+	// Udostępnij stan jako metryki Prometheus
+	if d.exporter != nil {
+		d.exporter.Update(systemState)
+	}
+
+	// Zapisanie stanu do pliku
+	if err := utils.SaveStateToFile(systemState, d.config.StateDir); err != nil {
+		log.Printf("Błąd zapisu stanu do pliku: %v", err)
+	}
+
+	// Wysłanie stanu do VM Bridge
+	err = d.sender.SendState(systemState)
+	d.tracker.RecordSend(err)
+	if err != nil {
+		log.Printf("Błąd wysyłania stanu do VM Bridge: %v", err)
+	} else {
+		log.Printf("Stan systemu pomyślnie wysłany do VM Bridge")
+	}
+
+	// Raportuj czas trwania
+	elapsedTime := time.Since(startTime)
+	log.Printf("Zbieranie danych zakończone. Czas trwania: %v", elapsedTime)
+}
+
+// reload wczytuje ponownie plik konfiguracyjny, nakłada na niego zmienne środowiskowe
+// i flagi, po czym stosuje wynik bez utraty stanu agenta. Niepoprawna konfiguracja
+// jest odrzucana, a agent działa dalej z poprzednią.
+func (d *daemon) reload(ticker *time.Ticker) {
+	if d.configPath == "" {
+		log.Println("Brak pliku konfiguracyjnego, nie ma czego przeładować")
+		return
+	}
+
+	// Zapamiętaj wersję pliku także przy błędzie, aby nie zgłaszać go co chwilę ponownie
+	d.configModTime = fileModTime(d.configPath)
+
+	config, err := loadConfig(d.configPath, d.configFlags)
+	if err == nil {
+		err = config.CheckDirectories()
+	}
+	if err == nil {
+		err = validateCollectors(config)
+	}
+	if err != nil {
+		log.Printf("Nie przeładowano konfiguracji, agent działa z poprzednią: %v", err)
+		return
+	}
+
+	previous := d.config
+	d.applyConfig(config)
+
+	if config.Interval != previous.Interval {
+		ticker.Reset(time.Duration(config.Interval) * time.Second)
+		log.Printf("Nowy interwał zbierania danych: %d sekund", config.Interval)
+	}
+
+	log.Println("Konfiguracja przeładowana")
+}
+
+// applyConfig stosuje nową konfigurację do działającego agenta
+func (d *daemon) applyConfig(config *utils.Config) {
+	previous := d.config
+
+	// Logowanie
+	if config.LogFile != previous.LogFile || config.Verbose != previous.Verbose {
+		if err := utils.ConfigureLogger(config.LogFile, config.Verbose); err != nil {
+			log.Printf("Nie można zmienić konfiguracji logowania: %v", err)
+		}
+	}
+
+	// Adres VM Bridge
+	if config.BridgeURL != previous.BridgeURL {
+		log.Printf("Nowy adres VM Bridge: %s", config.BridgeURL)
+		d.sender.URL = config.BridgeURL
+	}
+
+	// Aktualizacje przyrostowe; koder zachowuje numerację przy zmianie full_state_every
+	switch {
+	case config.DeltaUpdates && d.sender.Delta == nil:
+		d.sender.Delta = utils.NewDeltaEncoder(config.FullStateEvery)
+	case !config.DeltaUpdates:
+		d.sender.Delta = nil
+	default:
+		d.sender.Delta.SetFullEvery(config.FullStateEvery)
+	}
+
+	// Kolektory i ich limity czasu
+	d.systemCollector.SetCollectors(config.EnabledCollectors(collectors.Registered())...)
+	configureSystemCollector(d.systemCollector, config)
+
+	// Próg nieaktualności endpointu /health
+	if config.HealthStaleAfter > 0 {
+		d.tracker.SetStaleAfter(time.Duration(config.HealthStaleAfter) * time.Second)
+	} else {
+		d.tracker.SetInterval(time.Duration(config.Interval) * time.Second)
+	}
+
+	// Ustawienia, których nie można zmienić w działającym agencie
+	restartRequired := map[string]bool{
+		"spool_dir":      config.SpoolDir != previous.SpoolDir,
+		"spool_max_mb":   config.SpoolMaxMB != previous.SpoolMaxMB,
+		"metrics_listen": config.MetricsListen != previous.MetricsListen,
+		"health_listen":  config.HealthListen != previous.HealthListen,
+	}
+	for _, key := range sortedKeys(restartRequired) {
+		if restartRequired[key] {
+			log.Printf("Ostrzeżenie: zmiana %s zostanie zastosowana dopiero po restarcie agenta", key)
+		}
+	}
+
+	d.config = config
+}
+
+// configureSystemCollector ustawia limity czasu kolektorów i reguły klasyfikacji LLM
+// zgodnie z konfiguracją
+func configureSystemCollector(systemCollector *collectors.SystemCollector, config *utils.Config) {
+	systemCollector.SetDefaultTimeout(time.Duration(config.CollectorTimeout) * time.Second)
+
+	timeouts := make(map[string]time.Duration, len(config.CollectorTimeouts))
+	for name, seconds := range config.CollectorTimeouts {
+		timeouts[name] = time.Duration(seconds) * time.Second
+	}
+	systemCollector.SetTimeouts(timeouts)
+
+	// Reguły zostały sprawdzone przy wczytywaniu konfiguracji
+	classifier, err := classify.New(config.LLMRules)
+	if err != nil {
+		log.Printf("Ostrzeżenie: nie można zastosować reguł LLM: %v", err)
+		return
+	}
+	collectors.SetLLMClassifier(classifier)
+}
+
+// validateCollectors sprawdza, czy konfiguracja odwołuje się tylko do znanych kolektorów
+func validateCollectors(config *utils.Config) error {
+	known := make(map[string]bool)
+	for _, name := range collectors.Registered() {
+		known[name] = true
+	}
+
+	unknown := make(map[string]bool)
+	for name := range config.Collectors {
+		if !known[name] {
+			unknown[name] = true
+		}
+	}
+	for name := range config.CollectorTimeouts {
+		if !known[name] {
+			unknown[name] = true
+		}
+	}
+
+	if len(unknown) == 0 {
+		return nil
+	}
+	return fmt.Errorf("nieznane kolektory: %s (dostępne: %s)",
+		strings.Join(sortedKeys(unknown), ", "), strings.Join(collectors.Registered(), ", "))
+}
+
+// sortedKeys zwraca posortowane klucze mapy
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fileModTime zwraca czas modyfikacji pliku lub zerowy czas, jeśli pliku nie ma
+func fileModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// handleHTTP rejestruje handler pod ścieżką na serwerze o podanym adresie
+func handleHTTP(muxes map[string]*http.ServeMux, addr, pattern string, handler http.Handler) {
+	mux, ok := muxes[addr]
+	if !ok {
+		mux = http.NewServeMux()
+		muxes[addr] = mux
+	}
+	mux.Handle(pattern, handler)
+}
+
+// startHTTPServers uruchamia lokalne serwery HTTP (metryki, stan agenta)
+func startHTTPServers(muxes map[string]*http.ServeMux) {
+	for addr, mux := range muxes {
+		go func(addr string, mux *http.ServeMux) {
+			log.Printf("Serwer HTTP agenta nasłuchuje na %s", addr)
+			if err := http.ListenAndServe(addr, mux); err != nil {
+				log.Printf("Błąd serwera HTTP agenta %s: %v", addr, err)
+			}
+		}(addr, mux)
+	}
+}
@@ -0,0 +1,488 @@
+// Package diff porównuje dwa stany systemu i zwraca typowany zbiór zmian.
+// Elementy stanu są dopasowywane po stałych identyfikatorach (proces: PID
+// i czas utworzenia, usługa: typ i nazwa, dysk: punkt montowania, interfejs: nazwa),
+// dzięki czemu wynik nadaje się do raportów dryfu, alertów i aktualizacji bliźniaka.
+// Części stanu, których kolektor zgłosił błąd w którymkolwiek ze stanów, nie są
+// porównywane, a nazwy tych kolektorów trafiają do ChangeSet.Unknown.
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// ChangeKind określa rodzaj zmiany
+type ChangeKind string
+
+// Rodzaje zmian
+const (
+	HostnameChanged ChangeKind = "hostname_changed"
+	KernelChanged   ChangeKind = "kernel_changed"
+	PlatformChanged ChangeKind = "platform_changed"
+
+	ProcessStarted   ChangeKind = "process_started"
+	ProcessExited    ChangeKind = "process_exited"
+	ProcessRestarted ChangeKind = "process_restarted"
+
+	ServiceAdded          ChangeKind = "service_added"
+	ServiceRemoved        ChangeKind = "service_removed"
+	ServiceStatusChanged  ChangeKind = "service_status_changed"
+	ServiceRestarted      ChangeKind = "service_restarted"
+	ContainerImageChanged ChangeKind = "container_image_changed"
+	ContainerRecreated    ChangeKind = "container_recreated"
+
+	MountAdded   ChangeKind = "mount_added"
+	MountRemoved ChangeKind = "mount_removed"
+	MountChanged ChangeKind = "mount_changed"
+
+	InterfaceAdded          ChangeKind = "interface_added"
+	InterfaceRemoved        ChangeKind = "interface_removed"
+	InterfaceAddressChanged ChangeKind = "interface_address_changed"
+	InterfaceMACChanged     ChangeKind = "interface_mac_changed"
+
+	GPUAdded   ChangeKind = "gpu_added"
+	GPURemoved ChangeKind = "gpu_removed"
+
+	ModelAdded   ChangeKind = "model_added"
+	ModelRemoved ChangeKind = "model_removed"
+	ModelChanged ChangeKind = "model_changed" // Inna zawartość pliku pod tą samą ścieżką
+)
+
+// Change opisuje pojedynczą zmianę między dwoma stanami
+type Change struct {
+	Kind    ChangeKind `json:"kind"`
+	Key     string     `json:"key"`           // Stały identyfikator elementu
+	Subject string     `json:"subject"`       // Czytelna nazwa elementu
+	Old     string     `json:"old,omitempty"` // Poprzednia wartość
+	New     string     `json:"new,omitempty"` // Nowa wartość
+}
+
+// String zwraca czytelny opis zmiany
+func (c Change) String() string {
+	switch {
+	case c.Old != "" && c.New != "":
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, c.Subject, c.Old, c.New)
+	case c.New != "":
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Subject, c.New)
+	case c.Old != "":
+		return fmt.Sprintf("%s %s: %s", c.Kind, c.Subject, c.Old)
+	default:
+		return fmt.Sprintf("%s %s", c.Kind, c.Subject)
+	}
+}
+
+// ChangeSet to zbiór zmian między dwoma stanami systemu
+type ChangeSet struct {
+	From    string   `json:"from"` // Znacznik czasu starszego stanu
+	To      string   `json:"to"`   // Znacznik czasu nowszego stanu
+	Changes []Change `json:"changes"`
+	Unknown []string `json:"unknown,omitempty"` // Kolektory z błędem; ich części stanu nie zostały porównane
+}
+
+// Empty sprawdza, czy stany są identyczne z punktu widzenia porównania
+func (cs *ChangeSet) Empty() bool {
+	return len(cs.Changes) == 0
+}
+
+// ByKind zwraca zmiany podanego rodzaju
+func (cs *ChangeSet) ByKind(kind ChangeKind) []Change {
+	changes := make([]Change, 0)
+	for _, change := range cs.Changes {
+		if change.Kind == kind {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+// Compare porównuje dwa stany systemu i zwraca zbiór zmian od old do new
+func Compare(old, new *models.SystemState) *ChangeSet {
+	if old == nil {
+		old = &models.SystemState{}
+	}
+	if new == nil {
+		new = &models.SystemState{}
+	}
+
+	cs := &ChangeSet{
+		From:    old.Timestamp,
+		To:      new.Timestamp,
+		Changes: make([]Change, 0),
+	}
+
+	oldHardware := old.Hardware
+	if oldHardware == nil {
+		oldHardware = &models.Hardware{}
+	}
+	newHardware := new.Hardware
+	if newHardware == nil {
+		newHardware = &models.Hardware{}
+	}
+
+	// Brak danych z kolektora, który zgłosił błąd, nie oznacza zakończenia procesów
+	// czy usług, więc takie części stanu są pomijane
+	failed := make(map[string]bool)
+	for _, report := range []*models.CollectionReport{old.Collection, new.Collection} {
+		if report == nil {
+			continue
+		}
+		for name := range report.Errors {
+			if !failed[name] {
+				failed[name] = true
+				cs.Unknown = append(cs.Unknown, name)
+			}
+		}
+	}
+	sort.Strings(cs.Unknown)
+
+	if !failed["hardware"] {
+		cs.Changes = append(cs.Changes, compareHost(oldHardware, newHardware)...)
+	}
+	if !failed["processes"] {
+		cs.Changes = append(cs.Changes, compareProcesses(old.Processes, new.Processes)...)
+	}
+	cs.Changes = append(cs.Changes, compareServices(collectedServices(old.Services, failed), collectedServices(new.Services, failed))...)
+	if !failed["hardware"] {
+		cs.Changes = append(cs.Changes, compareMounts(oldHardware.Disks, newHardware.Disks)...)
+		cs.Changes = append(cs.Changes, compareInterfaces(oldHardware.Network, newHardware.Network)...)
+		cs.Changes = append(cs.Changes, compareGPUs(oldHardware.GPU, newHardware.GPU)...)
+	}
+	// Spis modeli powstaje na podstawie procesów
+	if !failed["processes"] && !failed["models"] {
+		cs.Changes = append(cs.Changes, compareModels(old.Models, new.Models)...)
+	}
+
+	return cs
+}
+
+// collectedServices zwraca usługi dostarczone przez kolektory bez błędu
+func collectedServices(services []models.Service, failed map[string]bool) []models.Service {
+	if len(failed) == 0 {
+		return services
+	}
+
+	collected := make([]models.Service, 0, len(services))
+	for i := range services {
+		if !failed[services[i].Collector()] {
+			collected = append(collected, services[i])
+		}
+	}
+	return collected
+}
+
+// compareHost porównuje podstawowe informacje o hoście
+func compareHost(old, new *models.Hardware) []Change {
+	changes := make([]Change, 0)
+
+	if old.Hostname != new.Hostname && old.Hostname != "" && new.Hostname != "" {
+		changes = append(changes, Change{Kind: HostnameChanged, Key: "hostname", Subject: "host", Old: old.Hostname, New: new.Hostname})
+	}
+	if old.KernelVersion != new.KernelVersion && old.KernelVersion != "" && new.KernelVersion != "" {
+		changes = append(changes, Change{Kind: KernelChanged, Key: "kernel", Subject: new.Hostname, Old: old.KernelVersion, New: new.KernelVersion})
+	}
+	oldPlatform := strings.TrimSpace(old.Platform + " " + old.PlatformVersion)
+	newPlatform := strings.TrimSpace(new.Platform + " " + new.PlatformVersion)
+	if oldPlatform != newPlatform && oldPlatform != "" && newPlatform != "" {
+		changes = append(changes, Change{Kind: PlatformChanged, Key: "platform", Subject: new.Hostname, Old: oldPlatform, New: newPlatform})
+	}
+
+	return changes
+}
+
+// processIdentity zwraca tożsamość procesu niezależną od PID, używaną do wykrywania restartów
+func processIdentity(p *models.Process) string {
+	return p.Name + "\x00" + strings.Join(p.Cmdline, " ")
+}
+
+// processSubject zwraca czytelną nazwę procesu
+func processSubject(p *models.Process) string {
+	return fmt.Sprintf("%s[%d]", p.Name, p.PID)
+}
+
+// compareProcesses porównuje procesy według PID i czasu utworzenia. Proces, który
+// zakończył się i został uruchomiony ponownie z tą samą nazwą i linią poleceń,
+// jest raportowany jako restart.
+func compareProcesses(old, new []models.Process) []Change {
+	oldByKey := make(map[string]*models.Process, len(old))
+	for i := range old {
+		oldByKey[old[i].Key()] = &old[i]
+	}
+	newByKey := make(map[string]*models.Process, len(new))
+	for i := range new {
+		newByKey[new[i].Key()] = &new[i]
+	}
+
+	// Zakończone i nowe procesy
+	exited := make([]*models.Process, 0)
+	for i := range old {
+		if _, ok := newByKey[old[i].Key()]; !ok {
+			exited = append(exited, &old[i])
+		}
+	}
+	started := make([]*models.Process, 0)
+	for i := range new {
+		if _, ok := oldByKey[new[i].Key()]; !ok {
+			started = append(started, &new[i])
+		}
+	}
+
+	// Dopasuj restarty: zakończony i nowy proces o tej samej tożsamości
+	pending := make(map[string][]*models.Process)
+	for _, p := range exited {
+		identity := processIdentity(p)
+		pending[identity] = append(pending[identity], p)
+	}
+
+	changes := make([]Change, 0)
+	restarted := make(map[*models.Process]bool)
+	for _, p := range started {
+		identity := processIdentity(p)
+		if candidates := pending[identity]; len(candidates) > 0 {
+			previous := candidates[0]
+			pending[identity] = candidates[1:]
+			restarted[previous] = true
+			changes = append(changes, Change{
+				Kind:    ProcessRestarted,
+				Key:     p.Key(),
+				Subject: p.Name,
+				Old:     fmt.Sprintf("pid %d", previous.PID),
+				New:     fmt.Sprintf("pid %d", p.PID),
+			})
+			continue
+		}
+		changes = append(changes, Change{
+			Kind:    ProcessStarted,
+			Key:     p.Key(),
+			Subject: processSubject(p),
+			New:     strings.Join(p.Cmdline, " "),
+		})
+	}
+
+	for _, p := range exited {
+		if restarted[p] {
+			continue
+		}
+		changes = append(changes, Change{
+			Kind:    ProcessExited,
+			Key:     p.Key(),
+			Subject: processSubject(p),
+			Old:     strings.Join(p.Cmdline, " "),
+		})
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// serviceIdentity zwraca stały identyfikator usługi: typ i nazwę. Nazwa, a nie ID,
+// pozwala rozpoznać kontener utworzony ponownie z nowym obrazem.
+func serviceIdentity(s *models.Service) string {
+	return s.Type + "/" + s.Name
+}
+
+// compareServices porównuje usługi według typu i nazwy
+func compareServices(old, new []models.Service) []Change {
+	oldByKey := make(map[string]*models.Service, len(old))
+	for i := range old {
+		oldByKey[serviceIdentity(&old[i])] = &old[i]
+	}
+
+	changes := make([]Change, 0)
+	seen := make(map[string]bool, len(new))
+	for i := range new {
+		current := &new[i]
+		key := serviceIdentity(current)
+		seen[key] = true
+
+		previous, ok := oldByKey[key]
+		if !ok {
+			changes = append(changes, Change{Kind: ServiceAdded, Key: key, Subject: current.Name, New: current.Status})
+			continue
+		}
+
+		if previous.Status != current.Status {
+			changes = append(changes, Change{Kind: ServiceStatusChanged, Key: key, Subject: current.Name, Old: previous.Status, New: current.Status})
+		}
+		if previous.Image != current.Image {
+			changes = append(changes, Change{Kind: ContainerImageChanged, Key: key, Subject: current.Name, Old: previous.Image, New: current.Image})
+		}
+		if current.IsContainer() && previous.ID != current.ID && previous.ID != "" && current.ID != "" {
+			changes = append(changes, Change{Kind: ContainerRecreated, Key: key, Subject: current.Name, Old: shortID(previous.ID), New: shortID(current.ID)})
+		} else if previous.PID != current.PID && previous.PID > 0 && current.PID > 0 {
+			changes = append(changes, Change{Kind: ServiceRestarted, Key: key, Subject: current.Name, Old: fmt.Sprintf("pid %d", previous.PID), New: fmt.Sprintf("pid %d", current.PID)})
+		}
+	}
+
+	for i := range old {
+		key := serviceIdentity(&old[i])
+		if !seen[key] {
+			changes = append(changes, Change{Kind: ServiceRemoved, Key: key, Subject: old[i].Name, Old: old[i].Status})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// shortID skraca identyfikator kontenera do 12 znaków, jak robi to Docker
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
+// compareMounts porównuje dyski według punktu montowania
+func compareMounts(old, new []models.Disk) []Change {
+	oldByKey := make(map[string]*models.Disk, len(old))
+	for i := range old {
+		oldByKey[old[i].Key()] = &old[i]
+	}
+
+	changes := make([]Change, 0)
+	seen := make(map[string]bool, len(new))
+	for i := range new {
+		current := &new[i]
+		key := current.Key()
+		seen[key] = true
+
+		previous, ok := oldByKey[key]
+		if !ok {
+			changes = append(changes, Change{Kind: MountAdded, Key: key, Subject: key, New: mountDescription(current)})
+			continue
+		}
+		if previous.Device != current.Device || previous.Fstype != current.Fstype {
+			changes = append(changes, Change{Kind: MountChanged, Key: key, Subject: key, Old: mountDescription(previous), New: mountDescription(current)})
+		}
+	}
+
+	for i := range old {
+		key := old[i].Key()
+		if !seen[key] {
+			changes = append(changes, Change{Kind: MountRemoved, Key: key, Subject: key, Old: mountDescription(&old[i])})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// mountDescription zwraca opis montowania: urządzenie i system plików
+func mountDescription(d *models.Disk) string {
+	return fmt.Sprintf("%s (%s)", d.Device, d.Fstype)
+}
+
+// compareInterfaces porównuje interfejsy sieciowe według nazwy
+func compareInterfaces(old, new map[string]models.NetworkInterface) []Change {
+	changes := make([]Change, 0)
+
+	for name, current := range new {
+		previous, ok := old[name]
+		if !ok {
+			changes = append(changes, Change{Kind: InterfaceAdded, Key: name, Subject: name, New: addressList(current.Addresses)})
+			continue
+		}
+		if addressList(previous.Addresses) != addressList(current.Addresses) {
+			changes = append(changes, Change{Kind: InterfaceAddressChanged, Key: name, Subject: name, Old: addressList(previous.Addresses), New: addressList(current.Addresses)})
+		}
+		if previous.MAC != current.MAC {
+			changes = append(changes, Change{Kind: InterfaceMACChanged, Key: name, Subject: name, Old: previous.MAC, New: current.MAC})
+		}
+	}
+
+	for name, previous := range old {
+		if _, ok := new[name]; !ok {
+			changes = append(changes, Change{Kind: InterfaceRemoved, Key: name, Subject: name, Old: addressList(previous.Addresses)})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// addressList zwraca posortowaną listę adresów jako tekst
+func addressList(addresses []string) string {
+	sorted := append([]string(nil), addresses...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// compareGPUs porównuje karty graficzne według producenta i indeksu
+func compareGPUs(old, new map[string][]models.GPUDevice) []Change {
+	gpuKeys := func(gpus map[string][]models.GPUDevice) map[string]string {
+		keys := make(map[string]string)
+		for vendor, devices := range gpus {
+			for _, device := range devices {
+				keys[fmt.Sprintf("%s/%d", vendor, device.Index)] = device.Name
+			}
+		}
+		return keys
+	}
+
+	oldKeys := gpuKeys(old)
+	newKeys := gpuKeys(new)
+
+	changes := make([]Change, 0)
+	for key, name := range newKeys {
+		if _, ok := oldKeys[key]; !ok {
+			changes = append(changes, Change{Kind: GPUAdded, Key: key, Subject: key, New: name})
+		}
+	}
+	for key, name := range oldKeys {
+		if _, ok := newKeys[key]; !ok {
+			changes = append(changes, Change{Kind: GPURemoved, Key: key, Subject: key, Old: name})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// compareModels porównuje pliki modeli według ścieżki (i kontenera); zmiana odcisku
+// oznacza podmianę pliku modelu
+func compareModels(old, new []models.ModelArtifact) []Change {
+	oldByKey := make(map[string]*models.ModelArtifact, len(old))
+	for i := range old {
+		oldByKey[old[i].Key()] = &old[i]
+	}
+
+	changes := make([]Change, 0)
+	seen := make(map[string]bool, len(new))
+	for i := range new {
+		current := &new[i]
+		key := current.Key()
+		seen[key] = true
+
+		previous, ok := oldByKey[key]
+		if !ok {
+			changes = append(changes, Change{Kind: ModelAdded, Key: key, Subject: current.Path, New: current.Fingerprint})
+			continue
+		}
+		if previous.Fingerprint != current.Fingerprint {
+			changes = append(changes, Change{Kind: ModelChanged, Key: key, Subject: current.Path, Old: previous.Fingerprint, New: current.Fingerprint})
+		}
+	}
+
+	for i := range old {
+		key := old[i].Key()
+		if !seen[key] {
+			changes = append(changes, Change{Kind: ModelRemoved, Key: key, Subject: old[i].Path, Old: old[i].Fingerprint})
+		}
+	}
+
+	sortChanges(changes)
+	return changes
+}
+
+// sortChanges sortuje zmiany według rodzaju i klucza
+func sortChanges(changes []Change) {
+	sort.SliceStable(changes, func(i, j int) bool {
+		if changes[i].Kind != changes[j].Kind {
+			return changes[i].Kind < changes[j].Kind
+		}
+		return changes[i].Key < changes[j].Key
+	})
+}
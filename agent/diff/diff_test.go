@@ -0,0 +1,238 @@
+package diff
+
+import (
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// baseState tworzy stan systemu używany jako punkt wyjścia w testach
+func baseState() *models.SystemState {
+	return &models.SystemState{
+		Timestamp: "2025-01-01T00:00:00Z",
+		Hardware: &models.Hardware{
+			Hostname:      "test-host",
+			KernelVersion: "6.1.0",
+			Disks: []models.Disk{
+				{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4"},
+			},
+			Network: map[string]models.NetworkInterface{
+				"eth0": {Name: "eth0", MAC: "aa:bb:cc:dd:ee:ff", Addresses: []string{"10.0.0.1/24"}},
+			},
+		},
+		Processes: []models.Process{
+			{PID: 1, Name: "init", CreateTime: "2025-01-01T00:00:00Z", Cmdline: []string{"/sbin/init"}},
+			{PID: 100, Name: "ollama", CreateTime: "2025-01-01T00:00:10Z", Cmdline: []string{"ollama", "serve"}},
+			{PID: 150, Name: "cron", CreateTime: "2025-01-01T00:00:05Z", Cmdline: []string{"cron"}},
+		},
+		Services: []models.Service{
+			{Name: "nginx", Type: "systemd", ID: "nginx", Status: "active"},
+			{Name: "vllm", Type: "docker", ID: "aaaaaaaaaaaaaaaa", Status: "running", Image: "vllm/vllm-openai:v0.4"},
+		},
+	}
+}
+
+// copyState tworzy głęboką kopię stanu wystarczającą na potrzeby testów
+func copyState(state *models.SystemState) *models.SystemState {
+	hardware := *state.Hardware
+	hardware.Disks = append([]models.Disk(nil), state.Hardware.Disks...)
+	hardware.Network = make(map[string]models.NetworkInterface)
+	for name, iface := range state.Hardware.Network {
+		hardware.Network[name] = iface
+	}
+
+	return &models.SystemState{
+		Timestamp: state.Timestamp,
+		Hardware:  &hardware,
+		Processes: append([]models.Process(nil), state.Processes...),
+		Services:  append([]models.Service(nil), state.Services...),
+		Models:    append([]models.ModelArtifact(nil), state.Models...),
+	}
+}
+
+func TestCompareIdenticalStates(t *testing.T) {
+	state := baseState()
+	cs := Compare(state, copyState(state))
+	if !cs.Empty() {
+		t.Errorf("Oczekiwano braku zmian, got %v", cs.Changes)
+	}
+}
+
+func TestCompareProcesses(t *testing.T) {
+	old := baseState()
+	new := copyState(old)
+	new.Processes = []models.Process{
+		old.Processes[0],
+		// ollama uruchomiona ponownie z nowym PID
+		{PID: 300, Name: "ollama", CreateTime: "2025-01-01T00:05:00Z", Cmdline: []string{"ollama", "serve"}},
+		// nowy proces
+		{PID: 400, Name: "python3", CreateTime: "2025-01-01T00:06:00Z", Cmdline: []string{"python3", "app.py"}},
+		// cron zakończył się, a jego PID zajął inny proces
+		{PID: 150, Name: "sshd", CreateTime: "2025-01-01T00:07:00Z", Cmdline: []string{"sshd"}},
+	}
+
+	cs := Compare(old, new)
+
+	restarted := cs.ByKind(ProcessRestarted)
+	if len(restarted) != 1 || restarted[0].Subject != "ollama" || restarted[0].Old != "pid 100" || restarted[0].New != "pid 300" {
+		t.Errorf("Niepoprawne restarty procesów: got %v", restarted)
+	}
+
+	started := cs.ByKind(ProcessStarted)
+	if len(started) != 2 {
+		t.Fatalf("Niepoprawna liczba uruchomionych procesów: got %v, want %v", len(started), 2)
+	}
+	if started[0].Key != "150@2025-01-01T00:07:00Z" || started[1].Key != "400@2025-01-01T00:06:00Z" {
+		t.Errorf("Niepoprawne uruchomione procesy: got %v", started)
+	}
+
+	exited := cs.ByKind(ProcessExited)
+	if len(exited) != 1 || exited[0].Key != "150@2025-01-01T00:00:05Z" {
+		t.Errorf("Niepoprawne zakończone procesy: got %v", exited)
+	}
+}
+
+func TestCompareServices(t *testing.T) {
+	old := baseState()
+	new := copyState(old)
+	new.Services = []models.Service{
+		{Name: "nginx", Type: "systemd", ID: "nginx", Status: "failed"},
+		{Name: "vllm", Type: "docker", ID: "bbbbbbbbbbbbbbbb", Status: "running", Image: "vllm/vllm-openai:v0.5"},
+		{Name: "redis", Type: "systemd", ID: "redis", Status: "active"},
+	}
+
+	cs := Compare(old, new)
+
+	status := cs.ByKind(ServiceStatusChanged)
+	if len(status) != 1 || status[0].Old != "active" || status[0].New != "failed" {
+		t.Errorf("Niepoprawne zmiany statusu usług: got %v", status)
+	}
+
+	image := cs.ByKind(ContainerImageChanged)
+	if len(image) != 1 || image[0].Key != "docker/vllm" || image[0].New != "vllm/vllm-openai:v0.5" {
+		t.Errorf("Niepoprawne zmiany obrazu kontenera: got %v", image)
+	}
+
+	recreated := cs.ByKind(ContainerRecreated)
+	if len(recreated) != 1 || recreated[0].Old != "aaaaaaaaaaaa" {
+		t.Errorf("Niepoprawne odtworzenia kontenerów: got %v", recreated)
+	}
+
+	added := cs.ByKind(ServiceAdded)
+	if len(added) != 1 || added[0].Key != "systemd/redis" {
+		t.Errorf("Niepoprawne dodane usługi: got %v", added)
+	}
+
+	if removed := cs.ByKind(ServiceRemoved); len(removed) != 0 {
+		t.Errorf("Nie oczekiwano usuniętych usług, got %v", removed)
+	}
+}
+
+func TestCompareHardware(t *testing.T) {
+	old := baseState()
+	new := copyState(old)
+	new.Hardware.KernelVersion = "6.2.0"
+	new.Hardware.Disks = append(new.Hardware.Disks, models.Disk{Device: "/dev/sdb1", Mountpoint: "/models", Fstype: "xfs"})
+	new.Hardware.Network["eth0"] = models.NetworkInterface{Name: "eth0", MAC: "aa:bb:cc:dd:ee:ff", Addresses: []string{"10.0.0.2/24"}}
+	new.Hardware.Network["wg0"] = models.NetworkInterface{Name: "wg0"}
+	new.Hardware.GPU = map[string][]models.GPUDevice{
+		"nvidia": {{Index: 0, Name: "RTX 4090"}},
+	}
+
+	cs := Compare(old, new)
+
+	tests := []struct {
+		kind ChangeKind
+		key  string
+	}{
+		{KernelChanged, "kernel"},
+		{MountAdded, "/models"},
+		{InterfaceAddressChanged, "eth0"},
+		{InterfaceAdded, "wg0"},
+		{GPUAdded, "nvidia/0"},
+	}
+	for _, tt := range tests {
+		changes := cs.ByKind(tt.kind)
+		if len(changes) != 1 || changes[0].Key != tt.key {
+			t.Errorf("Niepoprawne zmiany %s: got %v, want klucz %v", tt.kind, changes, tt.key)
+		}
+	}
+
+	if len(cs.Changes) != len(tests) {
+		t.Errorf("Niepoprawna liczba zmian: got %v, want %v", len(cs.Changes), len(tests))
+	}
+
+	// Porównanie w drugą stronę raportuje usunięcia
+	reverse := Compare(new, old)
+	if removed := reverse.ByKind(MountRemoved); len(removed) != 1 || removed[0].Key != "/models" {
+		t.Errorf("Niepoprawne usunięte montowania: got %v", removed)
+	}
+	if removed := reverse.ByKind(InterfaceRemoved); len(removed) != 1 || removed[0].Key != "wg0" {
+		t.Errorf("Niepoprawne usunięte interfejsy: got %v", removed)
+	}
+}
+
+func TestCompareModels(t *testing.T) {
+	old := baseState()
+	old.Models = []models.ModelArtifact{
+		{Path: "/models/llama-3-8b.Q4_K_M.gguf", Fingerprint: "aaaa", PIDs: []int32{100}},
+		{Path: "/models/embed.safetensors", Fingerprint: "bbbb", PIDs: []int32{100}},
+	}
+	new := copyState(old)
+	new.Models = []models.ModelArtifact{
+		{Path: "/models/llama-3-8b.Q4_K_M.gguf", Fingerprint: "cccc", PIDs: []int32{100}},
+		{Path: "/models/llama-3-8b.Q4_K_M.gguf", Fingerprint: "aaaa", PIDs: []int32{300}, Containers: []string{"abc123"}},
+	}
+
+	cs := Compare(old, new)
+
+	if changed := cs.ByKind(ModelChanged); len(changed) != 1 || changed[0].Old != "aaaa" || changed[0].New != "cccc" {
+		t.Errorf("Niepoprawne zmienione modele: got %v", changed)
+	}
+	if added := cs.ByKind(ModelAdded); len(added) != 1 || added[0].Key != "abc123:/models/llama-3-8b.Q4_K_M.gguf" {
+		t.Errorf("Niepoprawne dodane modele: got %v", added)
+	}
+	if removed := cs.ByKind(ModelRemoved); len(removed) != 1 || removed[0].Key != "/models/embed.safetensors" {
+		t.Errorf("Niepoprawne usunięte modele: got %v", removed)
+	}
+}
+
+func TestCompareCollectorErrors(t *testing.T) {
+	old := baseState()
+	new := copyState(old)
+	// Kolektory procesów i Dockera nie dostarczyły danych
+	new.Processes = nil
+	new.Services = new.Services[:1]
+	new.Services[0].Status = "failed"
+	new.Collection = &models.CollectionReport{Errors: map[string]string{
+		"processes": "przekroczono limit czasu",
+		"docker":    "przekroczono limit czasu",
+	}}
+
+	cs := Compare(old, new)
+
+	if len(cs.ByKind(ProcessExited)) != 0 || len(cs.ByKind(ServiceRemoved)) != 0 {
+		t.Errorf("Niepełny stan nie może oznaczać zakończenia procesów ani usług: got %v", cs.Changes)
+	}
+	if len(cs.Changes) != 1 || cs.Changes[0].Kind != ServiceStatusChanged {
+		t.Errorf("Oczekiwano tylko zmiany statusu usługi systemd: got %v", cs.Changes)
+	}
+	if len(cs.Unknown) != 2 || cs.Unknown[0] != "docker" || cs.Unknown[1] != "processes" {
+		t.Errorf("Niepoprawne nieporównane kolektory: got %v", cs.Unknown)
+	}
+
+	// Błąd w starszym stanie również wyklucza porównanie
+	if cs := Compare(new, copyState(old)); len(cs.ByKind(ProcessStarted)) != 0 || len(cs.ByKind(ServiceAdded)) != 0 {
+		t.Errorf("Niepełny stan nie może oznaczać uruchomienia procesów ani usług: got %v", cs.Changes)
+	}
+}
+
+func TestCompareNilStates(t *testing.T) {
+	cs := Compare(nil, baseState())
+	if len(cs.ByKind(ProcessStarted)) != 3 {
+		t.Errorf("Oczekiwano uruchomienia wszystkich procesów, got %v", cs.Changes)
+	}
+	if len(cs.ByKind(HostnameChanged)) != 0 {
+		t.Errorf("Nie oczekiwano zmiany nazwy hosta dla pustego stanu, got %v", cs.Changes)
+	}
+}
@@ -0,0 +1,253 @@
+// Package health śledzi działanie agenta (zbieranie i wysyłanie danych)
+// i udostępnia je przez lokalny endpoint HTTP dla watchdogów i VM Bridge.
+package health
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// Statusy agenta
+const (
+	StatusOK       = "ok"       // Dane są zbierane i dostarczane na bieżąco
+	StatusStarting = "starting" // Agent nie zakończył jeszcze pierwszego zbierania
+	StatusStale    = "stale"    // Zbieranie lub wysyłanie danych nie powiodło się od zbyt dawna
+)
+
+// staleMultiplier określa domyślny próg nieaktualności jako wielokrotność interwału
+const staleMultiplier = 3
+
+// SpoolInfo opisuje kolejkę niedostarczonych stanów (np. utils.Spool)
+type SpoolInfo interface {
+	Len() int
+	Size() int64
+}
+
+// CollectorStatus opisuje wynik kolektora w ostatnim zbieraniu
+type CollectorStatus struct {
+	OK              bool    `json:"ok"`
+	Error           string  `json:"error,omitempty"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// Status to raport o stanie agenta zwracany przez endpoint
+type Status struct {
+	Status                        string                     `json:"status"`
+	Problems                      []string                   `json:"problems,omitempty"`
+	StartedAt                     time.Time                  `json:"started_at"`
+	LastCollection                *time.Time                 `json:"last_collection,omitempty"`
+	LastCollectionDurationSeconds float64                    `json:"last_collection_duration_seconds"`
+	LastCollectionError           string                     `json:"last_collection_error,omitempty"`
+	LastSend                      *time.Time                 `json:"last_send,omitempty"`
+	LastSendError                 string                     `json:"last_send_error,omitempty"`
+	LastSendErrorTime             *time.Time                 `json:"last_send_error_time,omitempty"`
+	SpoolDepth                    int                        `json:"spool_depth"`
+	SpoolBytes                    int64                      `json:"spool_bytes"`
+	Collectors                    map[string]CollectorStatus `json:"collectors,omitempty"`
+}
+
+// Healthy sprawdza, czy agent działa poprawnie
+func (s *Status) Healthy() bool {
+	return s.Status == StatusOK
+}
+
+// Tracker zapisuje wyniki zbierania i wysyłania danych
+type Tracker struct {
+	mu         sync.Mutex
+	now        func() time.Time
+	staleAfter time.Duration
+	spool      SpoolInfo
+
+	startedAt              time.Time
+	lastCollection         time.Time
+	lastCollectionDuration time.Duration
+	lastCollectionError    string
+	lastSend               time.Time
+	lastSendError          string
+	lastSendErrorTime      time.Time
+	collectors             map[string]CollectorStatus
+}
+
+// NewTracker tworzy tracker dla agenta zbierającego dane co interval.
+// Dane są uznawane za nieaktualne po trzech interwałach bez powodzenia.
+func NewTracker(interval time.Duration) *Tracker {
+	t := &Tracker{
+		now:        time.Now,
+		collectors: make(map[string]CollectorStatus),
+	}
+	t.startedAt = t.now()
+	t.SetInterval(interval)
+	return t
+}
+
+// SetInterval zmienia interwał zbierania danych, z którego wynika próg nieaktualności
+func (t *Tracker) SetInterval(interval time.Duration) {
+	t.SetStaleAfter(staleMultiplier * interval)
+}
+
+// SetStaleAfter ustawia czas, po którym brak udanego zbierania lub wysyłania
+// oznacza niesprawnego agenta
+func (t *Tracker) SetStaleAfter(staleAfter time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.staleAfter = staleAfter
+}
+
+// SetSpool ustawia kolejkę, której głębokość jest raportowana
+func (t *Tracker) SetSpool(spool SpoolInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spool = spool
+}
+
+// RecordCollection zapisuje wynik zbierania danych
+func (t *Tracker) RecordCollection(state *models.SystemState, duration time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if state != nil && state.Collection != nil {
+		collectors := make(map[string]CollectorStatus, len(state.Collection.Durations))
+		for name, seconds := range state.Collection.Durations {
+			message, failed := state.Collection.Errors[name]
+			collectors[name] = CollectorStatus{
+				OK:              !failed,
+				Error:           message,
+				DurationSeconds: seconds,
+			}
+		}
+		t.collectors = collectors
+	}
+
+	if err != nil {
+		t.lastCollectionError = err.Error()
+		return
+	}
+
+	t.lastCollection = t.now()
+	t.lastCollectionDuration = duration
+	t.lastCollectionError = ""
+}
+
+// RecordSend zapisuje wynik wysyłania stanu do VM Bridge
+func (t *Tracker) RecordSend(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err != nil {
+		t.lastSendError = err.Error()
+		t.lastSendErrorTime = t.now()
+		return
+	}
+
+	t.lastSend = t.now()
+	t.lastSendError = ""
+}
+
+// Status zwraca bieżący raport o stanie agenta. Kolejka jest odpytywana po
+// zwolnieniu blokady, bo odczyt katalogu kolejki nie może wstrzymywać rejestrowania
+// wyników zbierania i wysyłania.
+func (t *Tracker) Status() *Status {
+	t.mu.Lock()
+	status := t.status()
+	spool := t.spool
+	t.mu.Unlock()
+
+	if spool != nil {
+		status.SpoolDepth = spool.Len()
+		status.SpoolBytes = spool.Size()
+	}
+	return status
+}
+
+// status buduje raport z pól trackera; wywołujący musi trzymać t.mu
+func (t *Tracker) status() *Status {
+	now := t.now()
+	status := &Status{
+		Status:                        StatusOK,
+		StartedAt:                     t.startedAt,
+		LastCollectionDurationSeconds: t.lastCollectionDuration.Seconds(),
+		LastCollectionError:           t.lastCollectionError,
+		LastSendError:                 t.lastSendError,
+		Collectors:                    make(map[string]CollectorStatus, len(t.collectors)),
+	}
+	for name, collector := range t.collectors {
+		status.Collectors[name] = collector
+	}
+	if !t.lastCollection.IsZero() {
+		lastCollection := t.lastCollection
+		status.LastCollection = &lastCollection
+	}
+	if !t.lastSend.IsZero() {
+		lastSend := t.lastSend
+		status.LastSend = &lastSend
+	}
+	if !t.lastSendErrorTime.IsZero() {
+		lastSendErrorTime := t.lastSendErrorTime
+		status.LastSendErrorTime = &lastSendErrorTime
+	}
+	// Czas od ostatniego powodzenia; przed pierwszym liczony od uruchomienia agenta
+	sinceCollection := now.Sub(t.startedAt)
+	if !t.lastCollection.IsZero() {
+		sinceCollection = now.Sub(t.lastCollection)
+	}
+	sinceSend := now.Sub(t.startedAt)
+	if !t.lastSend.IsZero() {
+		sinceSend = now.Sub(t.lastSend)
+	}
+
+	if t.staleAfter > 0 && sinceCollection > t.staleAfter {
+		status.Problems = append(status.Problems, fmt.Sprintf("brak udanego zbierania danych od %v", sinceCollection.Truncate(time.Second)))
+	}
+	if t.staleAfter > 0 && sinceSend > t.staleAfter {
+		status.Problems = append(status.Problems, fmt.Sprintf("brak udanego wysłania stanu od %v", sinceSend.Truncate(time.Second)))
+	}
+
+	switch {
+	case len(status.Problems) > 0:
+		status.Status = StatusStale
+	case t.lastCollection.IsZero():
+		status.Status = StatusStarting
+	}
+
+	sort.Strings(status.Problems)
+	return status
+}
+
+// ServeHTTP zwraca raport o stanie agenta w JSON: kod 200, gdy agent działa
+// poprawnie (lub dopiero się uruchamia), i 503, gdy dane są nieaktualne
+func (t *Tracker) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status := t.Status()
+
+	code := http.StatusOK
+	if status.Status == StatusStale {
+		code = http.StatusServiceUnavailable
+	}
+	writeStatus(w, code, status)
+}
+
+// ReadyHandler zwraca handler gotowości: kod 200 dopiero po pierwszym udanym
+// zbieraniu danych, dopóki agent nie jest niesprawny
+func (t *Tracker) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := t.Status()
+
+		code := http.StatusOK
+		if !status.Healthy() {
+			code = http.StatusServiceUnavailable
+		}
+		writeStatus(w, code, status)
+	})
+}
+
+// writeStatus zapisuje raport o stanie agenta jako odpowiedź JSON
+func writeStatus(w http.ResponseWriter, code int, status *Status) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,171 @@
+package health
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// fakeClock to sterowany zegar dla testów
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// fakeSpool to kolejka o stałej głębokości
+type fakeSpool struct{}
+
+func (fakeSpool) Len() int    { return 3 }
+func (fakeSpool) Size() int64 { return 300 }
+
+// newTestTracker tworzy tracker ze sterowanym zegarem
+func newTestTracker(interval time.Duration) (*Tracker, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	tracker := NewTracker(interval)
+	tracker.now = clock.Now
+	tracker.startedAt = clock.Now()
+	return tracker, clock
+}
+
+func TestTrackerLifecycle(t *testing.T) {
+	tracker, clock := newTestTracker(10 * time.Second)
+	tracker.SetSpool(fakeSpool{})
+
+	if status := tracker.Status(); status.Status != StatusStarting {
+		t.Errorf("Niepoprawny status przed pierwszym zbieraniem: got %v, want %v", status.Status, StatusStarting)
+	}
+
+	state := &models.SystemState{
+		Collection: &models.CollectionReport{
+			Durations: map[string]float64{"hardware": 0.2, "docker": 30},
+			Errors:    map[string]string{"docker": "przekroczono limit czasu"},
+		},
+	}
+	clock.Advance(5 * time.Second)
+	tracker.RecordCollection(state, 2*time.Second, nil)
+	tracker.RecordSend(nil)
+
+	status := tracker.Status()
+	if status.Status != StatusOK {
+		t.Errorf("Niepoprawny status po udanym zbieraniu: got %v, want %v (%v)", status.Status, StatusOK, status.Problems)
+	}
+	if status.LastCollectionDurationSeconds != 2 {
+		t.Errorf("Niepoprawny czas zbierania: got %v, want %v", status.LastCollectionDurationSeconds, 2)
+	}
+	if status.SpoolDepth != 3 || status.SpoolBytes != 300 {
+		t.Errorf("Niepoprawna głębokość kolejki: got %v (%v B)", status.SpoolDepth, status.SpoolBytes)
+	}
+	if docker := status.Collectors["docker"]; docker.OK || docker.Error == "" {
+		t.Errorf("Niepoprawny status kolektora docker: got %+v", docker)
+	}
+	if hardware := status.Collectors["hardware"]; !hardware.OK {
+		t.Errorf("Niepoprawny status kolektora hardware: got %+v", hardware)
+	}
+
+	// Wysyłanie przestaje działać: po trzech interwałach agent jest niesprawny
+	clock.Advance(20 * time.Second)
+	tracker.RecordCollection(state, time.Second, nil)
+	tracker.RecordSend(errors.New("connection refused"))
+	if status := tracker.Status(); status.Status != StatusOK {
+		t.Errorf("Niepoprawny status przed upływem progu: got %v (%v)", status.Status, status.Problems)
+	}
+
+	clock.Advance(15 * time.Second)
+	tracker.RecordCollection(state, time.Second, nil)
+	tracker.RecordSend(errors.New("connection refused"))
+	status = tracker.Status()
+	if status.Status != StatusStale {
+		t.Errorf("Niepoprawny status po upływie progu: got %v, want %v", status.Status, StatusStale)
+	}
+	if status.LastSendError != "connection refused" || len(status.Problems) != 1 {
+		t.Errorf("Niepoprawny opis problemu: got %q, %v", status.LastSendError, status.Problems)
+	}
+}
+
+// blockingSpool to kolejka, której odczyt trwa do zwolnienia, jak podczas odtwarzania
+type blockingSpool struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingSpool) Len() int {
+	close(s.entered)
+	<-s.release
+	return 1
+}
+func (s *blockingSpool) Size() int64 { return 100 }
+
+func TestTrackerStatusDoesNotHoldLockForSpool(t *testing.T) {
+	tracker, _ := newTestTracker(10 * time.Second)
+	spool := &blockingSpool{entered: make(chan struct{}), release: make(chan struct{})}
+	tracker.SetSpool(spool)
+
+	done := make(chan *Status)
+	go func() { done <- tracker.Status() }()
+	<-spool.entered
+
+	// Rejestrowanie wyników nie czeka na odczyt kolejki
+	recorded := make(chan struct{})
+	go func() {
+		tracker.RecordSend(nil)
+		close(recorded)
+	}()
+	select {
+	case <-recorded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("RecordSend zablokowany przez odczyt kolejki")
+	}
+
+	close(spool.release)
+	if status := <-done; status.SpoolDepth != 1 || status.SpoolBytes != 100 {
+		t.Errorf("Niepoprawna głębokość kolejki: got %v (%v B)", status.SpoolDepth, status.SpoolBytes)
+	}
+}
+
+func TestTrackerServeHTTP(t *testing.T) {
+	tracker, clock := newTestTracker(10 * time.Second)
+
+	get := func(handler http.Handler) (int, *Status) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+		var status Status
+		if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+			t.Fatalf("Nie można sparsować odpowiedzi: %v", err)
+		}
+		return recorder.Code, &status
+	}
+
+	// Podczas uruchamiania agent jest żywy, ale nie gotowy
+	if code, _ := get(tracker); code != http.StatusOK {
+		t.Errorf("Niepoprawny kod /health podczas uruchamiania: got %v, want %v", code, http.StatusOK)
+	}
+	if code, _ := get(tracker.ReadyHandler()); code != http.StatusServiceUnavailable {
+		t.Errorf("Niepoprawny kod /ready podczas uruchamiania: got %v, want %v", code, http.StatusServiceUnavailable)
+	}
+
+	tracker.RecordCollection(&models.SystemState{}, time.Second, nil)
+	tracker.RecordSend(nil)
+	if code, _ := get(tracker.ReadyHandler()); code != http.StatusOK {
+		t.Errorf("Niepoprawny kod /ready po zbieraniu: got %v, want %v", code, http.StatusOK)
+	}
+
+	// Agent przestał zbierać dane
+	clock.Advance(time.Minute)
+	code, status := get(tracker)
+	if code != http.StatusServiceUnavailable || status.Status != StatusStale {
+		t.Errorf("Niepoprawna odpowiedź dla nieaktualnych danych: got %v %v", code, status.Status)
+	}
+}
@@ -1,18 +1,18 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
-	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"gitlab.com/safetytwin/safetytwin/agent/collectors"
-	"gitlab.com/safetytwin/safetytwin/agent/models"
 	"gitlab.com/safetytwin/safetytwin/agent/utils"
 )
 
@@ -23,14 +23,55 @@ const (
 )
 
 func main() {
+	// Pierwszy argument niebędący flagą wybiera polecenie; bez polecenia agent
+	// działa jak dotychczas (polecenie "collect")
+	command := "collect"
+	args := os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command = args[0]
+		args = args[1:]
+	}
+
+	switch command {
+	case "collect":
+		runCollect(args)
+	case "inspect":
+		runInspect(args)
+	case "diff":
+		runDiff(args)
+	case "help":
+		printUsage()
+	default:
+		fmt.Fprintf(os.Stderr, "Nieznane polecenie: %s\n\n", command)
+		printUsage()
+		os.Exit(2)
+	}
+}
+
+// printUsage wyświetla listę dostępnych poleceń
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "SafetyTwin Agent v%s\n\n", VERSION)
+	fmt.Fprintln(os.Stderr, "Użycie:")
+	fmt.Fprintln(os.Stderr, "  safetytwin-agent [collect] [flagi]          zbieranie danych (domyślnie)")
+	fmt.Fprintln(os.Stderr, "  safetytwin-agent inspect [flagi] <stan>     podsumowanie zapisanego stanu")
+	fmt.Fprintln(os.Stderr, "  safetytwin-agent diff [flagi] <stan> <stan> zmiany między dwoma stanami")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "Szczegóły flag: safetytwin-agent <polecenie> -h")
+}
+
+// runCollect uruchamia zbieranie danych: jednorazowo (-output) lub w pętli
+func runCollect(args []string) {
 	// Wyświetl banner
 	log.Printf("SafetyTwin Agent v%s", VERSION)
 	// Parsowanie flag wiersza poleceń
-	configPath := flag.String("config", "", "Ścieżka do pliku konfiguracyjnego")
-	outputFile := flag.String("output", "", "Plik wyjściowy dla danych JSON (opcjonalny)")
-	pretty := flag.Bool("pretty", false, "Formatuj JSON w sposób czytelny dla człowieka")
-	version := flag.Bool("version", false, "Wyświetl informacje o wersji i zakończ")
-	flag.Parse()
+	flags := flag.NewFlagSet("collect", flag.ExitOnError)
+	configPath := flags.String("config", "", "Ścieżka do pliku konfiguracyjnego")
+	outputFile := flags.String("output", "", "Plik wyjściowy dla danych JSON (opcjonalny)")
+	pretty := flags.Bool("pretty", false, "Formatuj JSON w sposób czytelny dla człowieka")
+	version := flags.Bool("version", false, "Wyświetl informacje o wersji i zakończ")
+	printConfig := flags.Bool("print-config", false, "Wyświetl wynikową konfigurację i zakończ")
+	configFlags := utils.NewConfigFlags(flags)
+	flags.Parse(args)
 
 	// Wyświetl wersję i zakończ, jeśli podano flagę -version
 	if *version {
@@ -39,60 +80,67 @@ func main() {
 		os.Exit(0)
 	}
 
-	// Tryb jednorazowy (dla pliku wyjściowego)
-	if *outputFile != "" {
-		runSingleCollection(*outputFile, *pretty)
-		return
+	// Wczytanie konfiguracji: wartości domyślne, plik, zmienne środowiskowe, flagi.
+	// Tryb jednorazowy korzysta z tej samej konfiguracji co tryb ciągły.
+	*configPath = resolveConfigPath(*configPath)
+	config, err := loadConfig(*configPath, configFlags)
+	if err != nil {
+		log.Fatalf("Błąd wczytywania konfiguracji: %v", err)
 	}
 
-	// Wczytanie konfiguracji
-	var config *utils.Config
-	var err error
-
-	if *configPath != "" {
-		config, err = utils.LoadConfig(*configPath)
+	// Wyświetl wynikową konfigurację i zakończ, jeśli podano flagę -print-config
+	if *printConfig {
+		jsonData, err := json.MarshalIndent(config, "", "  ")
 		if err != nil {
-			log.Fatalf("Błąd wczytywania konfiguracji: %v", err)
-		}
-	} else {
-		// Domyślna konfiguracja
-		config = &utils.Config{
-			Interval:         10,
-			BridgeURL:        "http://localhost:5678/api/v1/update_state",
-			LogFile:          "/var/log/safetytwin/agent.log",
-			StateDir:         "/var/lib/safetytwin/agent-states",
-			IncludeProcesses: true,
-			Verbose:          false,
+			log.Fatalf("Błąd podczas serializacji konfiguracji: %v", err)
 		}
+		fmt.Println(string(jsonData))
+		return
 	}
 
-	// Upewnij się, że katalogi istnieją
-	stateDir := filepath.Dir(config.StateDir)
-	logDir := filepath.Dir(config.LogFile)
-	if err := os.MkdirAll(stateDir, 0755); err != nil {
-		log.Fatalf("Nie można utworzyć katalogu stanów: %v", err)
+	if err := validateCollectors(config); err != nil {
+		log.Fatalf("Błąd konfiguracji kolektorów: %v", err)
 	}
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		log.Fatalf("Nie można utworzyć katalogu logów: %v", err)
+
+	// Tryb jednorazowy (dla pliku wyjściowego)
+	if *outputFile != "" {
+		runSingleCollection(config, *outputFile, *pretty)
+		return
+	}
+
+	// Upewnij się, że katalogi istnieją i można w nich zapisywać
+	if err := config.CheckDirectories(); err != nil {
+		log.Fatalf("Błąd konfiguracji katalogów: %v", err)
 	}
 
 	// Konfiguracja loggera
 	utils.ConfigureLogger(config.LogFile, config.Verbose)
 
-	// Przygotowanie nadawcy danych
-	sender := utils.NewSender(config.BridgeURL)
+	agent := newDaemon(*configPath, configFlags, config)
 
-	// Obsługa sygnałów
+	// Obsługa sygnałów; SIGHUP przeładowuje konfigurację
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 	stopChan := make(chan struct{})
+	reloadChan := make(chan struct{}, 1)
 
 	// Uruchom proces zbierania danych w osobnym wątku
-	go runDataCollection(config, sender, stopChan)
+	go agent.run(stopChan, reloadChan)
 
 	// Czekaj na sygnał zakończenia
-	sig := <-sigChan
-	log.Printf("Otrzymano sygnał: %v. Kończenie działania...", sig)
+	for sig := range sigChan {
+		if sig == syscall.SIGHUP {
+			select {
+			case reloadChan <- struct{}{}:
+			default:
+				// Przeładowanie jest już zaplanowane
+			}
+			continue
+		}
+
+		log.Printf("Otrzymano sygnał: %v. Kończenie działania...", sig)
+		break
+	}
 	close(stopChan)
 
 	// Daj czas na zakończenie wątków
@@ -101,15 +149,16 @@ func main() {
 }
 
 // runSingleCollection wykonuje jednorazowe zbieranie danych i zapisuje je do pliku
-func runSingleCollection(outputFile string, pretty bool) {
+func runSingleCollection(config *utils.Config, outputFile string, pretty bool) {
 	fmt.Println("Rozpoczynam zbieranie informacji o systemie...")
 	startTime := time.Now()
 
-	// Utwórz kolektor systemu
-	systemCollector := collectors.NewSystemCollector()
+	// Utwórz kolektor systemu skonfigurowany tak jak w trybie ciągłym
+	systemCollector := collectors.NewSystemCollector(config.EnabledCollectors(collectors.Registered())...)
+	configureSystemCollector(systemCollector, config)
 
 	// Zbierz informacje o systemie
-	systemState, err := systemCollector.Collect()
+	systemState, err := systemCollector.Collect(context.Background())
 	if err != nil {
 		fmt.Printf("Błąd podczas zbierania informacji o systemie: %v\n", err)
 		os.Exit(1)
@@ -119,39 +168,7 @@ func runSingleCollection(outputFile string, pretty bool) {
 	fmt.Printf("Zbieranie informacji zakończone w %v\n\n", elapsedTime)
 
 	// Wyświetl podstawowe informacje
-	fmt.Println("Podstawowe informacje o systemie:")
-	fmt.Printf("Hostname: %s\n", systemState.Hardware.Hostname)
-	fmt.Printf("Platform: %s %s\n", systemState.Hardware.Platform, systemState.Hardware.PlatformVersion)
-	fmt.Printf("Kernel: %s\n", systemState.Hardware.KernelVersion)
-	fmt.Printf("CPU: %s (%d rdzeni fizycznych, %d rdzeni logicznych)\n", 
-		systemState.Hardware.CPU.Model, 
-		systemState.Hardware.CPU.PhysicalCores, 
-		systemState.Hardware.CPU.LogicalCores)
-	fmt.Printf("Pamięć: %.2f GB (użycie: %.1f%%)\n", 
-		systemState.Hardware.Memory.TotalGB, 
-		systemState.Hardware.Memory.Percent)
-	fmt.Printf("Liczba dysków: %d\n", len(systemState.Hardware.Disks))
-	fmt.Printf("Liczba interfejsów sieciowych: %d\n", len(systemState.Hardware.Network))
-	fmt.Printf("Liczba procesów: %d\n", len(systemState.Processes))
-	fmt.Printf("Liczba usług: %d\n", len(systemState.Services))
-
-	// Policz procesy i usługi związane z LLM
-	llmProcesses := 0
-	for _, proc := range systemState.Processes {
-		if proc.IsLLMRelated {
-			llmProcesses++
-		}
-	}
-
-	llmServices := 0
-	for _, svc := range systemState.Services {
-		if svc.IsLLMRelated {
-			llmServices++
-		}
-	}
-
-	fmt.Printf("Procesy związane z LLM: %d\n", llmProcesses)
-	fmt.Printf("Usługi związane z LLM: %d\n", llmServices)
+	printStateSummary(os.Stdout, systemState)
 
 	// Zapisz dane do pliku
 	var jsonData []byte
@@ -176,58 +193,17 @@ func runSingleCollection(outputFile string, pretty bool) {
 	fmt.Printf("Dane zapisane do pliku: %s\n", outputFile)
 }
 
-// runDataCollection uruchamia proces zbierania danych w pętli
-func runDataCollection(config *utils.Config, sender *utils.Sender, stopChan <-chan struct{}) {
-	// Interwał zbierania danych
-	interval := time.Duration(config.Interval) * time.Second
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	log.Printf("Agent uruchomiony. Interwał zbierania danych: %d sekund", config.Interval)
-
-	// Natychmiastowe pierwsze zbieranie
-	collectAndSendState(config, sender)
-
-	// Główna pętla zbierania danych
-	for {
-		select {
-		case <-ticker.C:
-			collectAndSendState(config, sender)
-		case <-stopChan:
-			log.Println("Zatrzymanie procesu zbierania danych")
-			return
-		}
+// resolveConfigPath zwraca ścieżkę pliku konfiguracyjnego z flagi -config
+// lub, jeśli jej nie podano, ze zmiennej SAFETYTWIN_CONFIG
+func resolveConfigPath(path string) string {
+	if path != "" {
+		return path
 	}
+	return os.Getenv(utils.EnvName("config"))
 }
 
-// collectAndSendState zbiera i wysyła stan systemu
-func collectAndSendState(config *utils.Config, sender *utils.Sender) {
-	startTime := time.Now()
-	log.Println("Rozpoczęcie zbierania danych o systemie...")
-
-	// Utwórz kolektor systemu
-	systemCollector := collectors.NewSystemCollector()
-
-	// Zbierz informacje o systemie
-	systemState, err := systemCollector.Collect()
-	if err != nil {
-		log.Printf("Błąd podczas zbierania informacji o systemie: %v", err)
-		return
-	}
-
-	// Zapisanie stanu do pliku
-	if err := utils.SaveStateToFile(systemState, config.StateDir); err != nil {
-		log.Printf("Błąd zapisu stanu do pliku: %v", err)
-	}
-
-	// Wysłanie stanu do VM Bridge
-	if err := sender.SendState(systemState); err != nil {
-		log.Printf("Błąd wysyłania stanu do VM Bridge: %v", err)
-	} else {
-		log.Printf("Stan systemu pomyślnie wysłany do VM Bridge")
-	}
-
-	// Raportuj czas trwania
-	elapsedTime := time.Since(startTime)
-	log.Printf("Zbieranie danych zakończone. Czas trwania: %v", elapsedTime)
+// loadConfig ustala konfigurację agenta: wartości domyślne nadpisane kolejno
+// plikiem konfiguracyjnym (jeśli podano ścieżkę), zmiennymi SAFETYTWIN_* i flagami
+func loadConfig(path string, configFlags *utils.ConfigFlags) (*utils.Config, error) {
+	return utils.ResolveConfig(path, os.Environ(), configFlags)
 }
@@ -0,0 +1,599 @@
+// Package metrics udostępnia ostatni zebrany stan systemu w formacie tekstowym
+// Prometheus, dzięki czemu hosty bliźniaka mogą być odpytywane bezpośrednio.
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// ContentType to typ zawartości formatu tekstowego Prometheus
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// bytesPerGB przelicza wartości w GB zapisywane przez kolektory na bajty
+const bytesPerGB = 1024 * 1024 * 1024
+
+// Exporter przechowuje ostatni stan systemu i udostępnia go jako metryki Prometheus
+type Exporter struct {
+	mu      sync.RWMutex
+	state   *models.SystemState
+	updated time.Time
+}
+
+// NewExporter tworzy nowy eksporter metryk
+func NewExporter() *Exporter {
+	return &Exporter{}
+}
+
+// Update zastępuje stan systemu udostępniany przez eksporter
+func (e *Exporter) Update(state *models.SystemState) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.state = state
+	e.updated = time.Now()
+}
+
+// ServeHTTP obsługuje żądania Prometheus (zwykle pod ścieżką /metrics)
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	state := e.state
+	updated := e.updated
+	e.mu.RUnlock()
+
+	if state == nil {
+		http.Error(w, "Brak zebranych danych o systemie", http.StatusServiceUnavailable)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetrics(&buf, state); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeFamily(&buf, family{
+		name:    "safetytwin_last_update_timestamp_seconds",
+		help:    "Czas ostatniej aktualizacji stanu w eksporterze (Unix)",
+		kind:    "gauge",
+		samples: []sample{{value: float64(updated.UnixNano()) / 1e9}},
+	})
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Write(buf.Bytes())
+}
+
+// family opisuje rodzinę metryk o wspólnej nazwie
+type family struct {
+	name    string
+	help    string
+	kind    string // gauge lub counter
+	samples []sample
+}
+
+// sample to pojedyncza wartość metryki z etykietami
+type sample struct {
+	labels []label
+	value  float64
+}
+
+// label to para nazwa-wartość etykiety
+type label struct {
+	name  string
+	value string
+}
+
+// WriteMetrics zapisuje stan systemu w formacie tekstowym Prometheus
+func WriteMetrics(w io.Writer, state *models.SystemState) error {
+	families := make([]family, 0)
+	families = append(families, hostFamilies(state)...)
+	if state.Hardware != nil {
+		families = append(families, cpuFamilies(state.Hardware.CPU)...)
+		families = append(families, memoryFamilies(state.Hardware.Memory)...)
+		families = append(families, diskFamilies(state.Hardware.Disks)...)
+		families = append(families, networkFamilies(state.Hardware.Network)...)
+		families = append(families, gpuFamilies(state.Hardware.GPU)...)
+		families = append(families, kernelFamilies(state.Hardware.Kernel)...)
+		families = append(families, sensorFamilies(state.Hardware.Sensors)...)
+	}
+	families = append(families, processFamilies(state.Processes)...)
+	families = append(families, serviceFamilies(state.Services)...)
+	families = append(families, modelFamilies(state.Models)...)
+	families = append(families, inferenceFamilies(state.InferenceServers)...)
+	families = append(families, collectorFamilies(state.Collection)...)
+
+	var buf bytes.Buffer
+	for _, f := range families {
+		writeFamily(&buf, f)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// hostFamilies zwraca metryki opisujące host
+func hostFamilies(state *models.SystemState) []family {
+	if state.Hardware == nil {
+		return nil
+	}
+	hw := state.Hardware
+
+	return []family{
+		{
+			name: "safetytwin_host_info",
+			help: "Informacje o hoście (wartość zawsze 1)",
+			kind: "gauge",
+			samples: []sample{{
+				labels: []label{
+					{"hostname", hw.Hostname},
+					{"platform", hw.Platform},
+					{"platform_version", hw.PlatformVersion},
+					{"kernel", hw.KernelVersion},
+				},
+				value: 1,
+			}},
+		},
+		{
+			name:    "safetytwin_host_uptime_seconds",
+			help:    "Czas działania hosta w sekundach",
+			kind:    "gauge",
+			samples: []sample{{value: float64(hw.Uptime)}},
+		},
+	}
+}
+
+// cpuFamilies zwraca metryki procesora
+func cpuFamilies(cpu *models.CPU) []family {
+	if cpu == nil {
+		return nil
+	}
+
+	cores := make([]sample, 0, len(cpu.PerCPU))
+	for i, core := range cpu.PerCPU {
+		cores = append(cores, sample{labels: []label{{"core", strconv.Itoa(i)}}, value: core.UsagePercent})
+	}
+
+	return []family{
+		{name: "safetytwin_cpu_usage_percent", help: "Całkowite użycie CPU w procentach", kind: "gauge", samples: []sample{{value: cpu.UsagePercent}}},
+		{name: "safetytwin_cpu_core_usage_percent", help: "Użycie rdzenia CPU w procentach", kind: "gauge", samples: cores},
+		{name: "safetytwin_cpu_logical_cores", help: "Liczba rdzeni logicznych", kind: "gauge", samples: []sample{{value: float64(cpu.LogicalCores)}}},
+	}
+}
+
+// memoryFamilies zwraca metryki pamięci
+func memoryFamilies(memory *models.Memory) []family {
+	if memory == nil {
+		return nil
+	}
+
+	return []family{
+		{name: "safetytwin_memory_total_bytes", help: "Całkowita pamięć w bajtach", kind: "gauge", samples: []sample{{value: memory.TotalGB * bytesPerGB}}},
+		{name: "safetytwin_memory_available_bytes", help: "Dostępna pamięć w bajtach", kind: "gauge", samples: []sample{{value: memory.AvailableGB * bytesPerGB}}},
+		{name: "safetytwin_memory_used_bytes", help: "Używana pamięć w bajtach", kind: "gauge", samples: []sample{{value: memory.UsedGB * bytesPerGB}}},
+		{name: "safetytwin_memory_usage_percent", help: "Użycie pamięci w procentach", kind: "gauge", samples: []sample{{value: memory.Percent}}},
+		{name: "safetytwin_swap_total_bytes", help: "Całkowita przestrzeń wymiany w bajtach", kind: "gauge", samples: []sample{{value: memory.SwapTotalGB * bytesPerGB}}},
+		{name: "safetytwin_swap_used_bytes", help: "Używana przestrzeń wymiany w bajtach", kind: "gauge", samples: []sample{{value: memory.SwapUsedGB * bytesPerGB}}},
+	}
+}
+
+// diskFamilies zwraca metryki dysków z etykietami device, mountpoint i fstype
+func diskFamilies(disks []models.Disk) []family {
+	total := make([]sample, 0, len(disks))
+	used := make([]sample, 0, len(disks))
+	free := make([]sample, 0, len(disks))
+	percent := make([]sample, 0, len(disks))
+
+	for _, disk := range disks {
+		labels := []label{{"device", disk.Device}, {"mountpoint", disk.Mountpoint}, {"fstype", disk.Fstype}}
+		total = append(total, sample{labels: labels, value: disk.TotalGB * bytesPerGB})
+		used = append(used, sample{labels: labels, value: disk.UsedGB * bytesPerGB})
+		free = append(free, sample{labels: labels, value: disk.FreeGB * bytesPerGB})
+		percent = append(percent, sample{labels: labels, value: disk.Percent})
+	}
+
+	return []family{
+		{name: "safetytwin_disk_total_bytes", help: "Rozmiar systemu plików w bajtach", kind: "gauge", samples: total},
+		{name: "safetytwin_disk_used_bytes", help: "Zajęte miejsce w bajtach", kind: "gauge", samples: used},
+		{name: "safetytwin_disk_free_bytes", help: "Wolne miejsce w bajtach", kind: "gauge", samples: free},
+		{name: "safetytwin_disk_usage_percent", help: "Zajętość systemu plików w procentach", kind: "gauge", samples: percent},
+	}
+}
+
+// networkFamilies zwraca liczniki interfejsów sieciowych z etykietą interface
+func networkFamilies(network map[string]models.NetworkInterface) []family {
+	names := make([]string, 0, len(network))
+	for name := range network {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	counters := []struct {
+		name  string
+		help  string
+		value func(models.NetworkInterface) uint64
+	}{
+		{"safetytwin_network_sent_bytes_total", "Bajty wysłane przez interfejs", func(n models.NetworkInterface) uint64 { return n.BytesSent }},
+		{"safetytwin_network_received_bytes_total", "Bajty odebrane przez interfejs", func(n models.NetworkInterface) uint64 { return n.BytesRecv }},
+		{"safetytwin_network_sent_packets_total", "Pakiety wysłane przez interfejs", func(n models.NetworkInterface) uint64 { return n.PacketsSent }},
+		{"safetytwin_network_received_packets_total", "Pakiety odebrane przez interfejs", func(n models.NetworkInterface) uint64 { return n.PacketsRecv }},
+		{"safetytwin_network_receive_errors_total", "Błędy odbioru na interfejsie", func(n models.NetworkInterface) uint64 { return n.Errin }},
+		{"safetytwin_network_transmit_errors_total", "Błędy wysyłania na interfejsie", func(n models.NetworkInterface) uint64 { return n.Errout }},
+		{"safetytwin_network_receive_drops_total", "Pakiety odrzucone przy odbiorze", func(n models.NetworkInterface) uint64 { return n.Dropin }},
+		{"safetytwin_network_transmit_drops_total", "Pakiety odrzucone przy wysyłaniu", func(n models.NetworkInterface) uint64 { return n.Dropout }},
+	}
+
+	families := make([]family, 0, len(counters))
+	for _, counter := range counters {
+		samples := make([]sample, 0, len(names))
+		for _, name := range names {
+			samples = append(samples, sample{labels: []label{{"interface", name}}, value: float64(counter.value(network[name]))})
+		}
+		families = append(families, family{name: counter.name, help: counter.help, kind: "counter", samples: samples})
+	}
+
+	return families
+}
+
+// gpuFamilies zwraca metryki kart graficznych z etykietami vendor, index i name
+func gpuFamilies(gpus map[string][]models.GPUDevice) []family {
+	vendors := make([]string, 0, len(gpus))
+	for vendor := range gpus {
+		vendors = append(vendors, vendor)
+	}
+	sort.Strings(vendors)
+
+	utilization := make([]sample, 0)
+	memoryUsed := make([]sample, 0)
+	memoryTotal := make([]sample, 0)
+	temperature := make([]sample, 0)
+
+	for _, vendor := range vendors {
+		for _, gpu := range gpus[vendor] {
+			labels := []label{{"vendor", vendor}, {"index", strconv.Itoa(gpu.Index)}, {"name", gpu.Name}}
+			utilization = append(utilization, sample{labels: labels, value: gpu.UtilizationGPU})
+			memoryUsed = append(memoryUsed, sample{labels: labels, value: gpu.MemoryUsedMB * 1024 * 1024})
+			memoryTotal = append(memoryTotal, sample{labels: labels, value: gpu.MemoryTotalMB * 1024 * 1024})
+			temperature = append(temperature, sample{labels: labels, value: gpu.Temperature})
+		}
+	}
+
+	return []family{
+		{name: "safetytwin_gpu_utilization_percent", help: "Wykorzystanie GPU w procentach", kind: "gauge", samples: utilization},
+		{name: "safetytwin_gpu_memory_used_bytes", help: "Używana pamięć GPU w bajtach", kind: "gauge", samples: memoryUsed},
+		{name: "safetytwin_gpu_memory_total_bytes", help: "Całkowita pamięć GPU w bajtach", kind: "gauge", samples: memoryTotal},
+		{name: "safetytwin_gpu_temperature_celsius", help: "Temperatura GPU w stopniach Celsjusza", kind: "gauge", samples: temperature},
+	}
+}
+
+// kernelFamilies zwraca metryki obciążenia jądra: load average, liczniki vmstat i PSI
+// z etykietami resource i kind
+func kernelFamilies(kernel *models.KernelStats) []family {
+	if kernel == nil {
+		return nil
+	}
+
+	resources := make([]string, 0, len(kernel.Pressure))
+	for resource := range kernel.Pressure {
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	pressure := make([]sample, 0)
+	for _, resource := range resources {
+		stats := kernel.Pressure[resource]
+		for _, p := range []struct {
+			kind     string
+			pressure *models.Pressure
+		}{{"some", stats.Some}, {"full", stats.Full}} {
+			if p.pressure != nil {
+				pressure = append(pressure, sample{labels: []label{{"resource", resource}, {"kind", p.kind}}, value: p.pressure.Avg10})
+			}
+		}
+	}
+
+	load := []sample{
+		{labels: []label{{"period", "1m"}}, value: kernel.LoadAvg1},
+		{labels: []label{{"period", "5m"}}, value: kernel.LoadAvg5},
+		{labels: []label{{"period", "15m"}}, value: kernel.LoadAvg15},
+	}
+
+	return []family{
+		{name: "safetytwin_kernel_load_average", help: "Średnie obciążenie systemu", kind: "gauge", samples: load},
+		{name: "safetytwin_kernel_procs_running", help: "Liczba procesów gotowych do działania", kind: "gauge", samples: []sample{{value: float64(kernel.ProcsRunning)}}},
+		{name: "safetytwin_kernel_procs_blocked", help: "Liczba procesów zablokowanych na operacjach I/O", kind: "gauge", samples: []sample{{value: float64(kernel.ProcsBlocked)}}},
+		{name: "safetytwin_kernel_context_switches_total", help: "Przełączenia kontekstu od startu systemu", kind: "counter", samples: []sample{{value: float64(kernel.ContextSwitches)}}},
+		{name: "safetytwin_kernel_major_page_faults_total", help: "Poważne błędy stron od startu systemu", kind: "counter", samples: []sample{{value: float64(kernel.MajorPageFaults)}}},
+		{name: "safetytwin_kernel_oom_kills_total", help: "Procesy zabite przez OOM killera od startu systemu", kind: "counter", samples: []sample{{value: float64(kernel.OOMKills)}}},
+		{name: "safetytwin_kernel_pressure_avg10_percent", help: "Udział czasu oczekiwania na zasób w ostatnich 10 s (PSI)", kind: "gauge", samples: pressure},
+	}
+}
+
+// sensorFamilies zwraca metryki czujników z etykietami chip, device i sensor oraz moc
+// i energię stref RAPL z etykietą domain
+func sensorFamilies(sensors *models.Sensors) []family {
+	if sensors == nil {
+		return nil
+	}
+
+	readings := func(list []models.SensorReading) []sample {
+		samples := make([]sample, 0, len(list))
+		for _, r := range list {
+			samples = append(samples, sample{labels: []label{{"chip", r.Chip}, {"device", r.Device}, {"sensor", r.Label}}, value: r.Value})
+		}
+		return samples
+	}
+
+	temperatures := append(readings(sensors.Temperatures), readings(sensors.ThermalZones)...)
+	watts := make([]sample, 0, len(sensors.Power))
+	energy := make([]sample, 0, len(sensors.Power))
+	for _, domain := range sensors.Power {
+		labels := []label{{"domain", domain.Name}}
+		watts = append(watts, sample{labels: labels, value: domain.Watts})
+		energy = append(energy, sample{labels: labels, value: domain.EnergyJoules})
+	}
+
+	return []family{
+		{name: "safetytwin_sensor_temperature_celsius", help: "Temperatura czujnika w stopniach Celsjusza", kind: "gauge", samples: temperatures},
+		{name: "safetytwin_sensor_fan_rpm", help: "Prędkość wentylatora w obrotach na minutę", kind: "gauge", samples: readings(sensors.Fans)},
+		{name: "safetytwin_sensor_voltage_volts", help: "Napięcie zasilania w woltach", kind: "gauge", samples: readings(sensors.Voltages)},
+		{name: "safetytwin_power_watts", help: "Średnia moc domeny RAPL od poprzedniego zbierania", kind: "gauge", samples: watts},
+		{name: "safetytwin_power_energy_joules_total", help: "Licznik energii domeny RAPL w dżulach", kind: "counter", samples: energy},
+	}
+}
+
+// processFamilies zwraca metryki procesów z etykietami pid, name i llm
+func processFamilies(processes []models.Process) []family {
+	cpu := make([]sample, 0, len(processes))
+	rss := make([]sample, 0, len(processes))
+	memory := make([]sample, 0, len(processes))
+	threads := make([]sample, 0, len(processes))
+	gpuMemory := make([]sample, 0)
+	llmInfo := make([]sample, 0)
+
+	for _, proc := range processes {
+		labels := []label{{"pid", strconv.Itoa(int(proc.PID))}, {"name", proc.Name}, {"llm", strconv.FormatBool(proc.IsLLMRelated)}}
+		cpu = append(cpu, sample{labels: labels, value: proc.CPUPercent})
+		memory = append(memory, sample{labels: labels, value: float64(proc.MemoryPercent)})
+		threads = append(threads, sample{labels: labels, value: float64(proc.NumThreads)})
+		if proc.MemoryInfo != nil {
+			rss = append(rss, sample{labels: labels, value: float64(proc.MemoryInfo.RSS)})
+		}
+		for _, gpu := range proc.GPU {
+			gpuLabels := append(append([]label{}, labels...), label{"vendor", gpu.Vendor}, label{"index", strconv.Itoa(gpu.Index)})
+			gpuMemory = append(gpuMemory, sample{labels: gpuLabels, value: gpu.MemoryUsedMB * 1024 * 1024})
+		}
+		if proc.LLM != nil {
+			llmLabels := []label{{"pid", strconv.Itoa(int(proc.PID))}, {"name", proc.Name}, {"category", proc.LLM.Category}, {"rule", proc.LLM.Rule}}
+			llmInfo = append(llmInfo, sample{labels: llmLabels, value: 1})
+		}
+	}
+
+	return []family{
+		{name: "safetytwin_process_cpu_percent", help: "Użycie CPU przez proces w procentach", kind: "gauge", samples: cpu},
+		{name: "safetytwin_process_resident_memory_bytes", help: "Pamięć rezydentna procesu (RSS) w bajtach", kind: "gauge", samples: rss},
+		{name: "safetytwin_process_memory_percent", help: "Użycie pamięci przez proces w procentach", kind: "gauge", samples: memory},
+		{name: "safetytwin_process_threads", help: "Liczba wątków procesu", kind: "gauge", samples: threads},
+		{name: "safetytwin_process_gpu_memory_bytes", help: "Pamięć GPU zajęta przez proces w bajtach", kind: "gauge", samples: gpuMemory},
+		{name: "safetytwin_process_llm_info", help: "Kategoria i reguła klasyfikacji procesu związanego z LLM", kind: "gauge", samples: llmInfo},
+	}
+}
+
+// modelFamilies zwraca rozmiary plików modeli używanych przez procesy. Ta sama
+// ścieżka w różnych kontenerach to różne pliki, więc etykieta container zawiera
+// identyfikatory kontenerów używających pliku (pusta dla plików hosta).
+func modelFamilies(artifacts []models.ModelArtifact) []family {
+	size := make([]sample, 0, len(artifacts))
+	for _, artifact := range artifacts {
+		architecture, quantization := "", ""
+		if artifact.GGUF != nil {
+			architecture, quantization = artifact.GGUF.Architecture, artifact.GGUF.Quantization
+		}
+		labels := []label{{"path", artifact.Path}, {"container", strings.Join(artifact.Containers, ",")}, {"format", artifact.Format}, {"architecture", architecture}, {"quantization", quantization}}
+		size = append(size, sample{labels: labels, value: float64(artifact.SizeBytes)})
+	}
+
+	return []family{
+		{name: "safetytwin_model_size_bytes", help: "Rozmiar pliku modelu używanego przez procesy w bajtach", kind: "gauge", samples: size},
+	}
+}
+
+// inferenceFamilies zwraca metryki serwerów inferencji: załadowane modele, kolejkę i przepustowość
+func inferenceFamilies(servers []models.InferenceServer) []family {
+	loaded := make([]sample, 0)
+	running := make([]sample, 0)
+	queue := make([]sample, 0)
+	promptTokens := make([]sample, 0)
+	generationTokens := make([]sample, 0)
+	generationRate := make([]sample, 0)
+
+	for _, server := range servers {
+		labels := []label{{"endpoint", server.Endpoint}, {"kind", server.Kind}}
+		for _, model := range server.Models {
+			if model.Loaded {
+				modelLabels := append(append([]label(nil), labels...), label{"model", model.Name}, label{"quantization", model.Quantization})
+				loaded = append(loaded, sample{labels: modelLabels, value: float64(model.ContextLength)})
+			}
+		}
+		if server.Metrics == nil {
+			continue
+		}
+		running = append(running, sample{labels: labels, value: server.Metrics.RunningRequests})
+		queue = append(queue, sample{labels: labels, value: server.Metrics.QueueDepth})
+		promptTokens = append(promptTokens, sample{labels: labels, value: server.Metrics.PromptTokensTotal})
+		generationTokens = append(generationTokens, sample{labels: labels, value: server.Metrics.GenerationTokensTotal})
+		generationRate = append(generationRate, sample{labels: labels, value: server.Metrics.GenerationTokensPerSecond})
+	}
+
+	return []family{
+		{name: "safetytwin_inference_model_context_length", help: "Rozmiar kontekstu modelu załadowanego w serwerze inferencji", kind: "gauge", samples: loaded},
+		{name: "safetytwin_inference_running_requests", help: "Liczba żądań obsługiwanych przez serwer inferencji", kind: "gauge", samples: running},
+		{name: "safetytwin_inference_queue_depth", help: "Liczba żądań oczekujących w kolejce serwera inferencji", kind: "gauge", samples: queue},
+		{name: "safetytwin_inference_prompt_tokens_total", help: "Liczba tokenów wejściowych przetworzonych przez serwer inferencji", kind: "counter", samples: promptTokens},
+		{name: "safetytwin_inference_generation_tokens_total", help: "Liczba tokenów wygenerowanych przez serwer inferencji", kind: "counter", samples: generationTokens},
+		{name: "safetytwin_inference_generation_tokens_per_second", help: "Liczba tokenów generowanych na sekundę od poprzedniego zbierania", kind: "gauge", samples: generationRate},
+	}
+}
+
+// serviceFamilies zwraca metryki usług systemowych i kontenerów
+func serviceFamilies(services []models.Service) []family {
+	serviceUp := make([]sample, 0)
+	serviceCPU := make([]sample, 0)
+	serviceMemory := make([]sample, 0)
+	containerCPU := make([]sample, 0)
+	containerMemory := make([]sample, 0)
+	containerMemoryUsage := make([]sample, 0)
+	containerMemoryLimit := make([]sample, 0)
+	llmInfo := make([]sample, 0)
+
+	for _, svc := range services {
+		llm := strconv.FormatBool(svc.IsLLMRelated)
+
+		if svc.IsContainer() {
+			labels := []label{{"container", svc.Name}, {"image", svc.Image}, {"llm", llm}}
+			containerCPU = append(containerCPU, sample{labels: labels, value: svc.CPUPercent})
+			containerMemory = append(containerMemory, sample{labels: labels, value: float64(svc.MemoryPercent)})
+			if value, ok := numericExtra(svc.Extra, "memory_usage_bytes"); ok {
+				containerMemoryUsage = append(containerMemoryUsage, sample{labels: labels, value: value})
+			}
+			if value, ok := numericExtra(svc.Extra, "memory_limit_bytes"); ok {
+				containerMemoryLimit = append(containerMemoryLimit, sample{labels: labels, value: value})
+			}
+		}
+
+		labels := []label{{"service", svc.Name}, {"type", svc.Type}, {"llm", llm}}
+		serviceUp = append(serviceUp, sample{labels: labels, value: boolValue(isRunning(svc.Status))})
+		serviceCPU = append(serviceCPU, sample{labels: labels, value: svc.CPUPercent})
+		serviceMemory = append(serviceMemory, sample{labels: labels, value: float64(svc.MemoryPercent)})
+		if svc.LLM != nil {
+			llmLabels := []label{{"service", svc.Name}, {"type", svc.Type}, {"category", svc.LLM.Category}, {"rule", svc.LLM.Rule}}
+			llmInfo = append(llmInfo, sample{labels: llmLabels, value: 1})
+		}
+	}
+
+	return []family{
+		{name: "safetytwin_service_up", help: "Czy usługa działa (1) czy nie (0)", kind: "gauge", samples: serviceUp},
+		{name: "safetytwin_service_cpu_percent", help: "Użycie CPU przez usługę w procentach", kind: "gauge", samples: serviceCPU},
+		{name: "safetytwin_service_memory_percent", help: "Użycie pamięci przez usługę w procentach", kind: "gauge", samples: serviceMemory},
+		{name: "safetytwin_service_llm_info", help: "Kategoria i reguła klasyfikacji usługi związanej z LLM", kind: "gauge", samples: llmInfo},
+		{name: "safetytwin_container_cpu_percent", help: "Użycie CPU przez kontener w procentach", kind: "gauge", samples: containerCPU},
+		{name: "safetytwin_container_memory_percent", help: "Użycie pamięci przez kontener w procentach", kind: "gauge", samples: containerMemory},
+		{name: "safetytwin_container_memory_usage_bytes", help: "Pamięć używana przez kontener w bajtach", kind: "gauge", samples: containerMemoryUsage},
+		{name: "safetytwin_container_memory_limit_bytes", help: "Limit pamięci kontenera w bajtach", kind: "gauge", samples: containerMemoryLimit},
+	}
+}
+
+// collectorFamilies zwraca metryki działania kolektorów
+func collectorFamilies(report *models.CollectionReport) []family {
+	if report == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(report.Durations))
+	for name := range report.Durations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	durations := make([]sample, 0, len(names))
+	success := make([]sample, 0, len(names))
+	for _, name := range names {
+		labels := []label{{"collector", name}}
+		_, failed := report.Errors[name]
+		durations = append(durations, sample{labels: labels, value: report.Durations[name]})
+		success = append(success, sample{labels: labels, value: boolValue(!failed)})
+	}
+
+	return []family{
+		{name: "safetytwin_collector_duration_seconds", help: "Czas działania kolektora w ostatnim zbieraniu", kind: "gauge", samples: durations},
+		{name: "safetytwin_collector_success", help: "Czy kolektor dostarczył dane w ostatnim zbieraniu", kind: "gauge", samples: success},
+	}
+}
+
+// isRunning sprawdza, czy status usługi oznacza działanie
+func isRunning(status string) bool {
+	switch strings.ToLower(status) {
+	case "active", "running":
+		return true
+	}
+	return false
+}
+
+// boolValue zamienia wartość logiczną na 0 lub 1
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// numericExtra odczytuje wartość liczbową z pola Extra (także po deserializacji JSON)
+func numericExtra(extra map[string]interface{}, key string) (float64, bool) {
+	switch value := extra[key].(type) {
+	case uint64:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case int:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
+
+// writeFamily zapisuje rodzinę metryk; rodziny bez próbek są pomijane
+func writeFamily(buf *bytes.Buffer, f family) {
+	if len(f.samples) == 0 {
+		return
+	}
+
+	fmt.Fprintf(buf, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(buf, "# TYPE %s %s\n", f.name, f.kind)
+	for _, s := range f.samples {
+		buf.WriteString(f.name)
+		if len(s.labels) > 0 {
+			buf.WriteByte('{')
+			for i, l := range s.labels {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				fmt.Fprintf(buf, "%s=\"%s\"", l.name, escapeLabelValue(l.value))
+			}
+			buf.WriteByte('}')
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(formatValue(s.value))
+		buf.WriteByte('\n')
+	}
+}
+
+// formatValue formatuje wartość zgodnie z formatem tekstowym Prometheus
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escapeLabelValue stosuje sekwencje ucieczki wymagane w wartościach etykiet
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// escapeHelp stosuje sekwencje ucieczki wymagane w opisie metryki
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// testState tworzy stan systemu z przykładowymi danymi wszystkich rodzajów
+func testState() *models.SystemState {
+	return &models.SystemState{
+		Timestamp: "2025-01-01T00:00:00Z",
+		Hardware: &models.Hardware{
+			Hostname:      "test-host",
+			KernelVersion: "6.1.0",
+			CPU:           &models.CPU{UsagePercent: 12.5, LogicalCores: 2, PerCPU: []models.CPUCore{{UsagePercent: 10}, {UsagePercent: 15}}},
+			Memory:        &models.Memory{TotalGB: 2, Percent: 50},
+			Disks:         []models.Disk{{Device: "/dev/sda1", Mountpoint: "/", Fstype: "ext4", TotalGB: 1, Percent: 42}},
+			Network: map[string]models.NetworkInterface{
+				"eth0": {Name: "eth0", BytesSent: 1000, BytesRecv: 2000},
+			},
+			Kernel: &models.KernelStats{
+				LoadAvg1: 3.5,
+				OOMKills: 2,
+				Pressure: map[string]models.PressureStats{"memory": {Some: &models.Pressure{Avg10: 1.25}}},
+			},
+			Sensors: &models.Sensors{
+				Temperatures: []models.SensorReading{{Chip: "coretemp", Device: "coretemp.0", Label: "Package id 0", Value: 54}, {Chip: "coretemp", Device: "coretemp.1", Label: "Package id 0", Value: 51}},
+				Power:        []models.PowerDomain{{Name: "package-0", EnergyJoules: 1000, Watts: 85.5}},
+			},
+		},
+		Processes: []models.Process{
+			{PID: 42, Name: "ollama", CPUPercent: 80, MemoryInfo: &models.MemoryInfo{RSS: 4096}, IsLLMRelated: true,
+				LLM: &models.LLMClassification{Rule: "ollama", Category: "inference_server"},
+				GPU: []models.GPUUsage{{Vendor: "nvidia", Index: 0, MemoryUsedMB: 1024}}},
+		},
+		Services: []models.Service{
+			{Name: "vllm", Type: "docker", Status: "running", Image: "vllm/vllm-openai", CPUPercent: 150, IsLLMRelated: true,
+				LLM:   &models.LLMClassification{Rule: "vllm", Category: "inference_server"},
+				Extra: map[string]interface{}{"memory_usage_bytes": float64(1 << 30)}},
+			{Name: "nginx", Type: "systemd", Status: "inactive"},
+		},
+		Models: []models.ModelArtifact{
+			{Path: "/models/llama.gguf", Format: "gguf", SizeBytes: 4096, GGUF: &models.GGUFMetadata{Architecture: "llama", Quantization: "Q4_K_M"}},
+			{Path: "/models/llama.gguf", Format: "gguf", SizeBytes: 2048, Containers: []string{"abc123", "def456"}},
+		},
+		InferenceServers: []models.InferenceServer{
+			{Kind: "vllm", Endpoint: "http://127.0.0.1:8000", PID: 42,
+				Models:  []models.InferenceModel{{Name: "llama-3-8b", Loaded: true, Quantization: "awq", ContextLength: 8192}},
+				Metrics: &models.InferenceMetrics{RunningRequests: 4, QueueDepth: 2, GenerationTokensTotal: 9000, GenerationTokensPerSecond: 120}},
+		},
+		Collection: &models.CollectionReport{
+			Durations: map[string]float64{"hardware": 0.5, "docker": 30},
+			Errors:    map[string]string{"docker": "przekroczono limit czasu"},
+		},
+	}
+}
+
+func TestWriteMetrics(t *testing.T) {
+	var buf strings.Builder
+	if err := WriteMetrics(&buf, testState()); err != nil {
+		t.Fatalf("WriteMetrics() error = %v", err)
+	}
+	output := buf.String()
+
+	want := []string{
+		`# TYPE safetytwin_cpu_usage_percent gauge`,
+		`safetytwin_cpu_usage_percent 12.5`,
+		`safetytwin_cpu_core_usage_percent{core="1"} 15`,
+		`safetytwin_memory_total_bytes 2.147483648e+09`,
+		`safetytwin_disk_usage_percent{device="/dev/sda1",mountpoint="/",fstype="ext4"} 42`,
+		`# TYPE safetytwin_network_sent_bytes_total counter`,
+		`safetytwin_network_received_bytes_total{interface="eth0"} 2000`,
+		`safetytwin_kernel_load_average{period="1m"} 3.5`,
+		`safetytwin_kernel_oom_kills_total 2`,
+		`safetytwin_kernel_pressure_avg10_percent{resource="memory",kind="some"} 1.25`,
+		`safetytwin_sensor_temperature_celsius{chip="coretemp",device="coretemp.0",sensor="Package id 0"} 54`,
+		`safetytwin_sensor_temperature_celsius{chip="coretemp",device="coretemp.1",sensor="Package id 0"} 51`,
+		`safetytwin_power_watts{domain="package-0"} 85.5`,
+		`safetytwin_process_resident_memory_bytes{pid="42",name="ollama",llm="true"} 4096`,
+		`safetytwin_process_gpu_memory_bytes{pid="42",name="ollama",llm="true",vendor="nvidia",index="0"} 1.073741824e+09`,
+		`safetytwin_process_llm_info{pid="42",name="ollama",category="inference_server",rule="ollama"} 1`,
+		`safetytwin_container_cpu_percent{container="vllm",image="vllm/vllm-openai",llm="true"} 150`,
+		`safetytwin_container_memory_usage_bytes{container="vllm",image="vllm/vllm-openai",llm="true"} 1.073741824e+09`,
+		`safetytwin_service_up{service="vllm",type="docker",llm="true"} 1`,
+		`safetytwin_service_up{service="nginx",type="systemd",llm="false"} 0`,
+		`safetytwin_service_llm_info{service="vllm",type="docker",category="inference_server",rule="vllm"} 1`,
+		`safetytwin_model_size_bytes{path="/models/llama.gguf",container="",format="gguf",architecture="llama",quantization="Q4_K_M"} 4096`,
+		`safetytwin_model_size_bytes{path="/models/llama.gguf",container="abc123,def456",format="gguf",architecture="",quantization=""} 2048`,
+		`safetytwin_inference_model_context_length{endpoint="http://127.0.0.1:8000",kind="vllm",model="llama-3-8b",quantization="awq"} 8192`,
+		`safetytwin_inference_queue_depth{endpoint="http://127.0.0.1:8000",kind="vllm"} 2`,
+		`safetytwin_inference_generation_tokens_total{endpoint="http://127.0.0.1:8000",kind="vllm"} 9000`,
+		`safetytwin_inference_generation_tokens_per_second{endpoint="http://127.0.0.1:8000",kind="vllm"} 120`,
+		`safetytwin_collector_success{collector="docker"} 0`,
+		`safetytwin_collector_success{collector="hardware"} 1`,
+		`safetytwin_host_info{hostname="test-host",platform="",platform_version="",kernel="6.1.0"} 1`,
+	}
+	for _, line := range want {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Brak linii w metrykach: %s\n%s", line, output)
+		}
+	}
+
+	// Rodziny bez próbek nie są wypisywane
+	if strings.Contains(output, "safetytwin_gpu_") {
+		t.Errorf("Nie oczekiwano metryk GPU bez kart graficznych")
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	got := escapeLabelValue("a\"b\\c\nd")
+	want := `a\"b\\c\nd`
+	if got != want {
+		t.Errorf("escapeLabelValue() = %v, want %v", got, want)
+	}
+}
+
+func TestExporterServeHTTP(t *testing.T) {
+	exporter := NewExporter()
+	server := httptest.NewServer(exporter)
+	defer server.Close()
+
+	// Bez zebranego stanu eksporter nie ma czego udostępnić
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Niepoprawny kod odpowiedzi bez stanu: got %v, want %v", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	exporter.Update(testState())
+
+	resp, err = http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Niepoprawny kod odpowiedzi: got %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("Content-Type"); got != ContentType {
+		t.Errorf("Niepoprawny typ zawartości: got %v, want %v", got, ContentType)
+	}
+	if !strings.Contains(string(body), "safetytwin_last_update_timestamp_seconds ") {
+		t.Errorf("Brak znacznika czasu aktualizacji w metrykach:\n%s", body)
+	}
+}
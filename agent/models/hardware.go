@@ -15,6 +15,8 @@ type Hardware struct {
 	Disks           []Disk                      `json:"disks"`
 	Network         map[string]NetworkInterface `json:"network"`
 	GPU             map[string][]GPUDevice      `json:"gpu,omitempty"`
+	Kernel          *KernelStats                `json:"kernel,omitempty"`
+	Sensors         *Sensors                    `json:"sensors,omitempty"`
 }
 
 // CPU reprezentuje informacje o procesorze
@@ -43,6 +45,20 @@ type Memory struct {
 	SwapPercent float64 `json:"swap_percent,omitempty"`
 }
 
+// KernelStats reprezentuje wskaźniki nasycenia systemu zgłaszane przez jądro.
+// Liczniki są sumaryczne od uruchomienia systemu.
+type KernelStats struct {
+	LoadAvg1        float64                  `json:"load_avg_1"`
+	LoadAvg5        float64                  `json:"load_avg_5"`
+	LoadAvg15       float64                  `json:"load_avg_15"`
+	ProcsRunning    uint64                   `json:"procs_running"`
+	ProcsBlocked    uint64                   `json:"procs_blocked"`
+	ContextSwitches uint64                   `json:"context_switches"`
+	MajorPageFaults uint64                   `json:"major_page_faults"`
+	OOMKills        uint64                   `json:"oom_kills"`
+	Pressure        map[string]PressureStats `json:"pressure,omitempty"` // "cpu", "memory", "io"
+}
+
 // Disk reprezentuje informacje o dysku
 type Disk struct {
 	Device     string  `json:"device"`
@@ -72,11 +88,59 @@ type NetworkInterface struct {
 
 // GPUDevice reprezentuje informacje o urządzeniu GPU
 type GPUDevice struct {
-	Index           int     `json:"index"`
-	Name            string  `json:"name"`
-	Temperature     float64 `json:"temperature"`
-	UtilizationGPU  float64 `json:"utilization_percent"`
-	MemoryUsedMB    float64 `json:"memory_used_mb"`
-	MemoryTotalMB   float64 `json:"memory_total_mb"`
+	Index          int          `json:"index"`
+	Name           string       `json:"name"`
+	Temperature    float64      `json:"temperature"`
+	UtilizationGPU float64      `json:"utilization_percent"`
+	MemoryUsedMB   float64      `json:"memory_used_mb"`
+	MemoryTotalMB  float64      `json:"memory_total_mb"`
+	ClockMHz       float64      `json:"clock_mhz,omitempty"`
+	MaxClockMHz    float64      `json:"max_clock_mhz,omitempty"`
+	Driver         string       `json:"driver,omitempty"`
+	PCIBusID       string       `json:"pci_bus_id,omitempty"`
+	UUID           string       `json:"uuid,omitempty"`
+	Processes      []GPUProcess `json:"processes,omitempty"`
+}
+
+// GPUProcess reprezentuje proces korzystający z karty graficznej
+type GPUProcess struct {
+	PID                int32   `json:"pid"`
+	Type               string  `json:"type"` // compute, graphics lub compute+graphics
+	MemoryUsedMB       float64 `json:"memory_used_mb"`
+	UtilizationPercent float64 `json:"utilization_percent,omitempty"` // Wykorzystanie SM od poprzedniego zbierania
+}
+
+// GPUUsage reprezentuje użycie karty graficznej przez proces lub usługę
+type GPUUsage struct {
+	Vendor             string  `json:"vendor"`
+	Index              int     `json:"index"`
+	UUID               string  `json:"uuid,omitempty"`
+	MemoryUsedMB       float64 `json:"memory_used_mb"`
+	UtilizationPercent float64 `json:"utilization_percent,omitempty"`
 }
 
+// Sensors reprezentuje odczyty czujników sprzętowych i liczników energii
+type Sensors struct {
+	Temperatures []SensorReading `json:"temperatures,omitempty"`  // °C (hwmon)
+	Fans         []SensorReading `json:"fans,omitempty"`          // obr./min (hwmon)
+	Voltages     []SensorReading `json:"voltages,omitempty"`      // V (hwmon)
+	ThermalZones []SensorReading `json:"thermal_zones,omitempty"` // °C (strefy termiczne)
+	Power        []PowerDomain   `json:"power,omitempty"`         // RAPL (powercap)
+}
+
+// SensorReading reprezentuje odczyt pojedynczego czujnika
+type SensorReading struct {
+	Chip     string  `json:"chip"`             // Układ hwmon (np. "coretemp") lub strefa termiczna
+	Device   string  `json:"device,omitempty"` // Urządzenie układu hwmon (np. "nvme0"); nazwa układu nie jest unikalna
+	Label    string  `json:"label"` // Etykieta czujnika (np. "Package id 0")
+	Value    float64 `json:"value"`
+	Max      float64 `json:"max,omitempty"`
+	Critical float64 `json:"critical,omitempty"`
+}
+
+// PowerDomain reprezentuje domenę pomiaru energii RAPL (np. pakiet procesora lub DRAM)
+type PowerDomain struct {
+	Name         string  `json:"name"`            // Np. "package-0" lub "package-0/dram"
+	EnergyJoules float64 `json:"energy_joules"`   // Licznik energii (przekręca się po max_energy_range_uj)
+	Watts        float64 `json:"watts,omitempty"` // Średnia moc od poprzedniego zbierania
+}
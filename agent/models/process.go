@@ -21,10 +21,22 @@ type Process struct {
 	OpenFiles     []OpenFile             `json:"open_files,omitempty"`
 	Connections   []Connection           `json:"connections,omitempty"`
 	IOCounters    *IOCounters            `json:"io_counters,omitempty"`
+	ContainerID   string                 `json:"container_id,omitempty"`
+	SystemdUnit   string                 `json:"systemd_unit,omitempty"`
+	CgroupPath    string                 `json:"cgroup_path,omitempty"`
+	GPU           []GPUUsage             `json:"gpu,omitempty"`
 	IsLLMRelated  bool                   `json:"is_llm_related"`
+	LLM           *LLMClassification     `json:"llm,omitempty"`
 	Extra         map[string]interface{} `json:"extra,omitempty"`
 }
 
+// LLMClassification opisuje regułę klasyfikacji, która uznała proces lub usługę
+// za związaną z LLM
+type LLMClassification struct {
+	Rule     string `json:"rule"`     // Nazwa dopasowanej reguły
+	Category string `json:"category"` // inference_server, training, vector_db, embedding lub other
+}
+
 // MemoryInfo reprezentuje informacje o pamięci procesu
 type MemoryInfo struct {
 	RSS     uint64 `json:"rss"`  // Resident Set Size
@@ -17,11 +17,20 @@ type Service struct {
 	CPUPercent   float64                `json:"cpu_percent,omitempty"`
 	MemoryPercent float32               `json:"memory_percent,omitempty"`
 	Image        string                 `json:"image,omitempty"`
+	Command      []string               `json:"command,omitempty"`
+	RestartPolicy string                `json:"restart_policy,omitempty"`
 	Ports        []Port                 `json:"ports,omitempty"`
 	Volumes      []Volume               `json:"volumes,omitempty"`
 	Environment  []string               `json:"environment,omitempty"`
 	Links        []string               `json:"links,omitempty"`
+	Networks     []string               `json:"networks,omitempty"`
+	Labels       map[string]string      `json:"labels,omitempty"`
 	IsLLMRelated bool                   `json:"is_llm_related"`
+	LLM          *LLMClassification     `json:"llm,omitempty"`
+	Systemd      *SystemdUnit           `json:"systemd,omitempty"`
+	Kubernetes   *KubernetesPod         `json:"kubernetes,omitempty"`
+	Cgroup       *CgroupStats           `json:"cgroup,omitempty"`
+	GPU          []GPUUsage             `json:"gpu,omitempty"`
 	Extra        map[string]interface{} `json:"extra,omitempty"`
 }
 
@@ -34,8 +43,152 @@ type Port struct {
 
 // Volume reprezentuje wolumen dla usługi
 type Volume struct {
+	Name        string `json:"name,omitempty"` // Nazwa wolumenu nazwanego
 	Source      string `json:"source"`
 	Destination string `json:"destination"`
 	ReadOnly    bool   `json:"read_only"`
 	Type        string `json:"type,omitempty"`
-}
\ No newline at end of file
+}
+
+// SystemdUnit reprezentuje stan jednostki systemd
+type SystemdUnit struct {
+	Unit                   string              `json:"unit"`
+	Description            string              `json:"description,omitempty"`
+	LoadState              string              `json:"load_state"`
+	ActiveState            string              `json:"active_state"`
+	SubState               string              `json:"sub_state"`
+	MainPID                int32               `json:"main_pid,omitempty"`
+	ControlGroup           string              `json:"control_group,omitempty"`
+	NRestarts              uint32              `json:"n_restarts"`
+	ExecMainStartTimestamp string              `json:"exec_main_start_timestamp,omitempty"`
+	Dependencies           map[string][]string `json:"dependencies,omitempty"` // np. "Requires", "After"
+
+	// Definicja jednostki potrzebna do jej odtworzenia w bliźniaczej maszynie
+	FragmentPath     string              `json:"fragment_path,omitempty"`
+	DropInPaths      []string            `json:"drop_in_paths,omitempty"`
+	ServiceType      string              `json:"service_type,omitempty"` // simple, forking, notify...
+	Restart          string              `json:"restart,omitempty"`
+	Exec             map[string][]string `json:"exec,omitempty"` // np. "ExecStart", "ExecStartPre"
+	User             string              `json:"user,omitempty"`
+	Group            string              `json:"group,omitempty"`
+	WorkingDirectory string              `json:"working_directory,omitempty"`
+	Environment      []string            `json:"environment,omitempty"`
+	EnvironmentFiles []string            `json:"environment_files,omitempty"`
+	Limits           map[string]string   `json:"limits,omitempty"` // np. "LimitNOFILE": "1024:524288"
+	WantedBy         []string            `json:"wanted_by,omitempty"`
+	RequiredBy       []string            `json:"required_by,omitempty"`
+	TriggeredBy      []string            `json:"triggered_by,omitempty"` // timery i gniazda aktywujące jednostkę
+}
+
+// KubernetesPod reprezentuje pod Kubernetesa uruchomiony na hoście
+type KubernetesPod struct {
+	Namespace      string                `json:"namespace"`
+	Name           string                `json:"name"`
+	UID            string                `json:"uid"`
+	Node           string                `json:"node,omitempty"`
+	Phase          string                `json:"phase"`
+	QOSClass       string                `json:"qos_class,omitempty"`
+	ServiceAccount string                `json:"service_account,omitempty"`
+	PodIP          string                `json:"pod_ip,omitempty"`
+	Workload       *KubernetesWorkload   `json:"workload,omitempty"`
+	Containers     []KubernetesContainer `json:"containers"`
+}
+
+// KubernetesWorkload reprezentuje obiekt zarządzający podem (Deployment, StatefulSet, DaemonSet...)
+type KubernetesWorkload struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// KubernetesContainer reprezentuje kontener poda wraz z zasobami
+type KubernetesContainer struct {
+	Name         string            `json:"name"`
+	Image        string            `json:"image"`
+	ContainerID  string            `json:"container_id,omitempty"` // np. "containerd://..."
+	Init         bool              `json:"init,omitempty"`
+	State        string            `json:"state,omitempty"` // running, waiting, terminated
+	Ready        bool              `json:"ready"`
+	RestartCount int32             `json:"restart_count"`
+	Requests     map[string]string `json:"requests,omitempty"` // np. "cpu": "500m", "nvidia.com/gpu": "1"
+	Limits       map[string]string `json:"limits,omitempty"`
+}
+
+// CgroupStats reprezentuje zużycie zasobów przez cgroup usługi lub kontenera (cgroup v2),
+// obejmujące wszystkie procesy potomne
+type CgroupStats struct {
+	Path             string                   `json:"path"`
+	CPUUsageUsec     uint64                   `json:"cpu_usage_usec"`
+	CPUUserUsec      uint64                   `json:"cpu_user_usec"`
+	CPUSystemUsec    uint64                   `json:"cpu_system_usec"`
+	CPUNrThrottled   uint64                   `json:"cpu_nr_throttled,omitempty"`
+	CPUThrottledUsec uint64                   `json:"cpu_throttled_usec,omitempty"`
+	MemoryCurrent    uint64                   `json:"memory_current"`
+	MemoryMax        uint64                   `json:"memory_max,omitempty"` // 0 = brak limitu
+	IOReadBytes      uint64                   `json:"io_read_bytes"`
+	IOWriteBytes     uint64                   `json:"io_write_bytes"`
+	IOReadOps        uint64                   `json:"io_read_ops"`
+	IOWriteOps       uint64                   `json:"io_write_ops"`
+	PidsCurrent      uint64                   `json:"pids_current"`
+	PidsMax          uint64                   `json:"pids_max,omitempty"` // 0 = brak limitu
+	Pressure         map[string]PressureStats `json:"pressure,omitempty"` // "cpu", "memory", "io"
+}
+
+// PressureStats reprezentuje informacje PSI (Pressure Stall Information) o zasobie
+type PressureStats struct {
+	Some *Pressure `json:"some,omitempty"` // Część zadań czekała na zasób
+	Full *Pressure `json:"full,omitempty"` // Wszystkie zadania czekały na zasób
+}
+
+// Pressure reprezentuje odsetek czasu oczekiwania na zasób w oknach 10, 60 i 300 sekund
+type Pressure struct {
+	Avg10     float64 `json:"avg10"`
+	Avg60     float64 `json:"avg60"`
+	Avg300    float64 `json:"avg300"`
+	TotalUsec uint64  `json:"total_usec"`
+}
+
+// ComposeProject reprezentuje projekt Docker Compose odtworzony z działających kontenerów
+type ComposeProject struct {
+	Name        string   `json:"name"`
+	WorkingDir  string   `json:"working_dir,omitempty"`
+	ConfigFiles []string `json:"config_files,omitempty"`
+	Services    []string `json:"services"`
+	Containers  []string `json:"containers"`
+	Networks    []string `json:"networks,omitempty"`
+	Volumes     []string `json:"volumes,omitempty"`
+	ComposeYAML string   `json:"compose_yaml"` // Odtworzony plik docker-compose.yml
+}
+
+// ContainerEvent reprezentuje zdarzenie kontenera zarejestrowane między zbieraniami stanu
+type ContainerEvent struct {
+	Time         string `json:"time"`
+	ContainerID  string `json:"container_id"`
+	Name         string `json:"name,omitempty"`
+	Image        string `json:"image,omitempty"`
+	Action       string `json:"action"`              // start, die, oom, health_status, restart
+	ExitCode     *int   `json:"exit_code,omitempty"` // Kod wyjścia dla zdarzenia die
+	Health       string `json:"health,omitempty"`    // Nowy stan zdrowia dla zdarzenia health_status
+	IsLLMRelated bool   `json:"is_llm_related"`
+	LLMCategory  string `json:"llm_category,omitempty"`
+}
+
+// IsContainer sprawdza, czy usługa jest kontenerem (Docker, Podman lub containerd)
+func (s *Service) IsContainer() bool {
+	switch s.Type {
+	case "docker", "podman", "containerd":
+		return true
+	}
+	return false
+}
+
+// Collector zwraca nazwę kolektora, który dostarcza usługi tego typu
+func (s *Service) Collector() string {
+	switch s.Type {
+	case "systemd", "system":
+		return "services"
+	case "k8s-pod":
+		return "kubernetes"
+	}
+	// docker, podman i containerd
+	return s.Type
+}
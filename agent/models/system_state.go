@@ -9,10 +9,95 @@ import (
 
 // SystemState reprezentuje pełny stan monitorowanego systemu
 type SystemState struct {
-	Timestamp string     `json:"timestamp"`
-	Hardware  *Hardware  `json:"hardware"`
-	Services  []Service  `json:"services"`
-	Processes []Process  `json:"processes"`
+	Timestamp        string                 `json:"timestamp"`
+	Hardware         *Hardware              `json:"hardware"`
+	Services         []Service              `json:"services"`
+	Processes        []Process              `json:"processes"`
+	Models           []ModelArtifact        `json:"models,omitempty"`            // Pliki modeli używane przez procesy
+	InferenceServers []InferenceServer      `json:"inference_servers,omitempty"` // Serwery inferencji odpytane przez ich API
+	Extra            map[string]interface{} `json:"extra,omitempty"`             // Wyniki dodatkowych kolektorów, według nazwy kolektora
+
+	Collection *CollectionReport `json:"collection,omitempty"`
+}
+
+// ModelArtifact reprezentuje plik modelu otwarty lub zmapowany w pamięci przez procesy.
+// Ścieżka jest widziana z przestrzeni nazw montowania pierwszego procesu (dla kontenera
+// jest to ścieżka wewnątrz kontenera).
+type ModelArtifact struct {
+	Path         string        `json:"path"`
+	SizeBytes    int64         `json:"size_bytes"`
+	ModifiedTime string        `json:"modified_time"`
+	Format       string        `json:"format"`      // gguf, ggml, safetensors, pytorch, onnx
+	Fingerprint  string        `json:"fingerprint"` // SHA-256 rozmiaru oraz pierwszego i ostatniego MiB pliku
+	PIDs         []int32       `json:"pids"`
+	Containers   []string      `json:"containers,omitempty"`
+	GGUF         *GGUFMetadata `json:"gguf,omitempty"`
+}
+
+// GGUFMetadata reprezentuje metadane z nagłówka pliku GGUF
+type GGUFMetadata struct {
+	Version         uint32 `json:"version"`
+	Architecture    string `json:"architecture,omitempty"` // np. llama, qwen2
+	Name            string `json:"name,omitempty"`
+	Quantization    string `json:"quantization,omitempty"` // np. Q4_K_M, F16
+	ContextLength   uint64 `json:"context_length,omitempty"`
+	EmbeddingLength uint64 `json:"embedding_length,omitempty"`
+	BlockCount      uint64 `json:"block_count,omitempty"`
+	TensorCount     uint64 `json:"tensor_count"`
+}
+
+// InferenceServer reprezentuje lokalny serwer inferencji LLM (Ollama, vLLM, TGI)
+// wykryty na porcie nasłuchującym procesu i odpytany przez jego API HTTP
+type InferenceServer struct {
+	Kind        string            `json:"kind"`     // ollama, vllm, tgi lub openai (inne API zgodne z OpenAI)
+	Endpoint    string            `json:"endpoint"` // np. http://127.0.0.1:11434
+	PID         int32             `json:"pid"`
+	ContainerID string            `json:"container_id,omitempty"`
+	Version     string            `json:"version,omitempty"`
+	Models      []InferenceModel  `json:"models,omitempty"`
+	Metrics     *InferenceMetrics `json:"metrics,omitempty"` // Tylko serwery udostępniające /metrics
+	Error       string            `json:"error,omitempty"`   // Błąd ostatniego odpytania
+}
+
+// InferenceModel reprezentuje model dostępny lub załadowany w serwerze inferencji
+type InferenceModel struct {
+	Name          string `json:"name"`
+	Loaded        bool   `json:"loaded"` // Czy model jest załadowany do pamięci
+	Family        string `json:"family,omitempty"`
+	ParameterSize string `json:"parameter_size,omitempty"` // np. 8.0B
+	Quantization  string `json:"quantization,omitempty"`   // np. Q4_K_M, awq, bfloat16
+	ContextLength uint64 `json:"context_length,omitempty"`
+	SizeBytes     int64  `json:"size_bytes,omitempty"`
+	VRAMBytes     int64  `json:"vram_bytes,omitempty"`
+	ExpiresAt     string `json:"expires_at,omitempty"` // Kiedy Ollama zwolni model z pamięci
+}
+
+// InferenceMetrics reprezentuje obciążenie serwera inferencji odczytane z /metrics.
+// Przepustowość jest liczona względem poprzedniego zbierania.
+type InferenceMetrics struct {
+	RunningRequests           float64 `json:"running_requests"`
+	QueueDepth                float64 `json:"queue_depth"` // Żądania oczekujące w kolejce
+	PromptTokensTotal         float64 `json:"prompt_tokens_total"`
+	GenerationTokensTotal     float64 `json:"generation_tokens_total"`
+	PromptTokensPerSecond     float64 `json:"prompt_tokens_per_second"`
+	GenerationTokensPerSecond float64 `json:"generation_tokens_per_second"`
+	KVCacheUsage              float64 `json:"kv_cache_usage,omitempty"` // Zajętość pamięci KV cache (0-1)
+}
+
+// CollectionReport opisuje przebieg zbierania danych przez poszczególne kolektory.
+// Kolektor obecny w Errors nie dostarczył danych, więc odpowiadająca mu część stanu jest nieaktualna.
+type CollectionReport struct {
+	Errors    map[string]string  `json:"errors,omitempty"` // Błędy kolektorów, według nazwy kolektora
+	Durations map[string]float64 `json:"durations"`        // Czas działania kolektorów w sekundach
+}
+
+// Failed sprawdza, czy kolektor o podanej nazwie zgłosił błąd
+func (r *CollectionReport) Failed(name string) bool {
+	if r == nil {
+		return false
+	}
+	_, ok := r.Errors[name]
+	return ok
 }
 
 // NewSystemState tworzy nowy obiekt stanu systemu
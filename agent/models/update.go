@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+)
+
+// Rodzaje aktualizacji stanu wysyłanych do VM Bridge
+const (
+	UpdateKindFull  = "full"  // Pełny stan systemu (punkt odniesienia)
+	UpdateKindDelta = "delta" // Różnica względem poprzedniej aktualizacji
+)
+
+// StateUpdate to aktualizacja stanu wysyłana do VM Bridge w trybie przyrostowym.
+// Aktualizacje mają kolejne numery Sequence; aktualizacja typu "delta" opisuje
+// zmiany względem aktualizacji o numerze BaseSequence. VM Bridge, który wykryje
+// lukę w numeracji, odpowiada kodem 409 Conflict (lub {"resync": true}), a agent
+// wysyła wtedy pełny stan.
+type StateUpdate struct {
+	Kind         string       `json:"kind"`
+	Sequence     uint64       `json:"sequence"`
+	BaseSequence uint64       `json:"base_sequence,omitempty"`
+	Timestamp    string       `json:"timestamp"`
+	State        *SystemState `json:"state,omitempty"` // Tylko dla "full"
+	Delta        *StateDelta  `json:"delta,omitempty"` // Tylko dla "delta"
+}
+
+// StateDelta opisuje zmiany stanu systemu względem poprzedniej aktualizacji.
+// Usunięte elementy są identyfikowane kluczami zwracanymi przez metody Key.
+type StateDelta struct {
+	Hardware         *Hardware              `json:"hardware,omitempty"` // Sprzęt bez dysków i interfejsów sieciowych
+	Processes        *ProcessDelta          `json:"processes,omitempty"`
+	Services         *ServiceDelta          `json:"services,omitempty"`
+	Disks            *DiskDelta             `json:"disks,omitempty"`
+	Interfaces       *InterfaceDelta        `json:"interfaces,omitempty"`
+	Models           []ModelArtifact        `json:"models,omitempty"`            // Pełna lista plików modeli
+	InferenceServers []InferenceServer      `json:"inference_servers,omitempty"` // Pełna lista serwerów inferencji
+	Extra            map[string]interface{} `json:"extra,omitempty"`
+	Collection       *CollectionReport      `json:"collection,omitempty"`
+}
+
+// ProcessDelta opisuje zmiany na liście procesów
+type ProcessDelta struct {
+	Added   []Process `json:"added,omitempty"`
+	Removed []string  `json:"removed,omitempty"`
+	Changed []Process `json:"changed,omitempty"`
+}
+
+// ServiceDelta opisuje zmiany na liście usług
+type ServiceDelta struct {
+	Added   []Service `json:"added,omitempty"`
+	Removed []string  `json:"removed,omitempty"`
+	Changed []Service `json:"changed,omitempty"`
+}
+
+// DiskDelta opisuje zmiany na liście dysków
+type DiskDelta struct {
+	Added   []Disk   `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []Disk   `json:"changed,omitempty"`
+}
+
+// InterfaceDelta opisuje zmiany interfejsów sieciowych
+type InterfaceDelta struct {
+	Added   []NetworkInterface `json:"added,omitempty"`
+	Removed []string           `json:"removed,omitempty"`
+	Changed []NetworkInterface `json:"changed,omitempty"`
+}
+
+// Key zwraca stały identyfikator procesu: PID i czas utworzenia, dzięki czemu
+// ponowne użycie PID przez inny proces nie jest traktowane jako ten sam proces
+func (p *Process) Key() string {
+	return fmt.Sprintf("%d@%s", p.PID, p.CreateTime)
+}
+
+// Key zwraca stały identyfikator usługi: typ oraz ID (lub nazwę, jeśli brak ID)
+func (s *Service) Key() string {
+	if s.ID != "" {
+		return s.Type + "/" + s.ID
+	}
+	return s.Type + "/" + s.Name
+}
+
+// Key zwraca stały identyfikator dysku: punkt montowania
+func (d *Disk) Key() string {
+	return d.Mountpoint
+}
+
+// Key zwraca stały identyfikator interfejsu sieciowego: nazwę
+func (n *NetworkInterface) Key() string {
+	return n.Name
+}
+
+// Key zwraca identyfikator pliku modelu: ścieżkę, poprzedzoną identyfikatorem kontenera,
+// jeśli plik jest używany w kontenerze
+func (m *ModelArtifact) Key() string {
+	if len(m.Containers) > 0 {
+		return m.Containers[0] + ":" + m.Path
+	}
+	return m.Path
+}
@@ -1,53 +1,257 @@
 package utils
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/url"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.com/safetytwin/safetytwin/agent/classify"
 )
 
 // Config reprezentuje konfigurację agenta
 type Config struct {
-	Interval         int    `json:"interval"`         // Interwał zbierania danych w sekundach
-	BridgeURL        string `json:"bridge_url"`        // URL do VM Bridge
-	LogFile          string `json:"log_file"`          // Ścieżka do pliku logów
-	StateDir         string `json:"state_dir"`         // Katalog na pliki stanów
-	IncludeProcesses bool   `json:"include_processes"` // Czy zbierać informacje o procesach
-	Verbose          bool   `json:"verbose"`           // Tryb szczegółowego logowania
+	Interval         int    `json:"interval"`           // Interwał zbierania danych w sekundach
+	BridgeURL        string `json:"bridge_url"`         // URL do VM Bridge
+	LogFile          string `json:"log_file"`           // Ścieżka do pliku logów
+	StateDir         string `json:"state_dir"`          // Katalog na pliki stanów
+	SpoolDir         string `json:"spool_dir"`          // Katalog kolejki niedostarczonych stanów
+	SpoolMaxMB       int    `json:"spool_max_mb"`       // Maksymalny rozmiar kolejki w MB
+	DeltaUpdates     bool   `json:"delta_updates"`      // Czy wysyłać aktualizacje przyrostowe zamiast pełnych stanów
+	FullStateEvery   int    `json:"full_state_every"`   // Co ile aktualizacji wysyłać pełny stan w trybie przyrostowym
+	IncludeProcesses bool   `json:"include_processes"`  // Czy zbierać informacje o procesach
+	Verbose          bool   `json:"verbose"`            // Tryb szczegółowego logowania
+	MetricsListen    string `json:"metrics_listen"`     // Adres nasłuchu metryk Prometheus (np. ":9101"), pusty wyłącza
+	HealthListen     string `json:"health_listen"`      // Adres nasłuchu endpointu /health (np. "127.0.0.1:9102"), pusty wyłącza
+	HealthStaleAfter int    `json:"health_stale_after"` // Po ilu sekundach bez powodzenia agent jest niesprawny (0 = 3 interwały)
+	EventTriggers    bool   `json:"event_triggers"`     // Czy zdarzenia kontenerów LLM wyzwalają natychmiastowe zbieranie danych
+
+	// Collectors włącza lub wyłącza kolektory według nazwy (np. "docker": false)
+	Collectors map[string]bool `json:"collectors,omitempty"`
+	// CollectorTimeout to domyślny limit czasu pojedynczego kolektora w sekundach
+	CollectorTimeout int `json:"collector_timeout"`
+	// CollectorTimeouts nadpisuje limit czasu dla wybranych kolektorów (w sekundach)
+	CollectorTimeouts map[string]int `json:"collector_timeouts,omitempty"`
+	// LLMRules to własne reguły klasyfikacji LLM, sprawdzane przed wbudowanymi
+	LLMRules []classify.Rule `json:"llm_rules,omitempty"`
+}
+
+// MinInterval to najkrótszy dopuszczalny interwał zbierania danych w sekundach.
+// Każde zbieranie uruchamia wszystkie kolektory naraz, więc krótszy interwał
+// tylko obciąża host; szybkie zmiany kontenerów wychwytują zdarzenia Dockera.
+const MinInterval = 10
+
+// DefaultConfig zwraca domyślną konfigurację agenta
+func DefaultConfig() *Config {
+	return &Config{
+		Interval:         10,
+		BridgeURL:        "http://localhost:5678/api/v1/update_state",
+		LogFile:          "/var/log/safetytwin/agent.log",
+		StateDir:         "/var/lib/safetytwin/agent-states",
+		SpoolDir:         "/var/lib/safetytwin/agent-spool",
+		SpoolMaxMB:       100,
+		FullStateEvery:   30,
+		IncludeProcesses: true,
+		Verbose:          false,
+		EventTriggers:    true,
+		CollectorTimeout: 30,
+	}
 }
 
-// LoadConfig wczytuje konfigurację z pliku JSON
+// LoadConfig wczytuje konfigurację z pliku JSON. Klucze pominięte w pliku
+// mają wartości domyślne; nieznane klucze i niepoprawne wartości są błędem.
 func LoadConfig(path string) (*Config, error) {
+	config := DefaultConfig()
+	if err := config.loadFile(path); err != nil {
+		return nil, err
+	}
+
+	// Sprawdź poprawność konfiguracji
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("niepoprawny plik konfiguracyjny %s: %v", path, err)
+	}
+
+	return config, nil
+}
+
+// ResolveConfig ustala konfigurację warstwowo: wartości domyślne, plik konfiguracyjny
+// (jeśli podano ścieżkę), zmienne środowiskowe SAFETYTWIN_* i flagi wiersza poleceń.
+// Każda kolejna warstwa nadpisuje tylko ustawienia, które faktycznie określa.
+func ResolveConfig(path string, environ []string, flags *ConfigFlags) (*Config, error) {
+	config := DefaultConfig()
+
+	if path != "" {
+		if err := config.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := config.ApplyEnv(environ); err != nil {
+		return nil, err
+	}
+
+	if flags != nil {
+		if err := flags.Apply(config); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sprawdź poprawność wynikowej konfiguracji
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("niepoprawna konfiguracja: %v", err)
+	}
+
+	return config, nil
+}
+
+// loadFile nadpisuje konfigurację wartościami z pliku JSON
+func (c *Config) loadFile(path string) error {
 	// Wczytaj plik konfiguracyjny
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return nil, fmt.Errorf("nie można odczytać pliku konfiguracyjnego: %v", err)
+		return fmt.Errorf("nie można odczytać pliku konfiguracyjnego: %v", err)
 	}
 
-	// Parsuj JSON
-	var config Config
-	if err := json.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("nie można sparsować pliku konfiguracyjnego: %v", err)
+	// Parsuj JSON; nieznane klucze są błędem
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("nie można sparsować pliku konfiguracyjnego %s: %v", path, err)
 	}
 
-	// Sprawdź poprawność konfiguracji
-	if config.Interval <= 0 {
-		config.Interval = 10 // Domyślny interwał 10 sekund
+	// Plik musi zawierać dokładnie jeden obiekt JSON
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("nie można sparsować pliku konfiguracyjnego %s: nadmiarowe dane po obiekcie JSON", path)
+	}
+
+	return nil
+}
+
+// Validate sprawdza poprawność wartości konfiguracji i zwraca wszystkie znalezione błędy
+func (c *Config) Validate() error {
+	problems := make([]string, 0)
+
+	if c.Interval < MinInterval {
+		problems = append(problems, fmt.Sprintf("interval musi wynosić co najmniej %d s (podano %d)", MinInterval, c.Interval))
+	}
+
+	if u, err := url.Parse(c.BridgeURL); err != nil {
+		problems = append(problems, fmt.Sprintf("bridge_url jest niepoprawnym adresem URL: %v", err))
+	} else if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("bridge_url musi być adresem http:// lub https:// z nazwą hosta (podano %q)", c.BridgeURL))
+	}
+
+	for key, value := range map[string]string{"log_file": c.LogFile, "state_dir": c.StateDir, "spool_dir": c.SpoolDir} {
+		if value == "" {
+			problems = append(problems, fmt.Sprintf("%s nie może być pusty", key))
+		}
+	}
+
+	if c.SpoolMaxMB < 0 {
+		problems = append(problems, fmt.Sprintf("spool_max_mb nie może być ujemny (podano %d)", c.SpoolMaxMB))
+	}
+	if c.FullStateEvery < 1 {
+		problems = append(problems, fmt.Sprintf("full_state_every musi wynosić co najmniej 1 (podano %d)", c.FullStateEvery))
+	}
+	if c.CollectorTimeout < 1 {
+		problems = append(problems, fmt.Sprintf("collector_timeout musi wynosić co najmniej 1 s (podano %d)", c.CollectorTimeout))
+	}
+	for name, seconds := range c.CollectorTimeouts {
+		if seconds < 1 {
+			problems = append(problems, fmt.Sprintf("collector_timeouts[%s] musi wynosić co najmniej 1 s (podano %d)", name, seconds))
+		}
+	}
+	if c.HealthStaleAfter < 0 {
+		problems = append(problems, fmt.Sprintf("health_stale_after nie może być ujemny (podano %d)", c.HealthStaleAfter))
+	}
+	if err := classify.Validate(c.LLMRules); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// Kolejność kluczy mapy jest losowa, więc sortuj komunikaty dla powtarzalności
+	sort.Strings(problems)
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// CheckDirectories tworzy katalogi używane przez agenta i sprawdza, czy można w nich zapisywać
+func (c *Config) CheckDirectories() error {
+	dirs := []struct {
+		key  string
+		path string
+	}{
+		{"state_dir", c.StateDir},
+		{"spool_dir", c.SpoolDir},
+		{"log_file", filepath.Dir(c.LogFile)},
 	}
 
-	if config.BridgeURL == "" {
-		config.BridgeURL = "http://localhost:5678/api/v1/update_state" // Domyślny URL
+	problems := make([]string, 0)
+	for _, dir := range dirs {
+		if err := checkWritableDir(dir.path); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", dir.key, err))
+		}
 	}
 
-	if config.LogFile == "" {
-		config.LogFile = "/var/log/safetytwin/agent.log" // Domyślna ścieżka logów
+	if len(problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// checkWritableDir tworzy katalog, jeśli nie istnieje, i sprawdza możliwość zapisu
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("nie można utworzyć katalogu %s: %v", dir, err)
+	}
+
+	file, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return fmt.Errorf("brak uprawnień do zapisu w katalogu %s: %v", dir, err)
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return nil
+}
+
+// optionalCollectors to kolektory, które działają tylko po jawnym włączeniu w mapie
+// Collectors, np. odpytujące lokalne usługi przez sieć
+var optionalCollectors = map[string]bool{
+	"inference": true,
+}
+
+// CollectorEnabled sprawdza, czy kolektor o podanej nazwie jest włączony.
+// Kolektory nieujęte w mapie Collectors są włączone, z wyjątkiem "processes",
+// który domyślnie zależy od IncludeProcesses, oraz kolektorów opcjonalnych.
+func (c *Config) CollectorEnabled(name string) bool {
+	if enabled, ok := c.Collectors[name]; ok {
+		return enabled
 	}
 
-	if config.StateDir == "" {
-		config.StateDir = "/var/lib/safetytwin/agent-states" // Domyślny katalog stanów
+	if name == "processes" {
+		return c.IncludeProcesses
 	}
 
-	return &config, nil
+	return !optionalCollectors[name]
+}
+
+// EnabledCollectors zwraca nazwy włączonych kolektorów spośród dostępnych
+func (c *Config) EnabledCollectors(available []string) []string {
+	enabled := make([]string, 0, len(available))
+	for _, name := range available {
+		if c.CollectorEnabled(name) {
+			enabled = append(enabled, name)
+		}
+	}
+	return enabled
 }
 
 // SaveConfig zapisuje konfigurację do pliku JSON
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix to prefiks zmiennych środowiskowych nadpisujących konfigurację.
+// Nazwa zmiennej powstaje z klucza JSON, np. bridge_url -> SAFETYTWIN_BRIDGE_URL.
+const EnvPrefix = "SAFETYTWIN_"
+
+// EnvName zwraca nazwę zmiennej środowiskowej dla klucza konfiguracji
+func EnvName(key string) string {
+	return EnvPrefix + strings.ToUpper(key)
+}
+
+// FlagName zwraca nazwę flagi wiersza poleceń dla klucza konfiguracji
+func FlagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// configKeys zwraca klucze JSON wszystkich pól konfiguracji w kolejności deklaracji
+func configKeys() []string {
+	configType := reflect.TypeOf(Config{})
+	keys := make([]string, 0, configType.NumField())
+	for i := 0; i < configType.NumField(); i++ {
+		if key := jsonKey(configType.Field(i)); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// jsonKey zwraca klucz JSON pola struktury
+func jsonKey(field reflect.StructField) string {
+	key := strings.Split(field.Tag.Get("json"), ",")[0]
+	if key == "-" {
+		return ""
+	}
+	return key
+}
+
+// Set ustawia pole konfiguracji o podanym kluczu JSON na wartość zapisaną tekstowo.
+// Mapy zapisuje się jako listę par "nazwa=wartość" rozdzieloną przecinkami,
+// np. "docker=false,processes=true", a listy (np. llm_rules) jako tablicę JSON.
+func (c *Config) Set(key, value string) error {
+	configValue := reflect.ValueOf(c).Elem()
+	configType := configValue.Type()
+
+	for i := 0; i < configType.NumField(); i++ {
+		if jsonKey(configType.Field(i)) != key {
+			continue
+		}
+
+		field := configValue.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(value)
+		case reflect.Int:
+			number, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%s: oczekiwano liczby całkowitej, podano %q", key, value)
+			}
+			field.SetInt(int64(number))
+		case reflect.Bool:
+			enabled, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return fmt.Errorf("%s: oczekiwano wartości logicznej, podano %q", key, value)
+			}
+			field.SetBool(enabled)
+		case reflect.Map:
+			m, err := parseMap(field.Type(), value)
+			if err != nil {
+				return fmt.Errorf("%s: %v", key, err)
+			}
+			field.Set(m)
+		case reflect.Slice:
+			list := reflect.New(field.Type())
+			if err := json.Unmarshal([]byte(value), list.Interface()); err != nil {
+				return fmt.Errorf("%s: oczekiwano tablicy JSON: %v", key, err)
+			}
+			field.Set(list.Elem())
+		default:
+			return fmt.Errorf("%s: nieobsługiwany typ %s", key, field.Type())
+		}
+		return nil
+	}
+
+	return fmt.Errorf("nieznany klucz konfiguracji %q", key)
+}
+
+// parseMap parsuje listę par "nazwa=wartość" do mapy o podanym typie
+func parseMap(mapType reflect.Type, value string) (reflect.Value, error) {
+	m := reflect.MakeMap(mapType)
+	if strings.TrimSpace(value) == "" {
+		return m, nil
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return reflect.Value{}, fmt.Errorf("oczekiwano par nazwa=wartość, podano %q", pair)
+		}
+		name := strings.TrimSpace(parts[0])
+		raw := strings.TrimSpace(parts[1])
+
+		switch mapType.Elem().Kind() {
+		case reflect.Bool:
+			enabled, err := strconv.ParseBool(raw)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: oczekiwano wartości logicznej, podano %q", name, raw)
+			}
+			m.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(enabled))
+		case reflect.Int:
+			number, err := strconv.Atoi(raw)
+			if err != nil {
+				return reflect.Value{}, fmt.Errorf("%s: oczekiwano liczby całkowitej, podano %q", name, raw)
+			}
+			m.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(number))
+		default:
+			return reflect.Value{}, fmt.Errorf("nieobsługiwany typ mapy %s", mapType)
+		}
+	}
+
+	return m, nil
+}
+
+// ApplyEnv nadpisuje konfigurację zmiennymi środowiskowymi SAFETYTWIN_*
+// (environ w formacie os.Environ)
+func (c *Config) ApplyEnv(environ []string) error {
+	env := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		if parts := strings.SplitN(entry, "=", 2); len(parts) == 2 {
+			env[parts[0]] = parts[1]
+		}
+	}
+
+	for _, key := range configKeys() {
+		value, ok := env[EnvName(key)]
+		if !ok {
+			continue
+		}
+		if err := c.Set(key, value); err != nil {
+			return fmt.Errorf("niepoprawna zmienna środowiskowa %s: %v", EnvName(key), err)
+		}
+	}
+
+	return nil
+}
+
+// ConfigFlags to flagi wiersza poleceń nadpisujące ustawienia konfiguracji.
+// Każdy klucz konfiguracji ma flagę o tej samej nazwie (z "-" zamiast "_");
+// stosowane są tylko flagi podane w wierszu poleceń.
+type ConfigFlags struct {
+	values map[string]string
+	bools  map[string]bool
+}
+
+// NewConfigFlags rejestruje w zbiorze flag flagi dla wszystkich kluczy konfiguracji
+func NewConfigFlags(flags *flag.FlagSet) *ConfigFlags {
+	f := &ConfigFlags{
+		values: make(map[string]string),
+		bools:  make(map[string]bool),
+	}
+
+	configType := reflect.TypeOf(Config{})
+	for i := 0; i < configType.NumField(); i++ {
+		field := configType.Field(i)
+		key := jsonKey(field)
+		if key == "" {
+			continue
+		}
+
+		f.bools[key] = field.Type.Kind() == reflect.Bool
+		usage := fmt.Sprintf("Nadpisuje %s z pliku konfiguracyjnego (zmienna %s)", key, EnvName(key))
+		flags.Var(&configFlagValue{flags: f, key: key}, FlagName(key), usage)
+	}
+
+	return f
+}
+
+// Apply nadpisuje konfigurację wartościami podanych flag
+func (f *ConfigFlags) Apply(c *Config) error {
+	keys := make([]string, 0, len(f.values))
+	for key := range f.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := c.Set(key, f.values[key]); err != nil {
+			return fmt.Errorf("niepoprawna flaga -%s: %v", FlagName(key), err)
+		}
+	}
+
+	return nil
+}
+
+// configFlagValue implementuje flag.Value dla klucza konfiguracji
+type configFlagValue struct {
+	flags *ConfigFlags
+	key   string
+}
+
+// String zwraca wartość flagi podaną w wierszu poleceń
+func (v *configFlagValue) String() string {
+	if v == nil || v.flags == nil {
+		return ""
+	}
+	return v.flags.values[v.key]
+}
+
+// Set zapamiętuje wartość flagi; jest stosowana dopiero w ConfigFlags.Apply
+func (v *configFlagValue) Set(value string) error {
+	// Sprawdź wartość od razu, aby błąd wskazywał flagę podczas parsowania
+	if err := (&Config{}).Set(v.key, value); err != nil {
+		return err
+	}
+	v.flags.values[v.key] = value
+	return nil
+}
+
+// IsBoolFlag pozwala podawać flagi logiczne bez wartości (np. -verbose)
+func (v *configFlagValue) IsBoolFlag() bool {
+	return v.flags.bools[v.key]
+}
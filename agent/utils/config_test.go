@@ -0,0 +1,174 @@
+package utils
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig zapisuje plik konfiguracyjny w katalogu tymczasowym testu
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "agent-config.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Nie można zapisać pliku konfiguracyjnego: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	config, err := LoadConfig(writeConfig(t, `{"interval": 15}`))
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	defaults := DefaultConfig()
+	if config.Interval != 15 {
+		t.Errorf("Niepoprawny interwał: got %v, want %v", config.Interval, 15)
+	}
+	if config.BridgeURL != defaults.BridgeURL || config.SpoolMaxMB != defaults.SpoolMaxMB || !config.IncludeProcesses {
+		t.Errorf("Pominięte klucze powinny mieć wartości domyślne: got %+v", config)
+	}
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"nieznany klucz", `{"intervall": 5}`, "intervall"},
+		{"niepoprawny URL", `{"bridge_url": "localhost:5678"}`, "bridge_url"},
+		{"URL bez hosta", `{"bridge_url": "http://"}`, "bridge_url"},
+		{"za krótki interwał", `{"interval": 0}`, "interval"},
+		{"interwał poniżej minimum", `{"interval": 5}`, "co najmniej 10 s"},
+		{"ujemny limit kolejki", `{"spool_max_mb": -1}`, "spool_max_mb"},
+		{"limit czasu kolektora", `{"collector_timeouts": {"docker": 0}}`, "collector_timeouts[docker]"},
+		{"niepoprawny JSON", `{"interval": "10"}`, "interval"},
+		{"dane po obiekcie", `{"interval": 10} {"interval": 20}`, "nadmiarowe dane"},
+		{"śmieci po obiekcie", "{\"interval\": 10}\n]", "nadmiarowe dane"},
+		{"reguła LLM", `{"llm_rules": [{"name": "rag", "category": "rag", "names": ["retriever"]}]}`, "llm_rules[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.content))
+			if err == nil {
+				t.Fatalf("Oczekiwano błędu dla %s", tt.content)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Błąd nie wskazuje klucza %s: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestCheckDirectories(t *testing.T) {
+	dir := t.TempDir()
+
+	config := DefaultConfig()
+	config.StateDir = filepath.Join(dir, "states")
+	config.SpoolDir = filepath.Join(dir, "spool")
+	config.LogFile = filepath.Join(dir, "log", "agent.log")
+	if err := config.CheckDirectories(); err != nil {
+		t.Fatalf("CheckDirectories() error = %v", err)
+	}
+
+	// Zwykły plik zamiast katalogu
+	blocker := filepath.Join(dir, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatalf("Nie można utworzyć pliku: %v", err)
+	}
+	config.SpoolDir = filepath.Join(blocker, "spool")
+	err := config.CheckDirectories()
+	if err == nil || !strings.Contains(err.Error(), "spool_dir") {
+		t.Errorf("Oczekiwano błędu dla spool_dir, got %v", err)
+	}
+}
+
+func TestResolveConfigLayers(t *testing.T) {
+	path := writeConfig(t, `{"interval": 20, "bridge_url": "http://file:5678/api", "verbose": false}`)
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	configFlags := NewConfigFlags(flags)
+	if err := flags.Parse([]string{"-verbose", "-bridge-url", "http://flag:5678/api"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	environ := []string{
+		"SAFETYTWIN_INTERVAL=30",
+		"SAFETYTWIN_BRIDGE_URL=http://env:5678/api",
+		"SAFETYTWIN_COLLECTORS=docker=false, processes=true",
+		`SAFETYTWIN_LLM_RULES=[{"name": "rag", "category": "other", "names": ["^retriever$"]}]`,
+		"OTHER=1",
+	}
+
+	config, err := ResolveConfig(path, environ, configFlags)
+	if err != nil {
+		t.Fatalf("ResolveConfig() error = %v", err)
+	}
+
+	// Zmienna środowiskowa nadpisuje plik, flaga nadpisuje zmienną
+	if config.Interval != 30 {
+		t.Errorf("Niepoprawny interwał: got %v, want %v", config.Interval, 30)
+	}
+	if config.BridgeURL != "http://flag:5678/api" {
+		t.Errorf("Niepoprawny bridge_url: got %v, want %v", config.BridgeURL, "http://flag:5678/api")
+	}
+	if !config.Verbose {
+		t.Errorf("Flaga -verbose bez wartości powinna włączyć tryb szczegółowy")
+	}
+	if enabled, ok := config.Collectors["docker"]; !ok || enabled {
+		t.Errorf("Niepoprawne kolektory: got %v", config.Collectors)
+	}
+	if !config.Collectors["processes"] {
+		t.Errorf("Niepoprawne kolektory: got %v", config.Collectors)
+	}
+
+	if len(config.LLMRules) != 1 || config.LLMRules[0].Name != "rag" || config.LLMRules[0].Names[0] != "^retriever$" {
+		t.Errorf("Niepoprawne reguły LLM: got %+v", config.LLMRules)
+	}
+
+	// Wartości spoza wszystkich warstw pozostają domyślne
+	if config.SpoolMaxMB != DefaultConfig().SpoolMaxMB {
+		t.Errorf("Niepoprawny spool_max_mb: got %v, want %v", config.SpoolMaxMB, DefaultConfig().SpoolMaxMB)
+	}
+}
+
+func TestResolveConfigInvalidOverrides(t *testing.T) {
+	if _, err := ResolveConfig("", []string{"SAFETYTWIN_INTERVAL=abc"}, nil); err == nil || !strings.Contains(err.Error(), "SAFETYTWIN_INTERVAL") {
+		t.Errorf("Oczekiwano błędu wskazującego zmienną SAFETYTWIN_INTERVAL, got %v", err)
+	}
+
+	if _, err := ResolveConfig("", []string{"SAFETYTWIN_INTERVAL=0"}, nil); err == nil || !strings.Contains(err.Error(), "interval") {
+		t.Errorf("Oczekiwano błędu walidacji interwału, got %v", err)
+	}
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	NewConfigFlags(flags)
+	if err := flags.Parse([]string{"-collector-timeouts", "docker"}); err == nil {
+		t.Errorf("Oczekiwano błędu dla niepoprawnej flagi -collector-timeouts")
+	}
+}
+
+func TestEnabledCollectors(t *testing.T) {
+	available := []string{"hardware", "processes", "docker", "inference"}
+
+	config := DefaultConfig()
+	config.IncludeProcesses = false
+	config.Collectors = map[string]bool{"docker": false}
+	enabled := strings.Join(config.EnabledCollectors(available), ",")
+	if enabled != "hardware" {
+		t.Errorf("Niepoprawne kolektory domyślne: got %v, want %v", enabled, "hardware")
+	}
+
+	config.Collectors = map[string]bool{"inference": true, "processes": true}
+	enabled = strings.Join(config.EnabledCollectors(available), ",")
+	if enabled != "hardware,processes,docker,inference" {
+		t.Errorf("Niepoprawne kolektory po włączeniu: got %v, want %v", enabled, "hardware,processes,docker,inference")
+	}
+}
@@ -0,0 +1,273 @@
+package utils
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// DeltaEncoder zamienia kolejne stany systemu na aktualizacje przyrostowe:
+// co fullEvery aktualizacji (oraz na żądanie VM Bridge) wysyłany jest pełny
+// stan, a pomiędzy nimi tylko zmiany względem poprzedniego stanu.
+type DeltaEncoder struct {
+	mu        sync.Mutex
+	fullEvery int
+	sequence  uint64
+	sinceFull int
+	last      *models.SystemState
+	resync    bool
+}
+
+// NewDeltaEncoder tworzy koder aktualizacji przyrostowych.
+// fullEvery <= 1 oznacza wysyłanie wyłącznie pełnych stanów.
+func NewDeltaEncoder(fullEvery int) *DeltaEncoder {
+	return &DeltaEncoder{
+		fullEvery: fullEvery,
+	}
+}
+
+// RequestResync wymusza wysłanie pełnego stanu w następnej aktualizacji
+func (e *DeltaEncoder) RequestResync() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.resync = true
+}
+
+// SetFullEvery zmienia co ile aktualizacji wysyłany jest pełny stan
+func (e *DeltaEncoder) SetFullEvery(fullEvery int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fullEvery = fullEvery
+}
+
+// Sequence zwraca numer ostatniej aktualizacji
+func (e *DeltaEncoder) Sequence() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.sequence
+}
+
+// Encode tworzy kolejną aktualizację dla podanego stanu
+func (e *DeltaEncoder) Encode(state *models.SystemState) *models.StateUpdate {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.sequence++
+	update := &models.StateUpdate{
+		Sequence:  e.sequence,
+		Timestamp: state.Timestamp,
+	}
+
+	if e.last == nil || e.resync || e.fullEvery <= 1 || e.sinceFull+1 >= e.fullEvery {
+		update.Kind = models.UpdateKindFull
+		update.State = state
+		e.sinceFull = 0
+		e.resync = false
+	} else {
+		update.Kind = models.UpdateKindDelta
+		update.BaseSequence = e.sequence - 1
+		update.Delta = ComputeDelta(e.last, state)
+		e.sinceFull++
+		// Aktualizacja nie usuwa części stanu, których kolektor zgłosił błąd,
+		// więc VM Bridge nadal ma ich poprzednią zawartość
+		state = mergeFailed(e.last, state)
+	}
+
+	e.last = state
+	return update
+}
+
+// ComputeDelta oblicza zmiany między dwoma stanami systemu. Części stanu, których
+// kolektor zgłosił błąd, są pomijane: brak procesów czy usług w niepełnym stanie
+// nie oznacza, że zostały zakończone.
+func ComputeDelta(previous, current *models.SystemState) *models.StateDelta {
+	report := current.Collection
+	delta := &models.StateDelta{
+		Services:         diffServices(collectedServices(previous.Services, report), collectedServices(current.Services, report)),
+		Models:           current.Models,
+		InferenceServers: current.InferenceServers,
+		Extra:            current.Extra,
+		Collection:       current.Collection,
+	}
+	if !report.Failed("processes") {
+		delta.Processes = diffProcesses(previous.Processes, current.Processes)
+	}
+
+	var previousHardware models.Hardware
+	if previous.Hardware != nil {
+		previousHardware = *previous.Hardware
+	}
+
+	if current.Hardware != nil && !report.Failed("hardware") {
+		// Dyski i interfejsy są przesyłane jako zmiany, pozostałe dane sprzętu w całości
+		hardware := *current.Hardware
+		hardware.Disks = nil
+		hardware.Network = nil
+		delta.Hardware = &hardware
+
+		delta.Disks = diffDisks(previousHardware.Disks, current.Hardware.Disks)
+		delta.Interfaces = diffInterfaces(previousHardware.Network, current.Hardware.Network)
+	}
+
+	return delta
+}
+
+// collectedServices zwraca usługi, których kolektor nie zgłosił błędu
+func collectedServices(services []models.Service, report *models.CollectionReport) []models.Service {
+	if report == nil || len(report.Errors) == 0 {
+		return services
+	}
+
+	collected := make([]models.Service, 0, len(services))
+	for i := range services {
+		if !report.Failed(services[i].Collector()) {
+			collected = append(collected, services[i])
+		}
+	}
+	return collected
+}
+
+// mergeFailed uzupełnia stan o części poprzedniego stanu, których kolektor zgłosił
+// błąd. Wynik odpowiada stanowi VM Bridge po zastosowaniu aktualizacji przyrostowej.
+func mergeFailed(previous, current *models.SystemState) *models.SystemState {
+	report := current.Collection
+	if report == nil || len(report.Errors) == 0 {
+		return current
+	}
+
+	merged := *current
+	if report.Failed("processes") {
+		merged.Processes = previous.Processes
+	}
+	if report.Failed("hardware") {
+		merged.Hardware = previous.Hardware
+	}
+
+	merged.Services = collectedServices(current.Services, report)
+	for i := range previous.Services {
+		if report.Failed(previous.Services[i].Collector()) {
+			merged.Services = append(merged.Services, previous.Services[i])
+		}
+	}
+
+	return &merged
+}
+
+// diffProcesses porównuje listy procesów według klucza procesu
+func diffProcesses(previous, current []models.Process) *models.ProcessDelta {
+	old := make(map[string]*models.Process, len(previous))
+	for i := range previous {
+		old[previous[i].Key()] = &previous[i]
+	}
+
+	delta := &models.ProcessDelta{}
+	seen := make(map[string]bool, len(current))
+	for i := range current {
+		key := current[i].Key()
+		seen[key] = true
+		if prev, ok := old[key]; !ok {
+			delta.Added = append(delta.Added, current[i])
+		} else if !reflect.DeepEqual(*prev, current[i]) {
+			delta.Changed = append(delta.Changed, current[i])
+		}
+	}
+	for i := range previous {
+		if key := previous[i].Key(); !seen[key] {
+			delta.Removed = append(delta.Removed, key)
+		}
+	}
+
+	if len(delta.Added) == 0 && len(delta.Removed) == 0 && len(delta.Changed) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// diffServices porównuje listy usług według klucza usługi
+func diffServices(previous, current []models.Service) *models.ServiceDelta {
+	old := make(map[string]*models.Service, len(previous))
+	for i := range previous {
+		old[previous[i].Key()] = &previous[i]
+	}
+
+	delta := &models.ServiceDelta{}
+	seen := make(map[string]bool, len(current))
+	for i := range current {
+		key := current[i].Key()
+		seen[key] = true
+		if prev, ok := old[key]; !ok {
+			delta.Added = append(delta.Added, current[i])
+		} else if !reflect.DeepEqual(*prev, current[i]) {
+			delta.Changed = append(delta.Changed, current[i])
+		}
+	}
+	for i := range previous {
+		if key := previous[i].Key(); !seen[key] {
+			delta.Removed = append(delta.Removed, key)
+		}
+	}
+
+	if len(delta.Added) == 0 && len(delta.Removed) == 0 && len(delta.Changed) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// diffDisks porównuje listy dysków według punktu montowania
+func diffDisks(previous, current []models.Disk) *models.DiskDelta {
+	old := make(map[string]*models.Disk, len(previous))
+	for i := range previous {
+		old[previous[i].Key()] = &previous[i]
+	}
+
+	delta := &models.DiskDelta{}
+	seen := make(map[string]bool, len(current))
+	for i := range current {
+		key := current[i].Key()
+		seen[key] = true
+		if prev, ok := old[key]; !ok {
+			delta.Added = append(delta.Added, current[i])
+		} else if *prev != current[i] {
+			delta.Changed = append(delta.Changed, current[i])
+		}
+	}
+	for i := range previous {
+		if key := previous[i].Key(); !seen[key] {
+			delta.Removed = append(delta.Removed, key)
+		}
+	}
+
+	if len(delta.Added) == 0 && len(delta.Removed) == 0 && len(delta.Changed) == 0 {
+		return nil
+	}
+	return delta
+}
+
+// diffInterfaces porównuje interfejsy sieciowe według nazwy
+func diffInterfaces(previous, current map[string]models.NetworkInterface) *models.InterfaceDelta {
+	delta := &models.InterfaceDelta{}
+	for name, iface := range current {
+		if prev, ok := previous[name]; !ok {
+			delta.Added = append(delta.Added, iface)
+		} else if !reflect.DeepEqual(prev, iface) {
+			delta.Changed = append(delta.Changed, iface)
+		}
+	}
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			delta.Removed = append(delta.Removed, name)
+		}
+	}
+
+	// Mapa nie ma kolejności, więc sortuj wyniki dla powtarzalności
+	sort.Slice(delta.Added, func(i, j int) bool { return delta.Added[i].Name < delta.Added[j].Name })
+	sort.Slice(delta.Changed, func(i, j int) bool { return delta.Changed[i].Name < delta.Changed[j].Name })
+	sort.Strings(delta.Removed)
+
+	if len(delta.Added) == 0 && len(delta.Removed) == 0 && len(delta.Changed) == 0 {
+		return nil
+	}
+	return delta
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+// testState tworzy stan systemu z podanymi procesami i jednym dyskiem
+func testState(timestamp string, processes ...models.Process) *models.SystemState {
+	return &models.SystemState{
+		Timestamp: timestamp,
+		Hardware: &models.Hardware{
+			Hostname: "test-host",
+			Disks:    []models.Disk{{Mountpoint: "/", Percent: 10}},
+			Network: map[string]models.NetworkInterface{
+				"eth0": {Name: "eth0", Addresses: []string{"10.0.0.1/24"}},
+			},
+		},
+		Processes: processes,
+	}
+}
+
+func TestDeltaEncoderSequence(t *testing.T) {
+	encoder := NewDeltaEncoder(3)
+
+	kinds := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		update := encoder.Encode(testState("t"))
+		if update.Sequence != uint64(i+1) {
+			t.Errorf("Niepoprawny numer aktualizacji: got %v, want %v", update.Sequence, i+1)
+		}
+		kinds = append(kinds, update.Kind)
+	}
+
+	want := []string{"full", "delta", "delta", "full", "delta"}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("Niepoprawne rodzaje aktualizacji: got %v, want %v", kinds, want)
+		}
+	}
+
+	// Żądanie VM Bridge wymusza pełny stan
+	encoder.RequestResync()
+	if update := encoder.Encode(testState("t")); update.Kind != models.UpdateKindFull {
+		t.Errorf("Oczekiwano pełnego stanu po żądaniu resynchronizacji, got %v", update.Kind)
+	}
+}
+
+func TestComputeDelta(t *testing.T) {
+	initProc := models.Process{PID: 1, Name: "init", CreateTime: "2025-01-01T00:00:00Z"}
+	nginx := models.Process{PID: 100, Name: "nginx", CreateTime: "2025-01-01T00:00:10Z"}
+	ollama := models.Process{PID: 200, Name: "ollama", CreateTime: "2025-01-01T00:01:00Z"}
+
+	previous := testState("t1", initProc, nginx)
+	current := testState("t2", initProc, ollama)
+	current.Hardware.Disks = append(current.Hardware.Disks, models.Disk{Mountpoint: "/data"})
+	current.Hardware.Network["eth0"] = models.NetworkInterface{Name: "eth0", Addresses: []string{"10.0.0.2/24"}}
+	current.Models = []models.ModelArtifact{{Path: "/models/llama.gguf", Format: "gguf", PIDs: []int32{200}}}
+	current.InferenceServers = []models.InferenceServer{{Kind: "ollama", Endpoint: "http://127.0.0.1:11434", PID: 200}}
+
+	delta := ComputeDelta(previous, current)
+
+	if delta.Processes == nil {
+		t.Fatal("Brak zmian procesów")
+	}
+	if len(delta.Processes.Added) != 1 || delta.Processes.Added[0].Name != "ollama" {
+		t.Errorf("Niepoprawne dodane procesy: got %+v", delta.Processes.Added)
+	}
+	if len(delta.Processes.Removed) != 1 || delta.Processes.Removed[0] != nginx.Key() {
+		t.Errorf("Niepoprawne usunięte procesy: got %v, want [%s]", delta.Processes.Removed, nginx.Key())
+	}
+	if len(delta.Processes.Changed) != 0 {
+		t.Errorf("Nieoczekiwane zmienione procesy: got %+v", delta.Processes.Changed)
+	}
+	if delta.Disks == nil || len(delta.Disks.Added) != 1 || delta.Disks.Added[0].Mountpoint != "/data" {
+		t.Errorf("Niepoprawne zmiany dysków: got %+v", delta.Disks)
+	}
+	if delta.Interfaces == nil || len(delta.Interfaces.Changed) != 1 {
+		t.Errorf("Niepoprawne zmiany interfejsów: got %+v", delta.Interfaces)
+	}
+	if delta.Hardware == nil || delta.Hardware.Disks != nil || delta.Hardware.Network != nil {
+		t.Errorf("Sprzęt w aktualizacji nie powinien zawierać dysków ani interfejsów: got %+v", delta.Hardware)
+	}
+	if delta.Services != nil {
+		t.Errorf("Nieoczekiwane zmiany usług: got %+v", delta.Services)
+	}
+	// Lista modeli jest przesyłana w całości
+	if len(delta.Models) != 1 || delta.Models[0].Path != "/models/llama.gguf" {
+		t.Errorf("Niepoprawne modele w aktualizacji: got %+v", delta.Models)
+	}
+	if len(delta.InferenceServers) != 1 || delta.InferenceServers[0].Kind != "ollama" {
+		t.Errorf("Niepoprawne serwery inferencji w aktualizacji: got %+v", delta.InferenceServers)
+	}
+}
+
+func TestDeltaEncoderCollectorErrors(t *testing.T) {
+	nginx := models.Process{PID: 100, Name: "nginx", CreateTime: "2025-01-01T00:00:10Z"}
+	ollama := models.Process{PID: 200, Name: "ollama", CreateTime: "2025-01-01T00:01:00Z"}
+	withServices := func(state *models.SystemState) *models.SystemState {
+		state.Services = []models.Service{
+			{Name: "nginx.service", Type: "systemd"},
+			{Name: "vllm", Type: "docker", ID: "abc123"},
+		}
+		return state
+	}
+
+	encoder := NewDeltaEncoder(10)
+	encoder.Encode(withServices(testState("t1", nginx, ollama)))
+
+	// Kolektory procesów i Dockera przekroczyły limit czasu: ich części stanu są puste
+	partial := testState("t2")
+	partial.Services = []models.Service{{Name: "nginx.service", Type: "systemd"}}
+	partial.Collection = &models.CollectionReport{Errors: map[string]string{
+		"processes": "przekroczono limit czasu",
+		"docker":    "przekroczono limit czasu",
+	}}
+	update := encoder.Encode(partial)
+	if update.Kind != models.UpdateKindDelta {
+		t.Fatalf("Oczekiwano aktualizacji przyrostowej, got %v", update.Kind)
+	}
+	if update.Delta.Processes != nil || update.Delta.Services != nil {
+		t.Errorf("Niepełny stan nie może usuwać procesów ani usług: got %+v, %+v", update.Delta.Processes, update.Delta.Services)
+	}
+
+	// Po udanym zbieraniu wysyłane są tylko rzeczywiste zmiany
+	update = encoder.Encode(withServices(testState("t3", ollama)))
+	if update.Delta.Processes == nil || len(update.Delta.Processes.Added) != 0 || len(update.Delta.Processes.Removed) != 1 || update.Delta.Processes.Removed[0] != nginx.Key() {
+		t.Errorf("Niepoprawne zmiany procesów po udanym zbieraniu: got %+v", update.Delta.Processes)
+	}
+	if update.Delta.Services != nil {
+		t.Errorf("Nieoczekiwane zmiany usług po udanym zbieraniu: got %+v", update.Delta.Services)
+	}
+}
+
+func TestSendStateResync(t *testing.T) {
+	// VM Bridge żąda pełnego stanu przy pierwszej aktualizacji przyrostowej
+	var kinds []string
+	resyncSent := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var update models.StateUpdate
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			t.Errorf("Błąd dekodowania JSON: %v", err)
+		}
+		kinds = append(kinds, update.Kind)
+
+		if update.Kind == models.UpdateKindDelta && !resyncSent {
+			resyncSent = true
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	sender := NewSender(server.URL)
+	sender.Delta = NewDeltaEncoder(10)
+
+	for _, ts := range []string{"t1", "t2", "t3"} {
+		if err := sender.SendState(testState(ts)); err != nil {
+			t.Fatalf("Błąd wysyłania stanu %s: %v", ts, err)
+		}
+	}
+
+	want := []string{"full", "delta", "full", "delta"}
+	if len(kinds) != len(want) {
+		t.Fatalf("Niepoprawne aktualizacje: got %v, want %v", kinds, want)
+	}
+	for i := range want {
+		if kinds[i] != want[i] {
+			t.Fatalf("Niepoprawne aktualizacje: got %v, want %v", kinds, want)
+		}
+	}
+}
@@ -5,10 +5,21 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 )
 
-// ConfigureLogger konfiguruje logger do zapisywania logów do pliku i na standardowe wyjście
+// Plik logów otwarty przez ostatnie wywołanie ConfigureLogger
+var (
+	logFileMu  sync.Mutex
+	openedFile *os.File
+)
+
+// ConfigureLogger konfiguruje logger do zapisywania logów do pliku i na standardowe wyjście.
+// Może być wywoływana ponownie (np. po przeładowaniu konfiguracji); poprzedni plik logów jest zamykany.
 func ConfigureLogger(logFile string, verbose bool) error {
+	logFileMu.Lock()
+	defer logFileMu.Unlock()
+
 	// Upewnij się, że katalog logów istnieje
 	logDir := filepath.Dir(logFile)
 	if err := os.MkdirAll(logDir, 0755); err != nil {
@@ -34,6 +45,12 @@ func ConfigureLogger(logFile string, verbose bool) error {
 	// Ustaw format logów
 	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
 
+	// Zamknij plik logów z poprzedniej konfiguracji
+	if openedFile != nil {
+		openedFile.Close()
+	}
+	openedFile = file
+
 	return nil
 }
 
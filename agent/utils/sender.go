@@ -3,18 +3,34 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"os"
+	"path/filepath"
+	"sort"
 	"time"
 
 	"gitlab.com/safetytwin/safetytwin/agent/models"
 )
 
+// ErrPayloadRejected oznacza, że VM Bridge odrzucił dane jako niepoprawne;
+// ponowne wysłanie tych samych danych nie ma sensu
+var ErrPayloadRejected = errors.New("VM Bridge odrzucił dane")
+
+// ErrResyncRequested oznacza, że VM Bridge wykrył lukę w numeracji aktualizacji
+// przyrostowych i oczekuje pełnego stanu
+var ErrResyncRequested = errors.New("VM Bridge zażądał pełnego stanu")
+
 // Sender odpowiada za wysyłanie danych do VM Bridge
 type Sender struct {
 	URL        string
 	HTTPClient *http.Client
+	// Spool przechowuje stany, których nie udało się dostarczyć (opcjonalna)
+	Spool *Spool
+	// Delta włącza wysyłanie aktualizacji przyrostowych zamiast pełnych stanów (opcjonalny)
+	Delta *DeltaEncoder
 }
 
 // NewSender tworzy nowy obiekt Sender
@@ -27,14 +43,73 @@ func NewSender(url string) *Sender {
 	}
 }
 
-// SendState wysyła stan systemu do VM Bridge
+// SendState wysyła stan systemu do VM Bridge. Jeśli Sender ma kolejkę, najpierw
+// wysyłane są oczekujące w niej stany, a stan, którego nie udało się dostarczyć,
+// trafia na koniec kolejki, dzięki czemu VM Bridge otrzymuje stany w kolejności zebrania.
 func (s *Sender) SendState(state *models.SystemState) error {
+	// Najpierw dostarcz stany oczekujące w kolejce
+	var replayErr error
+	if s.Spool != nil {
+		var replayed int
+		replayed, replayErr = s.Spool.Replay(s.send)
+		if replayed > 0 {
+			log.Printf("Dostarczono %d stanów z kolejki", replayed)
+		}
+	}
+
 	// Serializuj stan do JSON
-	jsonData, err := json.Marshal(state)
+	jsonData, err := s.encode(state)
 	if err != nil {
-		return fmt.Errorf("nie można serializować stanu systemu: %v", err)
+		return err
 	}
 
+	err = replayErr
+	if err == nil {
+		err = s.send(jsonData)
+		if errors.Is(err, ErrResyncRequested) {
+			// VM Bridge wykrył lukę: wyślij ten sam stan jako pełny
+			if jsonData, err = s.encode(state); err != nil {
+				return err
+			}
+			err = s.send(jsonData)
+		}
+		if err == nil || isPayloadRejected(err) || s.Spool == nil {
+			return err
+		}
+	}
+
+	// Zachowaj stan w kolejce do ponownego wysłania
+	if qErr := s.Spool.Enqueue(jsonData); qErr != nil {
+		return fmt.Errorf("%v; nie można dodać stanu do kolejki: %v", err, qErr)
+	}
+	return fmt.Errorf("%v; stan dodany do kolejki (oczekujące: %d)", err, s.Spool.Len())
+}
+
+// encode serializuje stan jako pełny dokument lub, w trybie przyrostowym, jako kolejną aktualizację
+func (s *Sender) encode(state *models.SystemState) ([]byte, error) {
+	var payload interface{} = state
+	if s.Delta != nil {
+		payload = s.Delta.Encode(state)
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("nie można serializować stanu systemu: %v", err)
+	}
+	return jsonData, nil
+}
+
+// send wysyła dokument do VM Bridge i odnotowuje żądanie pełnego stanu
+func (s *Sender) send(jsonData []byte) error {
+	err := s.post(jsonData)
+	if errors.Is(err, ErrResyncRequested) && s.Delta != nil {
+		s.Delta.RequestResync()
+	}
+	return err
+}
+
+// post wysyła pojedynczy dokument JSON do VM Bridge
+func (s *Sender) post(jsonData []byte) error {
 	// Utwórz request
 	req, err := http.NewRequest("POST", s.URL, bytes.NewBuffer(jsonData))
 	if err != nil {
@@ -52,14 +127,37 @@ func (s *Sender) SendState(state *models.SystemState) error {
 	}
 	defer resp.Body.Close()
 
+	// VM Bridge zgłasza lukę w aktualizacjach kodem 409 lub polem "resync" w odpowiedzi
+	if resp.StatusCode == http.StatusConflict {
+		return fmt.Errorf("%w: %s", ErrResyncRequested, resp.Status)
+	}
+	var reply struct {
+		Resync bool `json:"resync"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&reply); err == nil && reply.Resync {
+		return fmt.Errorf("%w: %s", ErrResyncRequested, resp.Status)
+	}
+
 	// Sprawdź kod odpowiedzi
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		// Błędy klienta (poza przekroczeniem czasu i limitem żądań) są trwałe
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+			resp.StatusCode != http.StatusRequestTimeout &&
+			resp.StatusCode != http.StatusTooManyRequests {
+			return fmt.Errorf("%w: %s", ErrPayloadRejected, resp.Status)
+		}
 		return fmt.Errorf("serwer zwrócił błąd: %s", resp.Status)
 	}
 
 	return nil
 }
 
+// isPayloadRejected sprawdza, czy dokumentu nie należy wysyłać ponownie: został
+// odrzucony albo jest aktualizacją przyrostową, której VM Bridge nie może zastosować
+func isPayloadRejected(err error) bool {
+	return errors.Is(err, ErrPayloadRejected) || errors.Is(err, ErrResyncRequested)
+}
+
 // SaveStateToFile zapisuje stan systemu do pliku JSON
 func SaveStateToFile(state *models.SystemState, stateDir string) error {
 	// Utwórz nazwę pliku na podstawie aktualnego czasu
@@ -79,3 +177,31 @@ func SaveStateToFile(state *models.SystemState, stateDir string) error {
 
 	return nil
 }
+
+// LoadStateFromFile wczytuje stan systemu zapisany w pliku JSON
+func LoadStateFromFile(path string) (*models.SystemState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("nie można odczytać pliku stanu: %v", err)
+	}
+
+	var state models.SystemState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("nie można sparsować pliku stanu %s: %v", path, err)
+	}
+
+	return &state, nil
+}
+
+// ListStateFiles zwraca pliki stanów zapisane przez SaveStateToFile,
+// posortowane od najstarszego do najnowszego
+func ListStateFiles(stateDir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(stateDir, "state-*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("nie można odczytać katalogu stanów: %v", err)
+	}
+
+	// Nazwy plików zawierają znacznik czasu, więc kolejność leksykalna jest chronologiczna
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"gitlab.com/safetytwin/safetytwin/agent/models"
+)
+
+func TestSendStateSpoolsWhileBridgeIsDown(t *testing.T) {
+	// Testowy VM Bridge, który można wyłączać
+	var mu sync.Mutex
+	available := false
+	var received []string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var state models.SystemState
+		if err := json.NewDecoder(r.Body).Decode(&state); err != nil {
+			t.Errorf("Błąd dekodowania JSON: %v", err)
+		}
+		received = append(received, state.Timestamp)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	spool, err := NewSpool(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+	sender := NewSender(server.URL)
+	sender.Spool = spool
+
+	// Bridge niedostępny: stany trafiają do kolejki
+	for _, ts := range []string{"t1", "t2"} {
+		if err := sender.SendState(&models.SystemState{Timestamp: ts}); err == nil {
+			t.Fatalf("Oczekiwano błędu wysyłania stanu %s", ts)
+		}
+	}
+	if spool.Len() != 2 {
+		t.Fatalf("Niepoprawna długość kolejki: got %v, want 2", spool.Len())
+	}
+
+	// Bridge wraca: zaległe stany są wysyłane przed bieżącym
+	mu.Lock()
+	available = true
+	mu.Unlock()
+
+	if err := sender.SendState(&models.SystemState{Timestamp: "t3"}); err != nil {
+		t.Fatalf("Błąd wysyłania stanu: %v", err)
+	}
+
+	want := []string{"t1", "t2", "t3"}
+	if len(received) != len(want) {
+		t.Fatalf("Niepoprawna liczba otrzymanych stanów: got %v, want %v", received, want)
+	}
+	for i := range want {
+		if received[i] != want[i] {
+			t.Errorf("Niepoprawna kolejność stanów: got %v, want %v", received, want)
+			break
+		}
+	}
+	if spool.Len() != 0 {
+		t.Errorf("Kolejka powinna być pusta, got %v", spool.Len())
+	}
+}
+
+func TestSendStateRejectedIsNotSpooled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	spool, err := NewSpool(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+	sender := NewSender(server.URL)
+	sender.Spool = spool
+
+	if err := sender.SendState(&models.SystemState{Timestamp: "t1"}); err == nil {
+		t.Fatal("Oczekiwano błędu odrzucenia stanu")
+	}
+	if spool.Len() != 0 {
+		t.Errorf("Odrzucony stan nie powinien trafić do kolejki, got %v", spool.Len())
+	}
+}
+
+func TestLoadStateFromFile(t *testing.T) {
+	dir := t.TempDir()
+
+	state := models.NewSystemState()
+	state.Timestamp = "2025-01-01T00:00:00Z"
+	state.Hardware.Hostname = "test-host"
+	if err := SaveStateToFile(state, dir); err != nil {
+		t.Fatalf("SaveStateToFile() error = %v", err)
+	}
+
+	files, err := ListStateFiles(dir)
+	if err != nil {
+		t.Fatalf("ListStateFiles() error = %v", err)
+	}
+	if len(files) != 1 {
+		t.Fatalf("Niepoprawna liczba plików stanu: got %v, want %v", len(files), 1)
+	}
+
+	loaded, err := LoadStateFromFile(files[0])
+	if err != nil {
+		t.Fatalf("LoadStateFromFile() error = %v", err)
+	}
+	if loaded.Timestamp != state.Timestamp || loaded.Hardware.Hostname != "test-host" {
+		t.Errorf("Niepoprawny wczytany stan: got %+v", loaded)
+	}
+
+	if _, err := LoadStateFromFile(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Oczekiwano błędu dla nieistniejącego pliku")
+	}
+}
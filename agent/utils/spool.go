@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Spool to trwała kolejka niedostarczonych stanów systemu. Każdy stan zapisywany
+// jest w osobnym pliku o rosnącym numerze, więc kolejność jest zachowana także
+// po restarcie agenta. Po przekroczeniu limitu rozmiaru usuwane są najstarsze wpisy.
+type Spool struct {
+	dir      string
+	maxBytes int64
+
+	mu      sync.Mutex
+	nextSeq uint64
+
+	// replayMu zapobiega równoczesnemu odtwarzaniu; mu jest zwalniany na czas wysyłania
+	replayMu sync.Mutex
+}
+
+// spoolEntry opisuje pojedynczy wpis kolejki
+type spoolEntry struct {
+	seq  uint64
+	path string
+	size int64
+}
+
+// NewSpool otwiera (lub tworzy) kolejkę w podanym katalogu.
+// maxBytes <= 0 oznacza brak limitu rozmiaru.
+func NewSpool(dir string, maxBytes int64) (*Spool, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("nie można utworzyć katalogu kolejki %s: %w", dir, err)
+	}
+
+	s := &Spool{
+		dir:      dir,
+		maxBytes: maxBytes,
+		nextSeq:  1,
+	}
+
+	// Kontynuuj numerację po wpisach pozostałych z poprzedniego uruchomienia
+	entries, err := s.entries()
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) > 0 {
+		s.nextSeq = entries[len(entries)-1].seq + 1
+	}
+
+	return s, nil
+}
+
+// Enqueue dopisuje dane na koniec kolejki
+func (s *Spool) Enqueue(payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := fmt.Sprintf("%020d.json", s.nextSeq)
+	tmpPath := filepath.Join(s.dir, name+".tmp")
+	path := filepath.Join(s.dir, name)
+
+	// Zapisz do pliku tymczasowego i zmień nazwę, aby nie zostawić niepełnego wpisu
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("nie można utworzyć wpisu kolejki %s: %w", tmpPath, err)
+	}
+	if _, err := file.Write(payload); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("nie można zapisać wpisu kolejki %s: %w", tmpPath, err)
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("nie można zsynchronizować wpisu kolejki %s: %w", tmpPath, err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("nie można zamknąć wpisu kolejki %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("nie można dodać wpisu do kolejki %s: %w", path, err)
+	}
+	s.nextSeq++
+
+	return s.enforceLimit()
+}
+
+// Replay wysyła wpisy kolejki w kolejności dodania za pomocą funkcji send.
+// Wpis jest usuwany po udanym wysłaniu; pierwszy błąd przerywa odtwarzanie
+// i pozostawia w kolejce ten i wszystkie późniejsze wpisy. Wpisy odrzucone
+// przez VM Bridge (ErrPayloadRejected) są usuwane, aby nie blokowały kolejki.
+// Zwraca liczbę usuniętych wpisów.
+//
+// Blokada kolejki nie jest trzymana podczas wysyłania, więc Enqueue, Len i Size
+// nie czekają na zapytania do VM Bridge w trakcie odtwarzania.
+func (s *Spool) Replay(send func(payload []byte) error) (int, error) {
+	s.replayMu.Lock()
+	defer s.replayMu.Unlock()
+
+	s.mu.Lock()
+	entries, err := s.entries()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, entry := range entries {
+		payload, err := os.ReadFile(entry.path)
+		if os.IsNotExist(err) {
+			// Wpis usunięty w międzyczasie przez limit rozmiaru kolejki
+			continue
+		}
+		if err != nil {
+			return replayed, fmt.Errorf("nie można odczytać wpisu kolejki %s: %w", entry.path, err)
+		}
+
+		if err := send(payload); err != nil {
+			if !isPayloadRejected(err) {
+				return replayed, err
+			}
+			log.Printf("VM Bridge odrzucił wpis kolejki %s, usuwam: %v", entry.path, err)
+		}
+
+		s.mu.Lock()
+		err = os.Remove(entry.path)
+		s.mu.Unlock()
+		if err != nil && !os.IsNotExist(err) {
+			return replayed, fmt.Errorf("nie można usunąć wpisu kolejki %s: %w", entry.path, err)
+		}
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// Len zwraca liczbę wpisów oczekujących w kolejce
+func (s *Spool) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
+
+// Size zwraca łączny rozmiar wpisów oczekujących w kolejce w bajtach
+func (s *Spool) Size() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return 0
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.size
+	}
+	return total
+}
+
+// enforceLimit usuwa najstarsze wpisy, dopóki kolejka przekracza limit rozmiaru.
+// Najnowszy wpis jest zawsze zachowywany.
+func (s *Spool) enforceLimit() error {
+	if s.maxBytes <= 0 {
+		return nil
+	}
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+
+	var total int64
+	for _, entry := range entries {
+		total += entry.size
+	}
+
+	dropped := 0
+	for i := 0; i < len(entries)-1 && total > s.maxBytes; i++ {
+		if err := os.Remove(entries[i].path); err != nil {
+			return fmt.Errorf("nie można usunąć najstarszego wpisu kolejki %s: %w", entries[i].path, err)
+		}
+		total -= entries[i].size
+		dropped++
+	}
+
+	if dropped > 0 {
+		log.Printf("Kolejka %s przekroczyła limit %d bajtów, usunięto %d najstarszych wpisów", s.dir, s.maxBytes, dropped)
+	}
+
+	return nil
+}
+
+// entries zwraca wpisy kolejki posortowane od najstarszego
+func (s *Spool) entries() ([]spoolEntry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("nie można odczytać katalogu kolejki %s: %w", s.dir, err)
+	}
+
+	entries := make([]spoolEntry, 0, len(files))
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, ".json"), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		info, err := file.Info()
+		if err != nil {
+			continue
+		}
+
+		entries = append(entries, spoolEntry{
+			seq:  seq,
+			path: filepath.Join(s.dir, name),
+			size: info.Size(),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+
+	return entries, nil
+}
@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSpoolReplayOrder(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := spool.Enqueue([]byte(fmt.Sprintf(`{"n":%d}`, i))); err != nil {
+			t.Fatalf("Błąd dodawania do kolejki: %v", err)
+		}
+	}
+
+	if spool.Len() != 3 {
+		t.Errorf("Niepoprawna długość kolejki: got %v, want 3", spool.Len())
+	}
+
+	var replayed []string
+	count, err := spool.Replay(func(payload []byte) error {
+		replayed = append(replayed, string(payload))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Błąd odtwarzania kolejki: %v", err)
+	}
+
+	want := []string{`{"n":1}`, `{"n":2}`, `{"n":3}`}
+	if count != len(want) {
+		t.Errorf("Niepoprawna liczba odtworzonych wpisów: got %v, want %v", count, len(want))
+	}
+	for i := range want {
+		if i >= len(replayed) || replayed[i] != want[i] {
+			t.Fatalf("Niepoprawna kolejność: got %v, want %v", replayed, want)
+		}
+	}
+	if spool.Len() != 0 {
+		t.Errorf("Kolejka powinna być pusta, got %v", spool.Len())
+	}
+}
+
+func TestSpoolReplayDoesNotBlock(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+	spool.Enqueue([]byte(`{"n":1}`))
+	spool.Enqueue([]byte(`{"n":2}`))
+
+	// Podczas wysyłania kolejka przyjmuje nowe wpisy i podaje swój stan
+	depths := []int{}
+	count, err := spool.Replay(func(payload []byte) error {
+		if string(payload) == `{"n":1}` {
+			if err := spool.Enqueue([]byte(`{"n":3}`)); err != nil {
+				return err
+			}
+		}
+		depths = append(depths, spool.Len())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Błąd odtwarzania kolejki: %v", err)
+	}
+	if count != 2 || len(depths) != 2 || depths[0] != 3 || depths[1] != 2 {
+		t.Errorf("Niepoprawne odtwarzanie: got %v wpisów, głębokości %v", count, depths)
+	}
+	// Wpis dodany w trakcie odtwarzania czeka na kolejne
+	if spool.Len() != 1 {
+		t.Errorf("Niepoprawna długość kolejki: got %v, want 1", spool.Len())
+	}
+}
+
+func TestSpoolReplayStopsOnError(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+	for i := 1; i <= 3; i++ {
+		spool.Enqueue([]byte(fmt.Sprintf(`{"n":%d}`, i)))
+	}
+
+	// Druga próba kończy się błędem
+	calls := 0
+	count, err := spool.Replay(func(payload []byte) error {
+		calls++
+		if calls == 2 {
+			return errors.New("bridge niedostępny")
+		}
+		return nil
+	})
+	if err == nil {
+		t.Fatal("Oczekiwano błędu odtwarzania")
+	}
+	if count != 1 {
+		t.Errorf("Niepoprawna liczba odtworzonych wpisów: got %v, want 1", count)
+	}
+	if spool.Len() != 2 {
+		t.Errorf("Niepoprawna długość kolejki: got %v, want 2", spool.Len())
+	}
+
+	// Odrzucone wpisy są usuwane i nie blokują kolejki
+	count, err = spool.Replay(func(payload []byte) error {
+		if string(payload) == `{"n":2}` {
+			return fmt.Errorf("%w: 400 Bad Request", ErrPayloadRejected)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Błąd odtwarzania kolejki: %v", err)
+	}
+	if count != 2 || spool.Len() != 0 {
+		t.Errorf("Oczekiwano opróżnienia kolejki: odtworzono %v, pozostało %v", count, spool.Len())
+	}
+}
+
+func TestSpoolSizeLimit(t *testing.T) {
+	spool, err := NewSpool(t.TempDir(), 25)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+
+	// Każdy wpis ma 10 bajtów, limit mieści dwa
+	for i := 0; i < 5; i++ {
+		spool.Enqueue([]byte(fmt.Sprintf("payload-%02d", i)))
+	}
+
+	if spool.Len() != 2 {
+		t.Errorf("Niepoprawna długość kolejki: got %v, want 2", spool.Len())
+	}
+
+	var replayed []string
+	spool.Replay(func(payload []byte) error {
+		replayed = append(replayed, string(payload))
+		return nil
+	})
+	if len(replayed) != 2 || replayed[0] != "payload-03" || replayed[1] != "payload-04" {
+		t.Errorf("Powinny zostać najnowsze wpisy: got %v", replayed)
+	}
+}
+
+func TestSpoolPersistsAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := NewSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Błąd tworzenia kolejki: %v", err)
+	}
+	spool.Enqueue([]byte("first"))
+
+	// Ponowne otwarcie kontynuuje numerację
+	spool, err = NewSpool(dir, 0)
+	if err != nil {
+		t.Fatalf("Błąd ponownego otwarcia kolejki: %v", err)
+	}
+	spool.Enqueue([]byte("second"))
+
+	var replayed []string
+	spool.Replay(func(payload []byte) error {
+		replayed = append(replayed, string(payload))
+		return nil
+	})
+	if len(replayed) != 2 || replayed[0] != "first" || replayed[1] != "second" {
+		t.Errorf("Niepoprawna kolejność po restarcie: got %v", replayed)
+	}
+}